@@ -69,6 +69,25 @@ func getOneDocument(response http.ResponseWriter, request *http.Request) {
 		return
 	}
 
+	// Extract query parameters
+	qpNameServings := "servings"
+	queryParams := request.URL.Query()
+	qpServings := queryParams.Get(qpNameServings)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	var servings int
+	if qpServings != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameServings, "value": qpServings})
+		l.Trace("Query parameter handling")
+		servings, err = parseServings(qpServings)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse servings")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+
 	// Create filter
 	filter := bson.D{{"_id", oid}}
 	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
@@ -95,6 +114,12 @@ func getOneDocument(response http.ResponseWriter, request *http.Request) {
 	// Check if document is a recipe
 	if collection == config.MongoCollectionRecipes {
 		log.Trace("Begin recipe scan")
+		// Scale ingredient amounts to the requested number of servings
+		if servings > 0 {
+			factor := scaleRecipe(document, servings)
+			log.WithFields(logrus.Fields{"factor": factor, "servings": servings}).Debug("Scaled recipe")
+		}
+
 		// Check if recipe can be made (i.e. associated ingredients are stocked and not expiring)
 		originalCanMake := (*document)["isCookable"].(bool)
 		isCookable, err := isCookable(ctx, document)
@@ -104,6 +129,9 @@ func getOneDocument(response http.ResponseWriter, request *http.Request) {
 			response.WriteHeader(http.StatusInternalServerError)
 			response.Write([]byte(err.Error()))
 			return
+		} else if servings > 0 {
+			// Scaled results are not persisted
+			(*document)["isCookable"] = isCookable
 		} else if isCookable != originalCanMake {
 			// Update if different
 			log.WithFields(logrus.Fields{"original": originalCanMake, "updated": isCookable}).Debug("Updating isCookable")
@@ -129,6 +157,34 @@ func getOneDocument(response http.ResponseWriter, request *http.Request) {
 				l.WithFields(logrus.Fields{"method": "PUT", "quantity": matched, "status": http.StatusOK}).Info("Succeeded")
 			}
 		}
+
+		// Attach nutrition totals, if any ingredient has nutrition facts
+		nutrition, err := recipeNutrition(ctx, document)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to compute nutrition")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		} else if nutrition != nil {
+			(*document)["nutrition"] = nutrition
+		}
+
+		// Attach cost estimates, if any ingredient has been bought before. The recipe is still served without them
+		prices, err := recipesPrices(ctx, []bson.M{*document})
+		if err != nil {
+			log.WithError(err).Warn("Failed to look up prices")
+		} else if cost := recipeCost(document, prices); cost != nil {
+			(*document)["cost"] = cost
+		}
+
+		// Attach dietary tags derived from the ingredients
+		_, err = tagRecipes(ctx, []bson.M{*document}, nil, nil)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to derive dietary tags")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		}
 		log.Trace("End recipe scan")
 	}
 
@@ -181,9 +237,12 @@ func getManyDocuments(response http.ResponseWriter, request *http.Request) {
 
 	// Extract query parameters
 	qpNameCookable := "isCookable"
+	qpNameDiet := "diet"
+	qpNameExcludeAllergens := "excludeAllergens"
 	qpNameFrom := "from"
 	qpNameHaveStocked := "haveStocked"
 	qpNameName := "name"
+	qpNameServings := "servings"
 	qpNameTo := "to"
 	queryParams := request.URL.Query()
 	qpName := queryParams.Get(qpNameName)
@@ -191,6 +250,10 @@ func getManyDocuments(response http.ResponseWriter, request *http.Request) {
 
 	// Check if query parameters are present
 	var filter bson.M
+	var servings int
+	var wantCookable *bool
+	var diets []string
+	var excludeAllergens []string
 	filterName := bson.M{}
 	if qpName != "" {
 		l := log.WithFields(logrus.Fields{"name": qpNameName, "value": qpName})
@@ -213,6 +276,7 @@ func getManyDocuments(response http.ResponseWriter, request *http.Request) {
 				response.Write([]byte(err.Error()))
 				return
 			} else {
+				wantCookable = &b
 				filterIsCookable = bson.M{
 					"isCookable": bson.M{
 						"$eq": b,
@@ -221,6 +285,27 @@ func getManyDocuments(response http.ResponseWriter, request *http.Request) {
 			}
 		}
 
+		qpServings := queryParams.Get(qpNameServings)
+		if qpServings != "" {
+			l := log.WithFields(logrus.Fields{"name": qpNameServings, "value": qpServings})
+			l.Trace("Query parameter handling")
+			servings, err = parseServings(qpServings)
+			if err != nil {
+				// Invalid query parameter value provided
+				l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse servings")
+				response.WriteHeader(http.StatusBadRequest)
+				response.Write([]byte(err.Error()))
+				return
+			}
+
+			// Cookability depends on the scaled amounts, so filter after scanning
+			filterIsCookable = bson.M{}
+		}
+
+		// Dietary restrictions are applied after scanning
+		diets = splitTags(queryParams.Get(qpNameDiet))
+		excludeAllergens = splitTags(queryParams.Get(qpNameExcludeAllergens))
+
 		// Create filter
 		filter = bson.M{"$and": []bson.M{
 			filterName,
@@ -331,10 +416,24 @@ func getManyDocuments(response http.ResponseWriter, request *http.Request) {
 	// Check if document is a recipe
 	if collection == config.MongoCollectionRecipes {
 		log.Trace("Begin recipe scan")
+
+		// Look up prices for every recipe at once. The recipes are still served without cost estimates
+		prices, err := recipesPrices(ctx, documents)
+		if err != nil {
+			log.WithError(err).Warn("Failed to look up prices")
+		}
+
+		scanned := []bson.M{}
 		for _, document := range documents {
-			// Check if recipe can be made (i.e. associated ingredients are stocked and not expiring)
+			// Scale ingredient amounts to the requested number of servings
 			id := document["_id"]
 			l := log.WithFields(logrus.Fields{"recipe": id})
+			if servings > 0 {
+				factor := scaleRecipe(&document, servings)
+				l.WithFields(logrus.Fields{"factor": factor, "servings": servings}).Debug("Scaled recipe")
+			}
+
+			// Check if recipe can be made (i.e. associated ingredients are stocked and not expiring)
 			originalCanMake := document["isCookable"].(bool)
 			isCookable, err := isCookable(ctx, &document)
 			if err != nil {
@@ -343,6 +442,12 @@ func getManyDocuments(response http.ResponseWriter, request *http.Request) {
 				response.WriteHeader(http.StatusInternalServerError)
 				response.Write([]byte(err.Error()))
 				return
+			} else if servings > 0 {
+				// Scaled results are not persisted
+				document["isCookable"] = isCookable
+				if wantCookable != nil && *wantCookable != isCookable {
+					continue
+				}
 			} else if isCookable != originalCanMake {
 				// Update if different
 				l.WithFields(logrus.Fields{"original": originalCanMake, "updated": isCookable}).Debug("Updating isCookable")
@@ -369,6 +474,34 @@ func getManyDocuments(response http.ResponseWriter, request *http.Request) {
 					l.WithFields(logrus.Fields{"quantity": matched}).Info("Updated recipe")
 				}
 			}
+
+			// Attach nutrition totals, if any ingredient has nutrition facts
+			nutrition, err := recipeNutrition(ctx, &document)
+			if err != nil {
+				l.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to compute nutrition")
+				response.WriteHeader(http.StatusInternalServerError)
+				response.Write([]byte(err.Error()))
+				return
+			} else if nutrition != nil {
+				document["nutrition"] = nutrition
+			}
+
+			// Attach cost estimates, if any ingredient has been bought before
+			if cost := recipeCost(&document, prices); cost != nil {
+				document["cost"] = cost
+			}
+
+			scanned = append(scanned, document)
+		}
+		documents = scanned
+
+		// Attach dietary tags and apply restrictions
+		documents, err = tagRecipes(ctx, documents, excludeAllergens, diets)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to derive dietary tags")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
 		}
 		log.Trace("End recipe scan")
 	}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const errorCalendarToken = "invalid calendar token"
+
+func calendarDocumentID(document bson.M) string {
+	if oid, ok := document["_id"].(primitive.ObjectID); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprint(document["_id"])
+}
+
+// An all-day VEVENT on the day (in loc) of the given epoch milliseconds
+func calendarEvent(uid string, ms float64, loc *time.Location, stamp, summary, description string) []string {
+	day := time.UnixMilli(int64(ms)).In(loc)
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+	lines := []string{
+		"BEGIN:VEVENT",
+		"UID:" + uid,
+		"DTSTAMP:" + stamp,
+		"DTSTART;VALUE=DATE:" + start.Format("20060102"),
+		"DTEND;VALUE=DATE:" + start.AddDate(0, 0, 1).Format("20060102"),
+		"SUMMARY:" + utils.ICalEscape(summary),
+	}
+	if description != "" {
+		lines = append(lines, "DESCRIPTION:"+utils.ICalEscape(description))
+	}
+	return append(lines, "TRANSP:TRANSPARENT", "END:VEVENT")
+}
+
+// A VCALENDAR with an all-day event for each ingredient's expiration date and each planned day of meals
+func calendarFeed(ingredients, plans []bson.M, loc *time.Location, now time.Time) string {
+	stamp := now.UTC().Format("20060102T150405Z")
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Forage//Expirations//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Forage",
+	}
+
+	for _, ingredient := range ingredients {
+		expirationDate, ok := utils.Float64FromNumber(ingredient["expirationDate"])
+		name, _ := ingredient["name"].(string)
+		if !ok || expirationDate <= 0 || name == "" {
+			continue
+		}
+
+		var description string
+		if storeIn, _ := ingredient["storeIn"].(string); storeIn != "" {
+			description = "Stored in " + storeIn
+		}
+		uid := calendarDocumentID(ingredient) + "-expiration@forage"
+		lines = append(lines, calendarEvent(uid, expirationDate, loc, stamp, name+" expires", description)...)
+	}
+
+	for _, plan := range plans {
+		date, ok := utils.Float64FromNumber(plan["date"])
+		if !ok {
+			continue
+		}
+
+		recipes := []string{}
+		for _, entry := range mealPlanEntries(plan) {
+			if planned, ok := parseRecipeIngredient(entry); ok {
+				recipes = append(recipes, planned.Name)
+			}
+		}
+		if len(recipes) == 0 {
+			continue
+		}
+
+		uid := calendarDocumentID(plan) + "-mealplan@forage"
+		lines = append(lines, calendarEvent(uid, date, loc, stamp, "Planned: "+strings.Join(recipes, ", "), "")...)
+	}
+
+	lines = append(lines, "END:VCALENDAR")
+
+	var feed strings.Builder
+	for _, line := range lines {
+		feed.WriteString(utils.ICalFold(line))
+		feed.WriteString("\r\n")
+	}
+	return feed.String()
+}
+
+// Subscribe to expiration dates and planned meals from a calendar app
+func getCalendar(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getCalendar",
+		"method": "GET",
+	})
+	qpNameStoreIn := "storeIn"
+	qpNameToken := "token"
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	qpStoreIn := queryParams.Get(qpNameStoreIn)
+	qpToken := queryParams.Get(qpNameToken)
+
+	// Calendar apps can't send headers, so a shared feed is protected by a token in its URL
+	if configuration.CalendarToken != "" && subtle.ConstantTimeCompare([]byte(qpToken), []byte(configuration.CalendarToken)) != 1 {
+		err := errors.New(errorCalendarToken)
+		log.WithFields(logrus.Fields{"status": http.StatusUnauthorized}).WithError(err).Warn("Failed to verify token")
+		response.WriteHeader(http.StatusUnauthorized)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	loc, err := time.LoadLocation(configuration.Timezone)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError, "timezone": configuration.Timezone}).WithError(err).Error("Failed to obtain timezone")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Filter by stocked food with an expiration date, optionally where it's stored
+	filter := bson.M{
+		"expirationDate": bson.M{"$gt": 0},
+		"haveStocked":    true,
+	}
+	if qpStoreIn != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameStoreIn, "value": qpStoreIn})
+		l.Trace("Query parameter handling")
+		filter["storeIn"] = bson.M{"$in": splitList(qpStoreIn)}
+	}
+	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{"expirationDate", 1}})
+
+	ingredients, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filter, opts)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to identify expiration dates")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	optsPlans := options.Find()
+	optsPlans.SetSort(bson.D{{"date", 1}})
+
+	plans, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionMealPlans, bson.M{}, optsPlans)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to identify planned meals")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	feed := calendarFeed(ingredients, plans, loc, time.Now())
+	log.WithFields(logrus.Fields{"quantity": len(ingredients) + len(plans), "size": len(feed), "status": http.StatusOK}).Info("Succeeded")
+	response.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(feed))
+}
@@ -115,9 +115,33 @@ func putConfiguration(response http.ResponseWriter, request *http.Request) {
 				response.Write([]byte(err.Error()))
 				return
 			} else {
-				configuration.Scheduler.StartAsync()
 				log.Info("Expiration watch job scheduled")
 			}
+
+			// Clearing the scheduler also removed the thaw reminder and sync jobs
+			if configuration.ThawTime != "" {
+				_, err = configuration.Scheduler.Every(1).Day().At(configuration.ThawTime).Do(checkThawing)
+				if err != nil {
+					log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to schedule thaw reminder job")
+					response.WriteHeader(http.StatusInternalServerError)
+					response.Write([]byte(err.Error()))
+					return
+				} else {
+					log.Info("Thaw reminder job scheduled")
+				}
+			}
+			if configuration.SyncInterval > 0 {
+				_, err = configuration.Scheduler.Every(configuration.SyncInterval).Do(syncShoppingList)
+				if err != nil {
+					log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to schedule shopping list sync job")
+					response.WriteHeader(http.StatusInternalServerError)
+					response.Write([]byte(err.Error()))
+					return
+				} else {
+					log.Info("Shopping list sync job scheduled")
+				}
+			}
+			configuration.Scheduler.StartAsync()
 		}
 
 		configuration.Lookahead = body.Lookahead
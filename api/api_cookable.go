@@ -3,10 +3,10 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/sirupsen/logrus"
-	"github.com/tyler-cromwell/forage/config"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/tyler-cromwell/forage/utils"
 )
 
 func getCookable(response http.ResponseWriter, request *http.Request) {
@@ -22,29 +22,45 @@ func getCookable(response http.ResponseWriter, request *http.Request) {
 	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
 	defer log.Trace("End function")
 
-	// Create filter
-	filter := bson.M{"isCookable": true}
-	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
 
-	// Grab the documents
-	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionRecipes, filter, nil)
+	query, err := parseRecipeQuery(queryParams)
 	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse recipe query")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"value": query}).Debug("Recipe query")
+	}
+
+	// Grab the documents
+	documents, _, err := findCookableRecipes(ctx, query)
+	if err != nil && strings.HasPrefix(err.Error(), utils.ErrorMemberNotFound) {
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to find member")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
 		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to identify cookable recipes")
 		response.WriteHeader(http.StatusInternalServerError)
 		response.Write([]byte(err.Error()))
+		return
 	} else {
 		log.WithFields(logrus.Fields{"quantity": len(documents), "value": documents}).Debug("Documents found")
+	}
 
-		// Prepare to respond with documents
-		marshalled, err := json.Marshal(documents)
-		if err != nil {
-			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode documents")
-			response.WriteHeader(http.StatusInternalServerError)
-			response.Write([]byte(err.Error()))
-		} else {
-			log.WithFields(logrus.Fields{"quantity": len(documents), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
-			response.WriteHeader(http.StatusOK)
-			response.Write(marshalled)
-		}
+	// Prepare to respond with documents
+	marshalled, err := json.Marshal(documents)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode documents")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(documents), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
 	}
 }
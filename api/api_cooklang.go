@@ -0,0 +1,466 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var cooklangBlockComment = regexp.MustCompile(`(?s)\[-.*?-\]`)
+var cooklangLineComment = regexp.MustCompile(`--.*`)
+
+// Timer units in seconds
+var cooklangTimeUnits = map[string]int{
+	"h": 3600, "hour": 3600, "hours": 3600, "hr": 3600, "hrs": 3600,
+	"m": 60, "min": 60, "mins": 60, "minute": 60, "minutes": 60,
+	"s": 1, "sec": 1, "secs": 1, "second": 1, "seconds": 1,
+}
+
+type cooklangItem struct {
+	Kind     byte // '@' ingredient, '#' cookware, '~' timer
+	Name     string
+	Quantity string
+	Unit     string
+}
+
+// Read a Cooklang component name and its optional {quantity%unit} starting just after the marker
+func cooklangComponent(text string) (cooklangItem, int) {
+	item := cooklangItem{}
+
+	// Multi-word names are terminated by braces
+	if brace := strings.Index(text, "{"); brace >= 0 && !strings.ContainsAny(text[:brace], "@#~\n") {
+		if close := strings.Index(text[brace:], "}"); close >= 0 {
+			item.Name = strings.TrimSpace(text[:brace])
+			amount := text[brace+1 : brace+close]
+			if i := strings.Index(amount, "%"); i >= 0 {
+				item.Quantity = strings.TrimSpace(amount[:i])
+				item.Unit = strings.TrimSpace(amount[i+1:])
+			} else {
+				item.Quantity = strings.TrimSpace(amount)
+			}
+			return item, brace + close + 1
+		}
+	}
+
+	// Single-word names end at whitespace or punctuation
+	for i, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '-' {
+			item.Name = text[:i]
+			return item, i
+		}
+	}
+	item.Name = text
+	return item, len(text)
+}
+
+// Split a Cooklang step into its plain text and the components it mentions
+func parseCooklangStep(step string) (string, []cooklangItem) {
+	var text strings.Builder
+	items := []cooklangItem{}
+	for i := 0; i < len(step); {
+		marker := step[i]
+		if marker != '@' && marker != '#' && marker != '~' {
+			text.WriteByte(marker)
+			i++
+			continue
+		}
+
+		item, n := cooklangComponent(step[i+1:])
+		item.Kind = marker
+		if item.Name == "" && item.Quantity == "" {
+			// A lone marker is just text
+			text.WriteByte(marker)
+			i++
+			continue
+		}
+		items = append(items, item)
+		i += n + 1
+
+		if marker == '~' && item.Quantity != "" {
+			text.WriteString(strings.TrimSpace(item.Quantity + " " + item.Unit))
+		} else {
+			text.WriteString(item.Name)
+		}
+	}
+	return strings.Join(strings.Fields(text.String()), " "), items
+}
+
+// Map a Cooklang file onto a recipe document
+func recipeFromCooklang(source, name string, names map[string]string) (bson.M, []string) {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = cooklangBlockComment.ReplaceAllString(source, "")
+
+	metadata := map[string]string{}
+	lines := strings.Split(source, "\n")
+
+	// YAML front matter
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i := 1; i < len(lines); i++ {
+			if strings.TrimSpace(lines[i]) == "---" {
+				for _, line := range lines[1:i] {
+					if key, value, ok := strings.Cut(line, ":"); ok {
+						metadata[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+					}
+				}
+				lines = lines[i+1:]
+				break
+			}
+		}
+	}
+
+	// Steps are paragraphs separated by blank lines, sections and notes are skipped
+	paragraphs := []string{}
+	current := []string{}
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, " "))
+			current = []string{}
+		}
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(cooklangLineComment.ReplaceAllString(line, ""))
+		if strings.HasPrefix(line, ">>") {
+			if key, value, ok := strings.Cut(strings.TrimPrefix(line, ">>"), ":"); ok {
+				metadata[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+			}
+		} else if line == "" || strings.HasPrefix(line, "=") || strings.HasPrefix(line, ">") {
+			flush()
+		} else {
+			current = append(current, line)
+		}
+	}
+	flush()
+
+	recipe := bson.M{"name": name}
+	if title, ok := metadata["title"]; ok && title != "" {
+		recipe["name"] = title
+	}
+	for _, key := range []string{"servings", "serves", "yield"} {
+		if servings, ok := parseYield(metadata[key]); ok {
+			recipe["servings"] = servings
+			break
+		}
+	}
+	for key, field := range map[string]string{"cook time": "cookTime", "prep time": "prepTime"} {
+		if value, ok := metadata[key]; ok {
+			if minutes, ok := parseMinutes(value); ok {
+				recipe[field] = minutes
+			}
+		}
+	}
+	for _, key := range []string{"source", "url"} {
+		if value, ok := metadata[key]; ok && value != "" {
+			recipe["url"] = value
+			break
+		}
+	}
+
+	ingredients := primitive.A{}
+	cookware := []string{}
+	steps := primitive.A{}
+	unmatched := []string{}
+	for _, paragraph := range paragraphs {
+		text, items := parseCooklangStep(paragraph)
+		step := bson.M{"text": text}
+		duration := 0
+
+		for _, item := range items {
+			switch item.Kind {
+			case '@':
+				line := strings.TrimSpace(item.Quantity + " " + item.Unit + " " + item.Name)
+				ingredient := recipeIngredient{Name: strings.ToLower(item.Name)}
+				if value, ok := parseQuantity(item.Quantity); ok {
+					ingredient.Value = value
+					ingredient.HasAmount = true
+					ingredient.Unit = "count"
+					if item.Unit != "" {
+						ingredient.Unit = utils.NormalizeUnit(item.Unit)
+					}
+				}
+				if match, ok := matchIngredient(ingredient.Name, names); ok {
+					ingredient.Name = match
+				} else {
+					unmatched = append(unmatched, line)
+				}
+
+				if ingredient.HasAmount {
+					ingredients = append(ingredients, bson.M{"name": ingredient.Name, "amount": bson.M{"value": ingredient.Value, "unit": ingredient.Unit}})
+				} else {
+					ingredients = append(ingredients, ingredient.Name)
+				}
+			case '#':
+				if !utils.Contains(cookware, item.Name) {
+					cookware = append(cookware, item.Name)
+				}
+			case '~':
+				value, ok := parseQuantity(item.Quantity)
+				seconds, known := cooklangTimeUnits[strings.ToLower(item.Unit)]
+				if ok && known {
+					duration += int(value * float64(seconds))
+				}
+			}
+		}
+
+		if duration > 0 {
+			step["duration"] = duration
+		}
+		steps = append(steps, step)
+	}
+
+	recipe["ingredients"] = ingredients
+	if len(cookware) > 0 {
+		recipe["cookware"] = cookware
+	}
+	if len(steps) > 0 {
+		recipe["steps"] = steps
+	}
+	return recipe, unmatched
+}
+
+func formatCooklangAmount(value float64, unit string) string {
+	quantity := strconv.FormatFloat(value, 'f', -1, 64)
+	if unit == "" || unit == "count" {
+		return quantity
+	}
+	return quantity + "%" + unit
+}
+
+// Spell a duration the way a step would mention it (e.g. "25 minutes")
+func cooklangDurations(seconds int) []string {
+	spellings := []string{}
+	for _, unit := range []string{"hours", "hour", "minutes", "minute", "min", "seconds", "second"} {
+		factor := cooklangTimeUnits[unit]
+		if seconds%factor == 0 {
+			spellings = append(spellings, fmt.Sprintf("%d %s", seconds/factor, unit))
+		}
+	}
+	return spellings
+}
+
+type cooklangReplacement struct {
+	Start, End int
+	Text       string
+}
+
+// Find a whole-word, case-insensitive mention of a phrase that isn't already marked up
+func findMention(text, phrase string, taken []cooklangReplacement) (int, bool) {
+	lower := strings.ToLower(text)
+	phrase = strings.ToLower(phrase)
+	for offset := 0; phrase != "" && offset < len(lower); {
+		i := strings.Index(lower[offset:], phrase)
+		if i < 0 {
+			break
+		}
+		start, end := offset+i, offset+i+len(phrase)
+		offset = start + 1
+
+		before := start == 0 || !isWordByte(lower[start-1])
+		after := end == len(lower) || !isWordByte(lower[end])
+		overlaps := false
+		for _, r := range taken {
+			if start < r.End && end > r.Start {
+				overlaps = true
+			}
+		}
+		if before && after && !overlaps {
+			return start, true
+		}
+	}
+	return 0, false
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// Render a recipe document as Cooklang, marking up the first mention of each ingredient, cookware and timer
+func recipeToCooklang(recipe *primitive.M) string {
+	var out strings.Builder
+
+	if name, ok := (*recipe)["name"].(string); ok && name != "" {
+		fmt.Fprintf(&out, ">> title: %s\n", name)
+	}
+	if servings, ok := utils.Float64FromNumber((*recipe)["servings"]); ok {
+		fmt.Fprintf(&out, ">> servings: %v\n", servings)
+	}
+	for _, field := range []string{"prepTime", "cookTime"} {
+		if minutes, ok := utils.Float64FromNumber((*recipe)[field]); ok {
+			fmt.Fprintf(&out, ">> %s time: %v minutes\n", strings.TrimSuffix(field, "Time"), minutes)
+		}
+	}
+	if url, ok := (*recipe)["url"].(string); ok && url != "" {
+		fmt.Fprintf(&out, ">> source: %s\n", url)
+	}
+
+	texts := []string{}
+	durations := []int{}
+	for _, step := range recipeSteps(recipe) {
+		texts = append(texts, step.Text)
+		durations = append(durations, step.Duration)
+	}
+
+	replacements := make([][]cooklangReplacement, len(texts))
+	mark := func(phrase string, render func(mention string) string) bool {
+		for i, text := range texts {
+			// Steps usually mention ingredients in the plural (e.g. "eggs" for "Egg")
+			for _, form := range []string{phrase, phrase + "s", phrase + "es"} {
+				if start, ok := findMention(text, form, replacements[i]); ok {
+					end := start + len(form)
+					replacements[i] = append(replacements[i], cooklangReplacement{start, end, render(text[start:end])})
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	unmentioned := []string{}
+	for _, entry := range recipeIngredients(recipe) {
+		ingredient, ok := parseRecipeIngredient(entry)
+		if !ok {
+			continue
+		}
+		render := func(mention string) string {
+			if ingredient.HasAmount {
+				return "@" + mention + "{" + formatCooklangAmount(ingredient.Value, ingredient.Unit) + "}"
+			}
+			return "@" + mention + "{}"
+		}
+		if !mark(ingredient.Name, render) {
+			unmentioned = append(unmentioned, render(ingredient.Name))
+		}
+	}
+	for _, name := range stringValues((*recipe)["cookware"]) {
+		if !mark(name, func(mention string) string { return "#" + mention + "{}" }) {
+			unmentioned = append(unmentioned, "#"+name+"{}")
+		}
+	}
+
+	for i, text := range texts {
+		if durations[i] > 0 {
+			marked := false
+			for _, spelling := range cooklangDurations(durations[i]) {
+				if start, ok := findMention(text, spelling, replacements[i]); ok {
+					quantity, unit, _ := strings.Cut(spelling, " ")
+					replacements[i] = append(replacements[i], cooklangReplacement{start, start + len(spelling), "~{" + quantity + "%" + unit + "}"})
+					marked = true
+					break
+				}
+			}
+			if !marked {
+				spelling := cooklangDurations(durations[i])[0]
+				quantity, unit, _ := strings.Cut(spelling, " ")
+				texts[i] = text + " ~{" + quantity + "%" + unit + "}"
+			}
+		}
+
+		// Apply replacements back to front so offsets stay valid
+		sort.Slice(replacements[i], func(a, b int) bool { return replacements[i][a].Start > replacements[i][b].Start })
+		for _, r := range replacements[i] {
+			texts[i] = texts[i][:r.Start] + r.Text + texts[i][r.End:]
+		}
+	}
+
+	// Anything never mentioned in a step still has to be declared somewhere
+	if len(unmentioned) > 0 {
+		texts = append([]string{strings.Join(unmentioned, " ")}, texts...)
+	}
+
+	if out.Len() > 0 {
+		out.WriteString("\n")
+	}
+	out.WriteString(strings.Join(texts, "\n\n"))
+	out.WriteString("\n")
+	return out.String()
+}
+
+func importCooklang(body []byte, queryParams url.Values, names map[string]string) ([]bson.M, []bson.M, error) {
+	recipe, lines := recipeFromCooklang(string(body), queryParams.Get("name"), names)
+	if recipe["name"] == "" || len(recipe["ingredients"].(primitive.A)) == 0 {
+		return []bson.M{}, []bson.M{}, nil
+	}
+	return []bson.M{recipe}, reportUnmatched([]bson.M{}, recipe, lines), nil
+}
+
+func postCooklangImport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postCooklangImport",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	handleRecipeImport(response, request, log, importCooklang)
+}
+
+func getCooklangExport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getCooklangExport",
+		"method": "GET",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract route parameters
+	vars := mux.Vars(request)
+	id := vars["id"]
+	log.WithFields(logrus.Fields{"value": vars}).Debug("Route variables")
+
+	// Parse document id
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil && err.Error() == utils.ErrorInvalidObjectID {
+		// Invalid document id provided
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusBadRequest}).WithError(err).Warn("Failed to parse document id")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusInternalServerError}).WithError(err).Error("Failed to parse document id")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	log = log.WithFields(logrus.Fields{"id": id})
+
+	// Attempt to get the recipe
+	recipe, err := configuration.Mongo.FindOneDocument(ctx, config.MongoCollectionRecipes, bson.D{{"_id", oid}})
+	if err != nil && err.Error() == utils.ErrorMongoNoDocuments {
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to get recipe")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get recipe")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	exported := recipeToCooklang(recipe)
+	log.WithFields(logrus.Fields{"size": len(exported), "status": http.StatusOK}).Info("Succeeded")
+	response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	response.WriteHeader(http.StatusOK)
+	response.Write([]byte(exported))
+}
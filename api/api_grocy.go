@@ -0,0 +1,373 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Grocy uses this best before date for products that never expire
+const grocyNeverExpires = "2999-12-31"
+
+// The subset of Grocy's objects needed to describe a pantry
+type grocyExport struct {
+	Locations     []map[string]interface{} `json:"locations"`
+	Products      []map[string]interface{} `json:"products"`
+	QuantityUnits []map[string]interface{} `json:"quantity_units"`
+	Stock         []map[string]interface{} `json:"stock"`
+}
+
+// Grocy ids and amounts are numbers or numeric strings depending on the version
+func grocyID(value interface{}) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return ""
+}
+
+func grocyNumber(value interface{}) (float64, bool) {
+	if s, ok := value.(string); ok {
+		n, err := strconv.ParseFloat(s, 64)
+		return n, err == nil
+	}
+	return utils.Float64FromNumber(value)
+}
+
+// Forage stores things in the pantry, refrigerator or freezer
+func grocyStoreIn(location map[string]interface{}) string {
+	name, _ := location["name"].(string)
+	name = strings.ToLower(name)
+	if isFreezer, _ := grocyNumber(location["is_freezer"]); isFreezer == 1 || location["is_freezer"] == true || strings.Contains(name, "freez") {
+		return "freezer"
+	} else if strings.Contains(name, "fridge") || strings.Contains(name, "refrigerator") {
+		return "refrigerator"
+	}
+	return "pantry"
+}
+
+func indexByID(objects []map[string]interface{}) map[string]map[string]interface{} {
+	index := map[string]map[string]interface{}{}
+	for _, object := range objects {
+		index[grocyID(object["id"])] = object
+	}
+	return index
+}
+
+// Map Grocy stock onto ingredient documents, one per stock entry plus one per product that isn't stocked
+func ingredientsFromGrocy(export grocyExport, loc *time.Location, now int64) []bson.M {
+	locations := indexByID(export.Locations)
+	products := indexByID(export.Products)
+	units := indexByID(export.QuantityUnits)
+
+	// Stock entries from /api/stock embed their product instead
+	for _, entry := range export.Stock {
+		if product, ok := entry["product"].(map[string]interface{}); ok {
+			if _, found := products[grocyID(product["id"])]; !found {
+				products[grocyID(product["id"])] = product
+				export.Products = append(export.Products, product)
+			}
+		}
+	}
+
+	ingredients := []bson.M{}
+	stocked := map[string]bool{}
+	for _, entry := range export.Stock {
+		productID := grocyID(entry["product_id"])
+		product, ok := products[productID]
+		if !ok {
+			continue
+		}
+		name, _ := product["name"].(string)
+		if name == "" {
+			continue
+		}
+		stocked[productID] = true
+
+		ingredient := bson.M{
+			"haveStocked":    true,
+			"name":           name,
+			"expirationDate": int64(0),
+			"stockedDate":    now,
+			"storeIn":        "pantry",
+			"updated":        now,
+		}
+
+		if amount, ok := grocyNumber(entry["amount"]); ok {
+			unit := "count"
+			if u, ok := units[grocyID(product["qu_id_stock"])]; ok {
+				if n, ok := u["name"].(string); ok && n != "" {
+					unit = utils.NormalizeUnit(n)
+				}
+			}
+			ingredient["amount"] = bson.M{"value": amount, "unit": unit}
+		}
+
+		if date, ok := entry["best_before_date"].(string); ok && date != "" && date != grocyNeverExpires {
+			if t, err := time.ParseInLocation("2006-01-02", date, loc); err == nil {
+				ingredient["expirationDate"] = int64(t.UTC().UnixNano()) / int64(time.Millisecond)
+			}
+		}
+
+		locationID := grocyID(entry["location_id"])
+		if locationID == "" {
+			locationID = grocyID(product["location_id"])
+		}
+		if location, ok := locations[locationID]; ok {
+			ingredient["storeIn"] = grocyStoreIn(location)
+		}
+
+		ingredients = append(ingredients, ingredient)
+	}
+
+	// Known products that are used up are still worth tracking
+	for _, product := range export.Products {
+		name, _ := product["name"].(string)
+		if name == "" || stocked[grocyID(product["id"])] {
+			continue
+		}
+
+		ingredient := bson.M{
+			"haveStocked":    false,
+			"name":           name,
+			"expirationDate": int64(0),
+			"stockedDate":    int64(0),
+			"storeIn":        "pantry",
+			"updated":        now,
+		}
+		if location, ok := locations[grocyID(product["location_id"])]; ok {
+			ingredient["storeIn"] = grocyStoreIn(location)
+		}
+		ingredients = append(ingredients, ingredient)
+		stocked[grocyID(product["id"])] = true
+	}
+	return ingredients
+}
+
+// Map ingredient documents back onto Grocy's objects
+func ingredientsToGrocy(ingredients []bson.M, loc *time.Location) grocyExport {
+	export := grocyExport{
+		Locations: []map[string]interface{}{
+			{"id": 1, "name": "Pantry", "is_freezer": 0},
+			{"id": 2, "name": "Refrigerator", "is_freezer": 0},
+			{"id": 3, "name": "Freezer", "is_freezer": 1},
+		},
+		Products:      []map[string]interface{}{},
+		QuantityUnits: []map[string]interface{}{},
+		Stock:         []map[string]interface{}{},
+	}
+	locationIDs := map[string]int{"pantry": 1, "refrigerator": 2, "freezer": 3}
+
+	productIDs := map[string]int{}
+	unitIDs := map[string]int{}
+	for _, ingredient := range ingredients {
+		name, _ := ingredient["name"].(string)
+		if name == "" {
+			continue
+		}
+		storeIn, _ := ingredient["storeIn"].(string)
+		locationID, ok := locationIDs[storeIn]
+		if !ok {
+			locationID = locationIDs["pantry"]
+		}
+
+		value, unit, hasAmount := parseAmount(ingredient["amount"])
+		if !hasAmount || unit == "" {
+			unit = "count"
+		}
+		unit = utils.NormalizeUnit(unit)
+		if _, ok := unitIDs[unit]; !ok {
+			unitIDs[unit] = len(unitIDs) + 1
+			export.QuantityUnits = append(export.QuantityUnits, map[string]interface{}{"id": unitIDs[unit], "name": unit})
+		}
+
+		if _, ok := productIDs[name]; !ok {
+			productIDs[name] = len(productIDs) + 1
+			export.Products = append(export.Products, map[string]interface{}{
+				"id":          productIDs[name],
+				"location_id": locationID,
+				"name":        name,
+				"qu_id_stock": unitIDs[unit],
+			})
+		}
+
+		if haveStocked, _ := ingredient["haveStocked"].(bool); !haveStocked {
+			continue
+		}
+		if !hasAmount {
+			value = 1
+		}
+
+		bestBefore := grocyNeverExpires
+		if date, ok := utils.Float64FromNumber(ingredient["expirationDate"]); ok && date > 0 {
+			bestBefore = time.Unix(0, int64(date)*int64(time.Millisecond)).In(loc).Format("2006-01-02")
+		}
+		export.Stock = append(export.Stock, map[string]interface{}{
+			"amount":           value,
+			"best_before_date": bestBefore,
+			"location_id":      locationID,
+			"product_id":       productIDs[name],
+		})
+	}
+
+	sort.SliceStable(export.Stock, func(i, j int) bool {
+		return export.Stock[i]["best_before_date"].(string) < export.Stock[j]["best_before_date"].(string)
+	})
+	return export
+}
+
+func postGrocyImport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postGrocyImport",
+		"method": "POST",
+	})
+	qpNameDryRun := "dryRun"
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	qpDryRun := queryParams.Get(qpNameDryRun)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	var dryRun bool
+	if qpDryRun != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameDryRun, "value": qpDryRun})
+		l.Trace("Query parameter handling")
+		var err error
+		dryRun, err = strconv.ParseBool(qpDryRun)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse boolean")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse the Grocy export
+	var export grocyExport
+	err = json.Unmarshal(bytes, &export)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode Grocy export")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	loc, err := time.LoadLocation(configuration.Timezone)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError, "timezone": configuration.Timezone}).WithError(err).Error("Failed to obtain timezone")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	now := int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond)
+	ingredients := ingredientsFromGrocy(export, loc, now)
+	log = log.WithFields(logrus.Fields{"quantity": len(ingredients)})
+
+	status := http.StatusOK
+	inserted := !dryRun && len(ingredients) > 0
+	if inserted {
+		documents := []interface{}{}
+		for _, ingredient := range ingredients {
+			documents = append(documents, ingredient)
+		}
+
+		err = configuration.Mongo.InsertManyDocuments(ctx, config.MongoCollectionIngredients, documents)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to insert ingredients")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		status = http.StatusCreated
+	}
+
+	// Prepare to respond with the import report
+	marshalled, err := json.Marshal(bson.M{
+		"ingredients": ingredients,
+		"inserted":    inserted,
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode report")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"size": len(marshalled), "status": status}).Info("Succeeded")
+		response.WriteHeader(status)
+		response.Write(marshalled)
+	}
+}
+
+func getGrocyExport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getGrocyExport",
+		"method": "GET",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Grab every ingredient
+	ingredients, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, bson.M{}, nil)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get ingredients")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(ingredients)}).Debug("Ingredients found")
+	}
+
+	loc, err := time.LoadLocation(configuration.Timezone)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError, "timezone": configuration.Timezone}).WithError(err).Error("Failed to obtain timezone")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	export := ingredientsToGrocy(ingredients, loc)
+
+	// Prepare to respond with the export
+	marshalled, err := json.Marshal(export)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode export")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
@@ -0,0 +1,211 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func postCook(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postCook",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	handleCookEvent(response, request, log, true)
+}
+
+func postCookLog(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postCookLog",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	handleCookEvent(response, request, log, false)
+}
+
+// Cooking consumes the recipe's ingredients, a manual log entry only records the event
+func handleCookEvent(response http.ResponseWriter, request *http.Request, log *logrus.Entry, consume bool) {
+	ctx := request.Context()
+
+	// Extract route parameters
+	vars := mux.Vars(request)
+	id := vars["id"]
+	log.WithFields(logrus.Fields{"value": vars}).Debug("Route variables")
+
+	// Parse document id
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil && err.Error() == utils.ErrorInvalidObjectID {
+		// Invalid document id provided
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusBadRequest}).WithError(err).Warn("Failed to parse document id")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusInternalServerError}).WithError(err).Error("Failed to parse document id")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	log = log.WithFields(logrus.Fields{"id": id})
+
+	// Extract query parameters
+	qpNameServings := "servings"
+	queryParams := request.URL.Query()
+	qpServings := queryParams.Get(qpNameServings)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	var servings int
+	if qpServings != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameServings, "value": qpServings})
+		l.Trace("Query parameter handling")
+		servings, err = parseServings(qpServings)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse servings")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse request body (optional)
+	var body struct {
+		Date   int64   `json:"date"`
+		Notes  string  `json:"notes"`
+		Rating float64 `json:"rating"`
+	}
+	if len(bytes) > 0 {
+		err = json.Unmarshal(bytes, &body)
+		if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+			// Invalid request body
+			log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode cook event")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		} else if err != nil {
+			// Something else failed
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode cook event")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		} else {
+			log.WithFields(logrus.Fields{"state": "unmarshalled", "value": body}).Debug("Request body")
+		}
+	}
+
+	if body.Rating != 0 && (body.Rating < 1 || body.Rating > 5) {
+		err := fmt.Errorf("rating must be between 1 and 5: %v", body.Rating)
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Invalid rating")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Attempt to get the recipe
+	recipe, err := configuration.Mongo.FindOneDocument(ctx, config.MongoCollectionRecipes, bson.D{{"_id", oid}})
+	if err != nil && err.Error() == utils.ErrorMongoNoDocuments {
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to get recipe")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get recipe")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"value": recipe}).Debug("Recipe found")
+	}
+
+	// Construct the cook event
+	event := bson.M{"source": "log"}
+	if body.Date != 0 {
+		event["date"] = body.Date
+	} else {
+		event["date"] = int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond)
+	}
+	if body.Notes != "" {
+		event["notes"] = body.Notes
+	}
+	if body.Rating != 0 {
+		event["rating"] = body.Rating
+	}
+	if servings > 0 {
+		event["servings"] = servings
+	}
+
+	if consume {
+		event["source"] = "cook"
+	}
+
+	// Record the event before touching inventory, a failed recording must not leave the ingredients used up
+	err = recordCook(ctx, recipe, event)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to record cook event")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	if consume {
+		// Scale ingredient amounts to the number of servings cooked
+		if servings > 0 {
+			factor := scaleRecipe(recipe, servings)
+			log.WithFields(logrus.Fields{"factor": factor, "servings": servings}).Debug("Scaled recipe")
+		}
+
+		err = consumeIngredients(ctx, recipe)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to consume ingredients")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	// Prepare to respond with the event
+	marshalled, err := json.Marshal(event)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode event")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"size": len(marshalled), "status": http.StatusCreated}).Info("Succeeded")
+		response.WriteHeader(http.StatusCreated)
+		response.Write(marshalled)
+	}
+}
@@ -0,0 +1,793 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const errorNoRecipes = "no recipes found"
+const errorNoIngredients = "no ingredients found"
+const errorImportTooLarge = "import too large"
+const errorImportTooDeep = "archives nested too deeply"
+
+// Limits on what an uploaded archive may unpack to, so a small upload can't exhaust memory
+const importMaxFileSize = 10 << 20
+const importMaxTotalSize = 50 << 20
+const importMaxZipDepth = 1
+
+var durationISO = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+var leadingNumber = regexp.MustCompile(`\d+`)
+
+// Convert an ISO 8601 duration (e.g. "PT1H30M") to minutes
+func parseISODuration(value string) (int, bool) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	match := durationISO.FindStringSubmatch(value)
+	if match == nil || value == "P" || value == "PT" {
+		return 0, false
+	}
+
+	minutes := 0
+	for i, factor := range []int{24 * 60, 60, 1} {
+		if match[i+1] != "" {
+			n, _ := strconv.Atoi(match[i+1])
+			minutes += n * factor
+		}
+	}
+	return minutes, true
+}
+
+var durationText = regexp.MustCompile(`(?i)(\d+(?:\.\d+)?)\s*(hours?|hrs?|h|minutes?|mins?|m)\b`)
+
+// Convert a duration like "PT20M", "1 hr 30 mins" or "45" to minutes
+func parseMinutes(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), v > 0
+	case string:
+		if minutes, ok := parseISODuration(v); ok {
+			return minutes, true
+		}
+
+		total := 0.0
+		for _, match := range durationText.FindAllStringSubmatch(v, -1) {
+			n, _ := strconv.ParseFloat(match[1], 64)
+			if strings.HasPrefix(strings.ToLower(match[2]), "h") {
+				n *= 60
+			}
+			total += n
+		}
+		if total > 0 {
+			return int(total), true
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(v))
+		return n, err == nil && n > 0
+	}
+	return 0, false
+}
+
+// Yields are numbers, strings like "4 servings", or lists of either
+func parseYield(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case float64:
+		return int(v), v >= 1
+	case string:
+		n, err := strconv.Atoi(leadingNumber.FindString(v))
+		return n, err == nil && n > 0
+	case []interface{}:
+		for _, entry := range v {
+			if n, ok := parseYield(entry); ok {
+				return n, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Instructions are a string, a list of strings, or HowToStep/HowToSection objects
+func parseInstructions(value interface{}) primitive.A {
+	steps := primitive.A{}
+	switch v := value.(type) {
+	case string:
+		for _, line := range strings.Split(v, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				steps = append(steps, bson.M{"text": line})
+			}
+		}
+	case []interface{}:
+		for _, entry := range v {
+			steps = append(steps, parseInstructions(entry)...)
+		}
+	case map[string]interface{}:
+		if elements, ok := v["itemListElement"]; ok {
+			steps = append(steps, parseInstructions(elements)...)
+		} else if text, ok := v["text"].(string); ok && strings.TrimSpace(text) != "" {
+			steps = append(steps, bson.M{"text": strings.TrimSpace(text)})
+		}
+	}
+	return steps
+}
+
+func isJSONLDRecipe(object map[string]interface{}) bool {
+	switch v := object["@type"].(type) {
+	case string:
+		return v == "Recipe"
+	case []interface{}:
+		for _, t := range v {
+			if t == "Recipe" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Find every Recipe in a JSON-LD document, which may be a single object, a list, or a @graph
+func jsonLDRecipes(value interface{}) []map[string]interface{} {
+	recipes := []map[string]interface{}{}
+	switch v := value.(type) {
+	case []interface{}:
+		for _, entry := range v {
+			recipes = append(recipes, jsonLDRecipes(entry)...)
+		}
+	case map[string]interface{}:
+		if isJSONLDRecipe(v) {
+			recipes = append(recipes, v)
+		} else if graph, ok := v["@graph"]; ok {
+			recipes = append(recipes, jsonLDRecipes(graph)...)
+		}
+	}
+	return recipes
+}
+
+// Map a schema.org Recipe onto a recipe document
+func recipeFromJSONLD(object map[string]interface{}, names map[string]string) (bson.M, []string) {
+	lines := []string{}
+	switch v := object["recipeIngredient"].(type) {
+	case []interface{}:
+		for _, line := range v {
+			if s, ok := line.(string); ok {
+				lines = append(lines, s)
+			}
+		}
+	case string:
+		lines = append(lines, v)
+	}
+
+	ingredients, unmatched := importIngredients(lines, names)
+	name, _ := object["name"].(string)
+	recipe := bson.M{
+		"ingredients": ingredients,
+		"name":        strings.TrimSpace(name),
+	}
+	if servings, ok := parseYield(object["recipeYield"]); ok {
+		recipe["servings"] = servings
+	}
+	if steps := parseInstructions(object["recipeInstructions"]); len(steps) > 0 {
+		recipe["steps"] = steps
+	}
+	for _, field := range []string{"cookTime", "prepTime"} {
+		if minutes, ok := parseMinutes(object[field]); ok {
+			recipe[field] = minutes
+		}
+	}
+	if url, ok := object["url"].(string); ok {
+		recipe["url"] = url
+	}
+	return recipe, unmatched
+}
+
+type recipeImporter func(body []byte, queryParams url.Values, names map[string]string) ([]bson.M, []bson.M, error)
+
+func reportUnmatched(unmatched []bson.M, recipe bson.M, lines []string) []bson.M {
+	for _, line := range lines {
+		unmatched = append(unmatched, bson.M{"line": line, "recipe": recipe["name"]})
+	}
+	return unmatched
+}
+
+func importJSONLD(body []byte, queryParams url.Values, names map[string]string) ([]bson.M, []bson.M, error) {
+	var document interface{}
+	err := json.Unmarshal(body, &document)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recipes := []bson.M{}
+	unmatched := []bson.M{}
+	for _, object := range jsonLDRecipes(document) {
+		recipe, lines := recipeFromJSONLD(object, names)
+		if recipe["name"] == "" {
+			continue
+		}
+		recipes = append(recipes, recipe)
+		unmatched = reportUnmatched(unmatched, recipe, lines)
+	}
+	return recipes, unmatched, nil
+}
+
+// Shared by every recipe format: parse, match ingredients, then insert or report back for review
+func handleRecipeImport(response http.ResponseWriter, request *http.Request, log *logrus.Entry, importer recipeImporter) {
+	ctx := request.Context()
+	qpNameDryRun := "dryRun"
+	qpNameForce := "force"
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	qpDryRun := queryParams.Get(qpNameDryRun)
+	qpForce := queryParams.Get(qpNameForce)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	var dryRun, force bool
+	var err error
+	if qpDryRun != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameDryRun, "value": qpDryRun})
+		l.Trace("Query parameter handling")
+		dryRun, err = strconv.ParseBool(qpDryRun)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse boolean")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+	if qpForce != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameForce, "value": qpForce})
+		l.Trace("Query parameter handling")
+		force, err = strconv.ParseBool(qpForce)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse boolean")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	// Read in request body, no bigger than everything it may unpack to
+	var tooLarge *http.MaxBytesError
+	bytes, err := io.ReadAll(http.MaxBytesReader(response, request.Body, importMaxTotalSize))
+	if errors.As(err, &tooLarge) {
+		err := errors.New(errorImportTooLarge)
+		log.WithFields(logrus.Fields{"status": http.StatusRequestEntityTooLarge}).WithError(err).Warn("Failed to read request body")
+		response.WriteHeader(http.StatusRequestEntityTooLarge)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes)}).Debug("Request body")
+	}
+
+	// Match ingredient lines against known ingredients
+	names, err := ingredientNames(ctx)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get ingredient names")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	recipes, unmatched, err := importer(bytes, queryParams, names)
+	if err != nil {
+		// Whatever was uploaded couldn't be read
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode recipes")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if len(recipes) == 0 {
+		err := fmt.Errorf(errorNoRecipes)
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to find recipes")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// A recipe without ingredients can't be checked or cooked, so it's rejected on its own
+	accepted := []bson.M{}
+	rejected := []bson.M{}
+	rejectedNames := []string{}
+	for _, recipe := range recipes {
+		if len(recipeIngredients(&recipe)) == 0 {
+			rejected = append(rejected, bson.M{"error": errorNoIngredients, "recipe": recipe["name"]})
+			rejectedNames = append(rejectedNames, fmt.Sprint(recipe["name"]))
+		} else {
+			accepted = append(accepted, recipe)
+		}
+	}
+	recipes = accepted
+	if len(recipes) == 0 {
+		err := fmt.Errorf("%s: %s", errorNoIngredients, strings.Join(rejectedNames, ", "))
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to find recipes")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if len(rejected) > 0 {
+		log.WithFields(logrus.Fields{"quantity": len(rejected), "value": rejected}).Warn("Rejected recipes")
+	}
+
+	documents := []interface{}{}
+	for _, recipe := range recipes {
+		// Check if recipe can be made (i.e. associated ingredients are stocked and not expiring)
+		isCookable, err := isCookable(ctx, &recipe)
+		l := log.WithFields(logrus.Fields{"recipe": recipe["name"]})
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to determine cookable")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		} else {
+			l.WithFields(logrus.Fields{"isCookable": isCookable}).Debug("Updating isCookable")
+			recipe["isCookable"] = isCookable
+		}
+		documents = append(documents, recipe)
+	}
+
+	// Hold the import back for review when asked to, or when lines didn't match
+	status := http.StatusOK
+	inserted := !dryRun && (len(unmatched) == 0 || force)
+	if inserted {
+		err := configuration.Mongo.InsertManyDocuments(ctx, config.MongoCollectionRecipes, documents)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to insert recipes")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		status = http.StatusCreated
+	} else {
+		log.WithFields(logrus.Fields{"dryRun": dryRun, "unmatched": len(unmatched)}).Info("Import held for review")
+	}
+
+	// Prepare to respond with the import report
+	marshalled, err := json.Marshal(bson.M{
+		"inserted":  inserted,
+		"recipes":   recipes,
+		"rejected":  rejected,
+		"unmatched": unmatched,
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode report")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(recipes), "size": len(marshalled), "status": status}).Info("Succeeded")
+		response.WriteHeader(status)
+		response.Write(marshalled)
+	}
+}
+
+func postRecipeImport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postRecipeImport",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	handleRecipeImport(response, request, log, importJSONLD)
+}
+
+// Read a decompressed file, failing once it grows past the given size
+func readLimited(reader io.Reader, limit int64) ([]byte, error) {
+	contents, err := io.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, err
+	} else if int64(len(contents)) > limit {
+		return nil, errors.New(errorImportTooLarge)
+	}
+	return contents, nil
+}
+
+// How much the next file may unpack to, given the total unpacked so far
+func importRemaining(total int64) int64 {
+	limit := int64(importMaxFileSize)
+	if remaining := importMaxTotalSize - total; remaining < limit {
+		limit = remaining
+	}
+	return limit
+}
+
+// Read every file in a zip archive whose name has the given suffix, descending into archives nested one level deep.
+// Everything read is added to the total, which is shared with whatever the files are unpacked to next.
+func zipFiles(body []byte, suffix string, total *int64) ([][]byte, error) {
+	return readZipFiles(body, suffix, 0, total)
+}
+
+func readZipFiles(body []byte, suffix string, depth int, total *int64) ([][]byte, error) {
+	if depth > importMaxZipDepth {
+		return nil, errors.New(errorImportTooDeep)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := [][]byte{}
+	for _, file := range archive.File {
+		name := strings.ToLower(file.Name)
+		if !strings.HasSuffix(name, suffix) && !strings.HasSuffix(name, ".zip") {
+			continue
+		}
+
+		reader, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := readLimited(reader, importRemaining(*total))
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+		*total += int64(len(contents))
+
+		if strings.HasSuffix(name, ".zip") {
+			nested, err := readZipFiles(contents, suffix, depth+1, total)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, nested...)
+		} else {
+			files = append(files, contents)
+		}
+	}
+	return files, nil
+}
+
+func isZip(body []byte) bool {
+	return len(body) > 4 && string(body[:4]) == "PK\x03\x04"
+}
+
+// Exports are a single JSON object, a list of them, or a page of them under "items"
+func jsonObjects(body []byte) ([]map[string]interface{}, error) {
+	var document interface{}
+	err := json.Unmarshal(body, &document)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []map[string]interface{}{}
+	switch v := document.(type) {
+	case []interface{}:
+		for _, entry := range v {
+			if object, ok := entry.(map[string]interface{}); ok {
+				objects = append(objects, object)
+			}
+		}
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			for _, entry := range items {
+				if object, ok := entry.(map[string]interface{}); ok {
+					objects = append(objects, object)
+				}
+			}
+		} else {
+			objects = append(objects, v)
+		}
+	}
+	return objects, nil
+}
+
+// Read JSON objects from a zip archive of files with the given suffix, or from plain JSON
+func exportObjects(body []byte, suffix string) ([]map[string]interface{}, error) {
+	if !isZip(body) {
+		return jsonObjects(body)
+	}
+
+	var total int64
+	files, err := zipFiles(body, suffix, &total)
+	if err != nil {
+		return nil, err
+	}
+	objects := []map[string]interface{}{}
+	for _, file := range files {
+		found, err := jsonObjects(file)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, found...)
+	}
+	return objects, nil
+}
+
+func formatQuantity(value interface{}) string {
+	if n, ok := value.(float64); ok && n > 0 {
+		return strconv.FormatFloat(n, 'f', -1, 64)
+	}
+	return ""
+}
+
+// Name of a nested {name} object (e.g. a Mealie or Tandoor food or unit)
+func nestedName(value interface{}) string {
+	if object, ok := value.(map[string]interface{}); ok {
+		name, _ := object["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// Paprika exports a .paprikarecipes zip of gzipped JSON recipes, or a single gzipped .paprikarecipe
+func importPaprika(body []byte, queryParams url.Values, names map[string]string) ([]bson.M, []bson.M, error) {
+	var total int64
+	files := [][]byte{body}
+	if isZip(body) {
+		var err error
+		files, err = zipFiles(body, ".paprikarecipe", &total)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	recipes := []bson.M{}
+	unmatched := []bson.M{}
+	for _, file := range files {
+		contents := file
+		if len(file) > 2 && file[0] == 0x1f && file[1] == 0x8b {
+			reader, err := gzip.NewReader(bytes.NewReader(file))
+			if err != nil {
+				return nil, nil, err
+			}
+			contents, err = readLimited(reader, importRemaining(total))
+			reader.Close()
+			if err != nil {
+				return nil, nil, err
+			}
+			total += int64(len(contents))
+		}
+
+		objects, err := jsonObjects(contents)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, object := range objects {
+			recipe, lines := recipeFromPaprika(object, names)
+			if recipe["name"] == "" {
+				continue
+			}
+			recipes = append(recipes, recipe)
+			unmatched = reportUnmatched(unmatched, recipe, lines)
+		}
+	}
+	return recipes, unmatched, nil
+}
+
+func recipeFromPaprika(object map[string]interface{}, names map[string]string) (bson.M, []string) {
+	text, _ := object["ingredients"].(string)
+	ingredients, unmatched := importIngredients(strings.Split(text, "\n"), names)
+
+	name, _ := object["name"].(string)
+	recipe := bson.M{
+		"ingredients": ingredients,
+		"name":        strings.TrimSpace(name),
+	}
+	if servings, ok := parseYield(object["servings"]); ok {
+		recipe["servings"] = servings
+	}
+	if steps := parseInstructions(object["directions"]); len(steps) > 0 {
+		recipe["steps"] = steps
+	}
+	if minutes, ok := parseMinutes(object["prep_time"]); ok {
+		recipe["prepTime"] = minutes
+	}
+	if minutes, ok := parseMinutes(object["cook_time"]); ok {
+		recipe["cookTime"] = minutes
+	}
+	if url, ok := object["source_url"].(string); ok && url != "" {
+		recipe["url"] = url
+	}
+	return recipe, unmatched
+}
+
+// Mealie exports recipes as JSON, either loose or zipped
+func importMealie(body []byte, queryParams url.Values, names map[string]string) ([]bson.M, []bson.M, error) {
+	objects, err := exportObjects(body, ".json")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recipes := []bson.M{}
+	unmatched := []bson.M{}
+	for _, object := range objects {
+		recipe, lines := recipeFromMealie(object, names)
+		if recipe["name"] == "" {
+			continue
+		}
+		recipes = append(recipes, recipe)
+		unmatched = reportUnmatched(unmatched, recipe, lines)
+	}
+	return recipes, unmatched, nil
+}
+
+func recipeFromMealie(object map[string]interface{}, names map[string]string) (bson.M, []string) {
+	// Ingredients are plain lines, or parsed into quantity, unit and food
+	lines := []string{}
+	entries, _ := object["recipeIngredient"].([]interface{})
+	for _, entry := range entries {
+		switch v := entry.(type) {
+		case string:
+			lines = append(lines, v)
+		case map[string]interface{}:
+			if food := nestedName(v["food"]); food != "" {
+				lines = append(lines, strings.Join(strings.Fields(formatQuantity(v["quantity"])+" "+nestedName(v["unit"])+" "+food), " "))
+			} else if text, ok := v["originalText"].(string); ok && text != "" {
+				lines = append(lines, text)
+			} else if note, ok := v["note"].(string); ok {
+				lines = append(lines, note)
+			}
+		}
+	}
+	ingredients, unmatched := importIngredients(lines, names)
+
+	name, _ := object["name"].(string)
+	recipe := bson.M{
+		"ingredients": ingredients,
+		"name":        strings.TrimSpace(name),
+	}
+	if servings, ok := parseYield(object["recipeServings"]); ok {
+		recipe["servings"] = servings
+	} else if servings, ok := parseYield(object["recipeYield"]); ok {
+		recipe["servings"] = servings
+	}
+	if steps := parseInstructions(object["recipeInstructions"]); len(steps) > 0 {
+		recipe["steps"] = steps
+	}
+	if minutes, ok := parseMinutes(object["prepTime"]); ok {
+		recipe["prepTime"] = minutes
+	}
+	if minutes, ok := parseMinutes(object["performTime"]); ok {
+		recipe["cookTime"] = minutes
+	} else if minutes, ok := parseMinutes(object["cookTime"]); ok {
+		recipe["cookTime"] = minutes
+	}
+	if url, ok := object["orgURL"].(string); ok && url != "" {
+		recipe["url"] = url
+	}
+	return recipe, unmatched
+}
+
+// Tandoor exports a zip of zips, each holding a recipe.json
+func importTandoor(body []byte, queryParams url.Values, names map[string]string) ([]bson.M, []bson.M, error) {
+	objects, err := exportObjects(body, "recipe.json")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recipes := []bson.M{}
+	unmatched := []bson.M{}
+	for _, object := range objects {
+		recipe, lines := recipeFromTandoor(object, names)
+		if recipe["name"] == "" {
+			continue
+		}
+		recipes = append(recipes, recipe)
+		unmatched = reportUnmatched(unmatched, recipe, lines)
+	}
+	return recipes, unmatched, nil
+}
+
+func recipeFromTandoor(object map[string]interface{}, names map[string]string) (bson.M, []string) {
+	// Ingredients belong to the step that uses them
+	lines := []string{}
+	steps := primitive.A{}
+	entries, _ := object["steps"].([]interface{})
+	for _, entry := range entries {
+		step, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		ingredients, _ := step["ingredients"].([]interface{})
+		for _, i := range ingredients {
+			ingredient, ok := i.(map[string]interface{})
+			if !ok || ingredient["is_header"] == true {
+				continue
+			}
+			food := nestedName(ingredient["food"])
+			if food == "" {
+				continue
+			} else if ingredient["no_amount"] == true {
+				lines = append(lines, food)
+			} else {
+				lines = append(lines, strings.Join(strings.Fields(formatQuantity(ingredient["amount"])+" "+nestedName(ingredient["unit"])+" "+food), " "))
+			}
+		}
+
+		if text, ok := step["instruction"].(string); ok && strings.TrimSpace(text) != "" {
+			parsed := bson.M{"text": strings.TrimSpace(text)}
+			if minutes, ok := step["time"].(float64); ok && minutes > 0 {
+				parsed["duration"] = int(minutes * 60)
+			}
+			steps = append(steps, parsed)
+		}
+	}
+	ingredients, unmatched := importIngredients(lines, names)
+
+	name, _ := object["name"].(string)
+	recipe := bson.M{
+		"ingredients": ingredients,
+		"name":        strings.TrimSpace(name),
+	}
+	if servings, ok := parseYield(object["servings"]); ok {
+		recipe["servings"] = servings
+	}
+	if len(steps) > 0 {
+		recipe["steps"] = steps
+	}
+	if minutes, ok := parseMinutes(object["working_time"]); ok {
+		recipe["prepTime"] = minutes
+	}
+	if minutes, ok := parseMinutes(object["waiting_time"]); ok {
+		recipe["cookTime"] = minutes
+	}
+	if url, ok := object["source_url"].(string); ok && url != "" {
+		recipe["url"] = url
+	}
+	return recipe, unmatched
+}
+
+func postPaprikaImport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postPaprikaImport",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	handleRecipeImport(response, request, log, importPaprika)
+}
+
+func postMealieImport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postMealieImport",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	handleRecipeImport(response, request, log, importMealie)
+}
+
+func postTandoorImport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postTandoorImport",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	handleRecipeImport(response, request, log, importTandoor)
+}
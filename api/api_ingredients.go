@@ -0,0 +1,192 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Every location is one of these environments, as recorded in an ingredient's "storeIn"
+var storageTypes = []string{"pantry", "refrigerator", "freezer"}
+
+// Move an ingredient to another environment or location (thawing in the refrigerator by default)
+func postIngredientMove(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postIngredientMove",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract route parameters
+	vars := mux.Vars(request)
+	id := vars["id"]
+	log.WithFields(logrus.Fields{"value": vars}).Debug("Route variables")
+
+	// Parse document id
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil && err.Error() == utils.ErrorInvalidObjectID {
+		// Invalid document id provided
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusBadRequest}).WithError(err).Warn("Failed to parse document id")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusInternalServerError}).WithError(err).Error("Failed to parse document id")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	log = log.WithFields(logrus.Fields{"id": id})
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse request body (optional)
+	var body struct {
+		Location string `json:"location"`
+		Shelf    string `json:"shelf"`
+		StoreIn  string `json:"storeIn"`
+	}
+	if len(bytes) > 0 {
+		err = json.Unmarshal(bytes, &body)
+		if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+			// Invalid request body
+			log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode move")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		} else if err != nil {
+			// Something else failed
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode move")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		} else {
+			log.WithFields(logrus.Fields{"state": "unmarshalled", "value": body}).Debug("Request body")
+		}
+	}
+
+	// A location determines the environment, moving without one leaves the old location behind
+	fields := bson.M{}
+	unset := bson.M{}
+	if body.Location != "" {
+		location, err := findLocation(ctx, body.Location)
+		if err != nil && (err.Error() == utils.ErrorInvalidObjectID || err.Error() == utils.ErrorMongoNoDocuments) {
+			err = fmt.Errorf("%s: %s", errorLocationUnknown, body.Location)
+			log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to get location")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		} else if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get location")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		}
+
+		if storeIn, ok := (*location)["type"].(string); ok {
+			body.StoreIn = storeIn
+		}
+		fields["location"] = body.Location
+		if body.Shelf != "" {
+			fields["shelf"] = body.Shelf
+		} else {
+			unset["shelf"] = ""
+		}
+	} else {
+		unset["location"] = ""
+		unset["shelf"] = ""
+		if body.StoreIn == "" {
+			body.StoreIn = "refrigerator"
+		}
+	}
+
+	if !utils.Contains(storageTypes, body.StoreIn) {
+		err := fmt.Errorf("invalid storage location: %s", body.StoreIn)
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Invalid storage location")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Attempt to get the ingredient
+	filter := bson.D{{"_id", oid}}
+	ingredient, err := configuration.Mongo.FindOneDocument(ctx, config.MongoCollectionIngredients, filter)
+	if err != nil && err.Error() == utils.ErrorMongoNoDocuments {
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to get ingredient")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get ingredient")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"value": ingredient}).Debug("Ingredient found")
+	}
+
+	// The new location's lifespan starts now, otherwise the old expiration stands
+	now := time.Now()
+	fields["storeIn"] = body.StoreIn
+	fields["updated"] = int64(now.UTC().UnixNano()) / int64(time.Millisecond)
+	if expirationDate, ok := lifespanExpiration(*ingredient, body.StoreIn, now); ok {
+		fields["expirationDate"] = expirationDate
+	}
+	update := bson.M{"$set": fields}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	log.WithFields(logrus.Fields{"value": update}).Debug("Update instructions")
+
+	_, _, err = configuration.Mongo.UpdateOneDocument(ctx, config.MongoCollectionIngredients, filter, update)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to move ingredient")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	for key, value := range fields {
+		(*ingredient)[key] = value
+	}
+	for key := range unset {
+		delete(*ingredient, key)
+	}
+
+	// Prepare to respond with the ingredient
+	marshalled, err := json.Marshal(ingredient)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode ingredient")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
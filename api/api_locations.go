@@ -0,0 +1,134 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const errorLocationUnknown = "unknown location"
+
+// Locations are { name, type, shelves, capacity } where type is one of storageTypes
+func findLocation(ctx context.Context, id string) (*bson.M, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+	return configuration.Mongo.FindOneDocument(ctx, config.MongoCollectionLocations, bson.D{{"_id", oid}})
+}
+
+func getLocationContents(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getLocationContents",
+		"method": "GET",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract route parameters
+	vars := mux.Vars(request)
+	id := vars["id"]
+	log.WithFields(logrus.Fields{"value": vars}).Debug("Route variables")
+	log = log.WithFields(logrus.Fields{"id": id})
+
+	// Attempt to get the location
+	location, err := findLocation(ctx, id)
+	if err != nil && err.Error() == utils.ErrorInvalidObjectID {
+		// Invalid document id provided
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to parse document id")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil && err.Error() == utils.ErrorMongoNoDocuments {
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to get location")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get location")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"value": location}).Debug("Location found")
+	}
+
+	// Grab what's stocked there
+	filter := bson.M{"$and": []bson.M{
+		{
+			"haveStocked": bson.M{
+				"$eq": true,
+			},
+		},
+		{
+			"location": bson.M{
+				"$eq": id,
+			},
+		},
+	}}
+	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filter, nil)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get documents")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(documents), "value": documents}).Debug("Documents found")
+	}
+
+	// Shelf by shelf, in the order the location lists them
+	shelves := stringValues((*location)["shelves"])
+	shelfIndex := func(document bson.M) int {
+		shelf, _ := document["shelf"].(string)
+		for i, s := range shelves {
+			if s == shelf {
+				return i
+			}
+		}
+		return len(shelves)
+	}
+	sort.SliceStable(documents, func(i, j int) bool {
+		if a, b := shelfIndex(documents[i]), shelfIndex(documents[j]); a != b {
+			return a < b
+		}
+		a, _ := documents[i]["name"].(string)
+		b, _ := documents[j]["name"].(string)
+		return a < b
+	})
+
+	contents := bson.M{
+		"contents": documents,
+		"location": location,
+		"quantity": len(documents),
+	}
+	if capacity, ok := utils.Float64FromNumber((*location)["capacity"]); ok {
+		contents["remaining"] = capacity - float64(len(documents))
+	}
+
+	// Prepare to respond with the contents
+	marshalled, err := json.Marshal(contents)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode contents")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(documents), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const errorPricesNoItems = "no items given"
+const errorPricesUnpriced = "purchases without a price"
+
+const priceSourceReceipt = "receipt"
+const priceSourceTrip = "trip"
+
+// A receipt lists what was bought at one store on one day
+type receipt struct {
+	Date  int64      `json:"date"`
+	Items []purchase `json:"items"`
+	Store string     `json:"store"`
+}
+
+// Record the price of each priced purchase against the ingredient it matched.
+// A purchase's own store wins over the given one.
+func recordPrices(ctx context.Context, matched []bson.M, purchases []purchase, store string, date time.Time, source string) ([]bson.M, error) {
+	dateMs := int64(date.UTC().UnixNano()) / int64(time.Millisecond)
+	documents := []bson.M{}
+	for i, ingredient := range matched {
+		p := purchases[i]
+		if p.Price <= 0 {
+			continue
+		}
+
+		document := bson.M{
+			"date":   dateMs,
+			"name":   ingredient["name"],
+			"price":  p.Price,
+			"source": source,
+		}
+		if p.Quantity > 0 {
+			unit := p.Unit
+			if _, existing, ok := parseAmount(ingredient["amount"]); ok && unit == "" {
+				unit = existing
+			}
+			document["amount"] = bson.M{"unit": unit, "value": p.Quantity}
+		}
+		if p.Store != "" {
+			document["store"] = p.Store
+		} else if store != "" {
+			document["store"] = store
+		}
+		documents = append(documents, document)
+	}
+	if len(documents) == 0 {
+		return documents, nil
+	}
+
+	docs := []interface{}{}
+	for _, document := range documents {
+		docs = append(docs, document)
+	}
+	err := configuration.Mongo.InsertManyDocuments(ctx, config.MongoCollectionPrices, docs)
+	if err != nil {
+		return nil, err
+	}
+	return documents, nil
+}
+
+// Price history, newest first, optionally for a single ingredient
+func getPrices(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getPrices",
+		"method": "GET",
+	})
+	qpNameName := "name"
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	qpName := queryParams.Get(qpNameName)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	filter := bson.M{}
+	if qpName != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameName, "value": qpName})
+		l.Trace("Query parameter handling")
+		filter["name"] = qpName
+	}
+	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+
+	// Define sorting criteria
+	opts := options.Find()
+	opts.SetSort(bson.D{{"date", -1}})
+	log.WithFields(logrus.Fields{"value": opts.Sort}).Debug("Sorting criteria")
+
+	// Grab the documents
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionPrices, filter, opts)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get prices")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(documents), "value": documents}).Debug("Documents found")
+	}
+
+	// Prepare to respond with documents
+	marshalled, err := json.Marshal(documents)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode documents")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(documents), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
+
+// Record the prices on a receipt, every item must match an ingredient and carry a price
+func postPrices(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postPrices",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse receipt
+	var body receipt
+	err = json.Unmarshal(bytes, &body)
+	if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+		// Invalid request body
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode receipt")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode receipt")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else if len(body.Items) == 0 {
+		err := errors.New(errorPricesNoItems)
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode receipt")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(body.Items), "state": "unmarshalled", "value": body}).Debug("Request body")
+	}
+
+	unpriced := []string{}
+	for _, p := range body.Items {
+		if p.Price <= 0 {
+			unpriced = append(unpriced, p.String())
+		}
+	}
+	if len(unpriced) > 0 {
+		err := fmt.Errorf("%s: %s", errorPricesUnpriced, strings.Join(unpriced, ", "))
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode receipt")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Grab every ingredient to match names, aliases and barcodes against
+	ingredients, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, bson.M{}, nil)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get ingredients")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	names := ingredientNameIndex(ingredients)
+
+	matched := []bson.M{}
+	unmatched := []string{}
+	for _, p := range body.Items {
+		if ingredient, ok := matchPurchase(p, ingredients, names, matched); ok {
+			matched = append(matched, ingredient)
+		} else {
+			unmatched = append(unmatched, p.String())
+		}
+	}
+	if len(unmatched) > 0 {
+		err := fmt.Errorf("%s: %s", errorUnmatchedPurchases, strings.Join(unmatched, ", "))
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to match purchases")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Receipts without a date were from today
+	date := time.Now()
+	if body.Date > 0 {
+		date = time.Unix(0, body.Date*int64(time.Millisecond))
+	}
+
+	recorded, err := recordPrices(ctx, matched, body.Items, body.Store, date, priceSourceReceipt)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to record prices")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Prepare to respond with what was recorded
+	marshalled, err := json.Marshal(recorded)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode prices")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(recorded), "size": len(marshalled), "status": http.StatusCreated}).Info("Succeeded")
+		response.WriteHeader(http.StatusCreated)
+		response.Write(marshalled)
+	}
+}
@@ -0,0 +1,375 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func getNutritionReport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getNutritionReport",
+		"method": "GET",
+	})
+	qpNameFrom := "from"
+	qpNameTo := "to"
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	qpFrom := queryParams.Get(qpNameFrom)
+	qpTo := queryParams.Get(qpNameTo)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	// Default to the coming week
+	loc, err := time.LoadLocation(configuration.Timezone)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError, "timezone": configuration.Timezone}).WithError(err).Error("Failed to obtain timezone")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	now := time.Now().In(loc)
+	timeFrom := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	timeTo := timeFrom.Add(7 * 24 * time.Hour)
+
+	if qpFrom != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameFrom, "value": qpFrom})
+		l.Trace("Query parameter handling")
+		from, err := strconv.ParseInt(qpFrom, 10, 64)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse number")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		timeFrom = time.Unix(0, from*int64(time.Millisecond)).In(loc)
+	}
+	if qpTo != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameTo, "value": qpTo})
+		l.Trace("Query parameter handling")
+		to, err := strconv.ParseInt(qpTo, 10, 64)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse number")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		timeTo = time.Unix(0, to*int64(time.Millisecond)).In(loc)
+	}
+
+	// Filter by meals planned within the given window
+	filter := bson.M{
+		"date": bson.M{
+			"$gte": int64(timeFrom.UTC().UnixNano()) / int64(time.Millisecond),
+			"$lte": int64(timeTo.UTC().UnixNano()) / int64(time.Millisecond),
+		},
+	}
+	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+
+	// Define sorting criteria
+	opts := options.Find()
+	opts.SetSort(bson.D{{"date", 1}})
+	log.WithFields(logrus.Fields{"value": opts.Sort}).Debug("Sorting criteria")
+
+	// Grab the meal plans
+	plans, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionMealPlans, filter, opts)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get meal plans")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(plans), "value": plans}).Debug("Meal plans found")
+	}
+
+	// Sum nutrition of each planned recipe per day
+	days := []bson.M{}
+	perServing := map[string]bson.M{}
+	for _, plan := range plans {
+		date, _ := utils.Float64FromNumber(plan["date"])
+		t := time.Unix(0, int64(date)*int64(time.Millisecond)).In(loc)
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+		dayMs := int64(day.UTC().UnixNano()) / int64(time.Millisecond)
+
+		if len(days) == 0 || days[len(days)-1]["date"] != dayMs {
+			days = append(days, bson.M{
+				"date":    dayMs,
+				"missing": []string{},
+				"recipes": []string{},
+				"total":   map[string]float64{},
+			})
+		}
+		current := days[len(days)-1]
+
+		for _, entry := range mealPlanEntries(plan) {
+			planned, ok := parseRecipeIngredient(entry)
+			if !ok {
+				continue
+			}
+			servings := 1.0
+			if fields, ok := entry.(primitive.M); ok {
+				if v, ok := utils.Float64FromNumber(fields["servings"]); ok {
+					servings = v
+				}
+			} else if fields, ok := entry.(map[string]interface{}); ok {
+				if v, ok := utils.Float64FromNumber(fields["servings"]); ok {
+					servings = v
+				}
+			}
+			l := log.WithFields(logrus.Fields{"recipe": planned.Name, "servings": servings})
+
+			nutrition, found := perServing[planned.Name]
+			if !found {
+				recipe, err := configuration.Mongo.FindOneDocument(ctx, config.MongoCollectionRecipes, bson.D{{"name", planned.Name}})
+				if err != nil && err.Error() == utils.ErrorMongoNoDocuments {
+					l.WithError(err).Warn("Failed to find planned recipe")
+					current["missing"] = utils.AppendUnique(current["missing"].([]string), planned.Name)
+					continue
+				} else if err != nil {
+					l.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get planned recipe")
+					response.WriteHeader(http.StatusInternalServerError)
+					response.Write([]byte(err.Error()))
+					return
+				}
+
+				nutrition, err = recipeNutrition(ctx, recipe)
+				if err != nil {
+					l.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to compute nutrition")
+					response.WriteHeader(http.StatusInternalServerError)
+					response.Write([]byte(err.Error()))
+					return
+				}
+				perServing[planned.Name] = nutrition
+			}
+
+			current["recipes"] = append(current["recipes"].([]string), planned.Name)
+			if nutrition == nil {
+				current["missing"] = utils.AppendUnique(current["missing"].([]string), planned.Name)
+				continue
+			}
+
+			total := current["total"].(map[string]float64)
+			for nutrient, value := range nutrition["perServing"].(map[string]float64) {
+				total[nutrient] += value * servings
+			}
+			current["missing"] = utils.AppendUnique(current["missing"].([]string), nutrition["missing"].([]string)...)
+		}
+	}
+
+	// Sum nutrition of everything currently stocked
+	filterStocked := bson.M{"$and": []bson.M{
+		{
+			"haveStocked": bson.M{
+				"$eq": true,
+			},
+		},
+		{
+			"nutrition": bson.M{
+				"$exists": true,
+			},
+		},
+	}}
+	log.WithFields(logrus.Fields{"value": filterStocked}).Debug("Filter data")
+
+	stocked, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filterStocked, nil)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get stocked ingredients")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	inventoryTotal := map[string]float64{}
+	inventoryMissing := []string{}
+	for _, document := range stocked {
+		ingredient, _ := parseRecipeIngredient(primitive.M{"name": document["name"], "amount": document["amount"]})
+		templates := map[string]primitive.M{}
+		switch v := document["nutrition"].(type) {
+		case primitive.M:
+			templates[ingredient.Name] = v
+		case map[string]interface{}:
+			templates[ingredient.Name] = v
+		}
+
+		totals, missing := computeNutrition([]recipeIngredient{ingredient}, templates)
+		for nutrient, value := range totals {
+			inventoryTotal[nutrient] += value
+		}
+		inventoryMissing = utils.AppendUnique(inventoryMissing, missing...)
+	}
+
+	// Prepare to respond with the report
+	marshalled, err := json.Marshal(bson.M{
+		"days": days,
+		"inventory": bson.M{
+			"missing": inventoryMissing,
+			"total":   inventoryTotal,
+		},
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode report")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"days": len(days), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
+
+// What the food that expired while stocked was worth, from the latest prices paid for it
+func getWasteReport(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getWasteReport",
+		"method": "GET",
+	})
+	qpNameFrom := "from"
+	qpNameTo := "to"
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	qpFrom := queryParams.Get(qpNameFrom)
+	qpTo := queryParams.Get(qpNameTo)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	// Default to the past 30 days, nothing after now has expired yet
+	now := time.Now()
+	timeTo := now
+	timeFrom := timeTo.Add(-30 * 24 * time.Hour)
+
+	if qpFrom != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameFrom, "value": qpFrom})
+		l.Trace("Query parameter handling")
+		from, err := strconv.ParseInt(qpFrom, 10, 64)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse number")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		timeFrom = time.Unix(0, from*int64(time.Millisecond))
+	}
+	if qpTo != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameTo, "value": qpTo})
+		l.Trace("Query parameter handling")
+		to, err := strconv.ParseInt(qpTo, 10, 64)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse number")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		timeTo = time.Unix(0, to*int64(time.Millisecond))
+		if timeTo.After(now) {
+			timeTo = now
+		}
+	}
+
+	// Filter by food that expired within the given window while still stocked
+	filter := bson.M{"$and": []bson.M{
+		{
+			"expirationDate": bson.M{
+				"$gte": int64(timeFrom.UTC().UnixNano()) / int64(time.Millisecond),
+				"$lte": int64(timeTo.UTC().UnixNano()) / int64(time.Millisecond),
+			},
+		},
+		{
+			"haveStocked": bson.M{
+				"$eq": true,
+			},
+		},
+	}}
+	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+
+	// Define sorting criteria
+	opts := options.Find()
+	opts.SetSort(bson.D{{"expirationDate", 1}})
+	log.WithFields(logrus.Fields{"value": opts.Sort}).Debug("Sorting criteria")
+
+	expired, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filter, opts)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to identify expired items")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(expired), "value": expired}).Debug("Documents found")
+	}
+
+	names := []string{}
+	for _, document := range expired {
+		if name, ok := document["name"].(string); ok && !utils.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	prices, err := latestPrices(ctx, names)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get prices")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Value each item by how much of it was stocked, or by the whole price when that isn't known
+	items := []bson.M{}
+	unpriced := []string{}
+	total := 0.0
+	for _, document := range expired {
+		ingredient, _ := parseRecipeIngredient(primitive.M{"name": document["name"], "amount": document["amount"]})
+		item := bson.M{
+			"_id":            document["_id"],
+			"expirationDate": document["expirationDate"],
+			"name":           ingredient.Name,
+		}
+
+		price, found := prices[ingredient.Name]
+		value, ok := estimateCost(price, ingredient.Value, ingredient.Unit, ingredient.HasAmount)
+		if !found || !ok {
+			unpriced = append(unpriced, ingredient.Name)
+		} else {
+			item["value"] = roundCents(value)
+			total += value
+		}
+		items = append(items, item)
+	}
+
+	// Prepare to respond with the report
+	marshalled, err := json.Marshal(bson.M{
+		"from":     int64(timeFrom.UTC().UnixNano()) / int64(time.Millisecond),
+		"items":    items,
+		"to":       int64(timeTo.UTC().UnixNano()) / int64(time.Millisecond),
+		"total":    roundCents(total),
+		"unpriced": unpriced,
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode report")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(items), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
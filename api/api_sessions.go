@@ -0,0 +1,576 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const errorSessionNotFound = "session not found"
+const errorRecipeNoSteps = "recipe has no steps"
+const errorSessionFinishing = "session is already finishing"
+
+type sessionTimer struct {
+	Name     string `json:"name"`
+	Step     int    `json:"step"`
+	Duration int    `json:"duration"`
+	Ends     int64  `json:"ends"`
+	Elapsed  bool   `json:"elapsed"`
+	timer    *time.Timer
+}
+
+type sessionEvent struct {
+	Name string
+	Data interface{}
+}
+
+// A guided cooking session walks through a recipe's steps, running timers along the way.
+// Sessions only live in memory, so restarting the server abandons them.
+type cookingSession struct {
+	ID        string
+	Recipe    primitive.M
+	Servings  int
+	Step      int
+	Started   int64
+	Timers    []*sessionTimer
+	finishing bool
+	listeners map[chan sessionEvent]bool
+	ended     *sessionEvent
+	active    time.Time
+	expiry    *time.Timer
+}
+
+var sessions = map[string]*cookingSession{}
+var sessionsLock sync.Mutex
+
+// How long a session may go untouched before it's treated as abandoned
+var sessionIdleTimeout = 12 * time.Hour
+
+// Callers must hold sessionsLock
+func sessionView(session *cookingSession) bson.M {
+	// Copy timers so the view can be encoded after the lock is released
+	timers := []sessionTimer{}
+	for _, t := range session.Timers {
+		timers = append(timers, *t)
+	}
+
+	view := bson.M{
+		"id":       session.ID,
+		"name":     session.Recipe["name"],
+		"recipe":   session.Recipe["_id"],
+		"started":  session.Started,
+		"step":     session.Step,
+		"steps":    session.Recipe["steps"],
+		"timers":   timers,
+		"finished": false,
+	}
+	if session.Servings > 0 {
+		view["servings"] = session.Servings
+	}
+	return view
+}
+
+// Callers must hold sessionsLock
+func publishSessionEvent(session *cookingSession, name string, data interface{}) {
+	for listener := range session.listeners {
+		// Slow listeners miss events rather than holding up the session
+		select {
+		case listener <- sessionEvent{name, data}:
+		default:
+		}
+	}
+}
+
+// Callers must hold sessionsLock
+func startSessionTimer(session *cookingSession, name string, step int, duration time.Duration) *sessionTimer {
+	now := time.Now().UTC()
+	t := &sessionTimer{
+		Name:     name,
+		Step:     step,
+		Duration: int(duration / time.Second),
+		Ends:     int64(now.Add(duration).UnixNano()) / int64(time.Millisecond),
+	}
+	t.timer = time.AfterFunc(duration, func() {
+		sessionsLock.Lock()
+		defer sessionsLock.Unlock()
+		t.Elapsed = true
+		touchSession(session)
+		publishSessionEvent(session, "timer", *t)
+	})
+	session.Timers = append(session.Timers, t)
+	return t
+}
+
+// Start the timer for a step when it has a duration. Callers must hold sessionsLock
+func startStepTimer(session *cookingSession) {
+	steps := recipeSteps(&session.Recipe)
+	if session.Step < len(steps) && steps[session.Step].Duration > 0 {
+		name := fmt.Sprintf("Step %d", session.Step+1)
+		startSessionTimer(session, name, session.Step, time.Duration(steps[session.Step].Duration)*time.Second)
+	}
+}
+
+// Keep a session alive for another idle period. Callers must hold sessionsLock
+func touchSession(session *cookingSession) {
+	session.active = time.Now()
+	if session.expiry == nil {
+		session.expiry = time.AfterFunc(sessionIdleTimeout, func() { expireSession(session) })
+	}
+}
+
+// End a session nobody has touched for the idle period, or check again once it could be
+func expireSession(session *cookingSession) {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+	if sessions[session.ID] != session {
+		return
+	}
+	if idle := time.Since(session.active); idle < sessionIdleTimeout {
+		session.expiry.Reset(sessionIdleTimeout - idle)
+		return
+	}
+	logrus.WithFields(logrus.Fields{"at": "api.expireSession", "session": session.ID}).Info("Expired idle session")
+	endSession(session, "expired", sessionView(session))
+}
+
+// Remove a session, stopping its timers and telling listeners why. Callers must hold sessionsLock
+func endSession(session *cookingSession, name string, data interface{}) {
+	for _, t := range session.Timers {
+		t.timer.Stop()
+	}
+	if session.expiry != nil {
+		session.expiry.Stop()
+	}
+
+	// Closing the channels rather than sending on them means even a slow listener gets the last event
+	session.ended = &sessionEvent{name, data}
+	for listener := range session.listeners {
+		close(listener)
+	}
+	session.listeners = map[chan sessionEvent]bool{}
+	delete(sessions, session.ID)
+}
+
+// Callers must hold sessionsLock
+func findSession(request *http.Request, log *logrus.Entry) (*cookingSession, bool) {
+	// Extract route parameters
+	vars := mux.Vars(request)
+	id := vars["id"]
+	log.WithFields(logrus.Fields{"value": vars}).Debug("Route variables")
+
+	session, ok := sessions[id]
+	if !ok {
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusNotFound}).Warn("Failed to find session")
+	} else {
+		touchSession(session)
+	}
+	return session, ok
+}
+
+func respondSessionNotFound(response http.ResponseWriter) {
+	response.WriteHeader(http.StatusNotFound)
+	response.Write([]byte(errorSessionNotFound))
+}
+
+func respondSession(response http.ResponseWriter, log *logrus.Entry, status int, view interface{}) {
+	marshalled, err := json.Marshal(view)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode session")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"size": len(marshalled), "status": status}).Info("Succeeded")
+		response.WriteHeader(status)
+		response.Write(marshalled)
+	}
+}
+
+func postSession(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postSession",
+		"method": "POST",
+	})
+	qpNameServings := "servings"
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract route parameters
+	vars := mux.Vars(request)
+	id := vars["id"]
+	log.WithFields(logrus.Fields{"value": vars}).Debug("Route variables")
+
+	// Parse document id
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil && err.Error() == utils.ErrorInvalidObjectID {
+		// Invalid document id provided
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusBadRequest}).WithError(err).Warn("Failed to parse document id")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"id": id, "status": http.StatusInternalServerError}).WithError(err).Error("Failed to parse document id")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	log = log.WithFields(logrus.Fields{"id": id})
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	qpServings := queryParams.Get(qpNameServings)
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	var servings int
+	if qpServings != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameServings, "value": qpServings})
+		l.Trace("Query parameter handling")
+		servings, err = parseServings(qpServings)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse servings")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	// Attempt to get the recipe
+	recipe, err := configuration.Mongo.FindOneDocument(ctx, config.MongoCollectionRecipes, bson.D{{"_id", oid}})
+	if err != nil && err.Error() == utils.ErrorMongoNoDocuments {
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to get recipe")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get recipe")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else if len(recipeSteps(recipe)) == 0 {
+		err := fmt.Errorf(errorRecipeNoSteps)
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to start session")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Scale ingredient amounts to the number of servings being cooked
+	if servings > 0 {
+		factor := scaleRecipe(recipe, servings)
+		log.WithFields(logrus.Fields{"factor": factor, "servings": servings}).Debug("Scaled recipe")
+	}
+
+	sessionsLock.Lock()
+	session := &cookingSession{
+		ID:        primitive.NewObjectID().Hex(),
+		Recipe:    *recipe,
+		Servings:  servings,
+		Started:   int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond),
+		Timers:    []*sessionTimer{},
+		listeners: map[chan sessionEvent]bool{},
+	}
+	sessions[session.ID] = session
+	touchSession(session)
+	startStepTimer(session)
+	view := sessionView(session)
+	sessionsLock.Unlock()
+	log.WithFields(logrus.Fields{"session": session.ID}).Debug("Started session")
+
+	respondSession(response, log, http.StatusCreated, view)
+}
+
+func getSession(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getSession",
+		"method": "GET",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	sessionsLock.Lock()
+	session, ok := findSession(request, log)
+	if !ok {
+		sessionsLock.Unlock()
+		respondSessionNotFound(response)
+		return
+	}
+	view := sessionView(session)
+	sessionsLock.Unlock()
+
+	respondSession(response, log, http.StatusOK, view)
+}
+
+func postSessionAdvance(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postSessionAdvance",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	sessionsLock.Lock()
+	session, ok := findSession(request, log)
+	if !ok {
+		sessionsLock.Unlock()
+		respondSessionNotFound(response)
+		return
+	}
+	log = log.WithFields(logrus.Fields{"session": session.ID})
+
+	// Move on to the next step
+	if session.Step+1 < len(recipeSteps(&session.Recipe)) {
+		session.Step++
+		startStepTimer(session)
+		view := sessionView(session)
+		publishSessionEvent(session, "step", view)
+		step := session.Step
+		sessionsLock.Unlock()
+
+		log.WithFields(logrus.Fields{"step": step}).Debug("Advanced session")
+		respondSession(response, log, http.StatusOK, view)
+		return
+	}
+	if session.finishing {
+		sessionsLock.Unlock()
+		err := fmt.Errorf(errorSessionFinishing)
+		log.WithFields(logrus.Fields{"status": http.StatusConflict}).WithError(err).Warn("Failed to advance session")
+		response.WriteHeader(http.StatusConflict)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	session.finishing = true
+	recipe := session.Recipe
+	servings := session.Servings
+	sessionsLock.Unlock()
+
+	// Past the last step, so the meal is done: use up the ingredients and log it
+	err := consumeIngredients(ctx, &recipe)
+	if err != nil {
+		sessionsLock.Lock()
+		session.finishing = false
+		sessionsLock.Unlock()
+
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to consume ingredients")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	event := bson.M{
+		"date":   int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond),
+		"source": "session",
+	}
+	if servings > 0 {
+		event["servings"] = servings
+	}
+	err = recordCook(ctx, &recipe, event)
+	if err != nil {
+		// The ingredients are already used up, so don't let a retry consume them twice
+		sessionsLock.Lock()
+		endSession(session, "done", sessionView(session))
+		sessionsLock.Unlock()
+
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to record cook event")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	sessionsLock.Lock()
+	view := sessionView(session)
+	view["cook"] = event
+	view["finished"] = true
+	endSession(session, "done", view)
+	sessionsLock.Unlock()
+
+	log.Debug("Finished session")
+	respondSession(response, log, http.StatusOK, view)
+}
+
+func postSessionTimer(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postSessionTimer",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse timer
+	var body struct {
+		Duration int    `json:"duration"`
+		Name     string `json:"name"`
+	}
+	err = json.Unmarshal(bytes, &body)
+	if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+		// Invalid request body
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode timer")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode timer")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else if body.Duration <= 0 {
+		err := fmt.Errorf("duration must be positive: %d", body.Duration)
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Invalid timer")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	sessionsLock.Lock()
+	session, ok := findSession(request, log)
+	if !ok {
+		sessionsLock.Unlock()
+		respondSessionNotFound(response)
+		return
+	}
+
+	if body.Name == "" {
+		body.Name = fmt.Sprintf("Timer %d", len(session.Timers)+1)
+	}
+	t := *startSessionTimer(session, body.Name, session.Step, time.Duration(body.Duration)*time.Second)
+	sessionsLock.Unlock()
+
+	respondSession(response, log, http.StatusCreated, t)
+}
+
+func deleteSession(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.deleteSession",
+		"method": "DELETE",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	sessionsLock.Lock()
+	session, ok := findSession(request, log)
+	if !ok {
+		sessionsLock.Unlock()
+		respondSessionNotFound(response)
+		return
+	}
+
+	// Abandoning a session leaves the inventory alone
+	view := sessionView(session)
+	endSession(session, "abandoned", view)
+	sessionsLock.Unlock()
+
+	log.WithFields(logrus.Fields{"status": http.StatusOK}).Info("Succeeded")
+	response.WriteHeader(http.StatusOK)
+}
+
+func getSessionEvents(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getSessionEvents",
+		"method": "GET",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	sessionsLock.Lock()
+	session, ok := findSession(request, log)
+	if !ok {
+		sessionsLock.Unlock()
+		respondSessionNotFound(response)
+		return
+	}
+	listener := make(chan sessionEvent, 16)
+	session.listeners[listener] = true
+	current := sessionView(session)
+	sessionsLock.Unlock()
+
+	defer func() {
+		sessionsLock.Lock()
+		delete(session.listeners, listener)
+		sessionsLock.Unlock()
+	}()
+
+	// Stream server-sent events until the session ends or the client goes away
+	response.Header().Set("Content-Type", "text/event-stream")
+	response.Header().Set("Cache-Control", "no-cache")
+	response.WriteHeader(http.StatusOK)
+	log.WithFields(logrus.Fields{"session": session.ID, "status": http.StatusOK}).Info("Streaming events")
+
+	write := func(event sessionEvent) {
+		marshalled, err := json.Marshal(event.Data)
+		if err != nil {
+			log.WithError(err).Error("Failed to encode event")
+			return
+		}
+		fmt.Fprintf(response, "event: %s\ndata: %s\n\n", event.Name, marshalled)
+		if flusher, ok := response.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+
+	write(sessionEvent{"session", current})
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-listener:
+			if !ok {
+				// The session ended, finish with why
+				sessionsLock.Lock()
+				ended := *session.ended
+				sessionsLock.Unlock()
+				write(ended)
+				return
+			}
+			write(event)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const errorUnmatchedPurchases = "unmatched purchases"
+
+// A purchased item is identified by name (or alias) or by barcode, optionally with what was paid for it
+type purchase struct {
+	Barcode  string  `json:"barcode"`
+	Name     string  `json:"name"`
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+	Store    string  `json:"store"`
+	Unit     string  `json:"unit"`
+}
+
+func (p purchase) String() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.Barcode
+}
+
+// Pick the ingredient document a purchase restocks, preferring one that isn't already stocked or matched earlier in the trip
+func matchPurchase(p purchase, ingredients []bson.M, names map[string]string, matched []bson.M) (bson.M, bool) {
+	var candidates []bson.M
+	if p.Barcode != "" {
+		for _, ingredient := range ingredients {
+			if barcode, _ := ingredient["barcode"].(string); barcode == p.Barcode {
+				candidates = append(candidates, ingredient)
+			}
+		}
+	} else if name, ok := matchIngredient(strings.ToLower(strings.TrimSpace(p.Name)), names); ok {
+		for _, ingredient := range ingredients {
+			if ingredient["name"] == name {
+				candidates = append(candidates, ingredient)
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		taken := false
+		for _, m := range matched {
+			if m["_id"] == candidate["_id"] {
+				taken = true
+				break
+			}
+		}
+		if stocked, _ := candidate["haveStocked"].(bool); !stocked && !taken {
+			return candidate, true
+		}
+	}
+	if len(candidates) > 0 {
+		return candidates[0], true
+	}
+	return nil, false
+}
+
+// What an ingredient has on hand after a purchase, adding to the amount already stocked when the units allow it
+func restockAmount(ingredient bson.M, p purchase) bson.M {
+	value, unit, ok := parseAmount(ingredient["amount"])
+	if !ok {
+		return bson.M{"unit": p.Unit, "value": p.Quantity}
+	}
+	if p.Unit == "" {
+		p.Unit = unit
+	}
+	if stocked, _ := ingredient["haveStocked"].(bool); stocked {
+		if quantity, err := utils.ConvertUnits(p.Quantity, p.Unit, unit); err == nil {
+			return bson.M{"unit": unit, "value": value + quantity}
+		}
+	}
+	return bson.M{"unit": p.Unit, "value": p.Quantity}
+}
+
+// Mark each matched ingredient stocked, putting back the ones already written if any update fails
+func restockIngredients(ctx context.Context, matched []bson.M, purchases []purchase) ([]string, error) {
+	log := logrus.WithFields(logrus.Fields{"at": "api.restockIngredients"})
+
+	now := time.Now()
+	nowMs := int64(now.UTC().UnixNano()) / int64(time.Millisecond)
+	restocked := []string{}
+	originals := []bson.M{}
+	originalIds := []interface{}{}
+
+	// A document bought more than once builds on its earlier restock, but is only put back to how it was before the trip
+	current := map[interface{}]bson.M{}
+	for i, ingredient := range matched {
+		id := ingredient["_id"]
+		before := ingredient
+		updated, seen := current[id]
+		if seen {
+			ingredient = updated
+		}
+
+		fields := bson.M{
+			"haveStocked": true,
+			"stockedDate": nowMs,
+			"updated":     nowMs,
+		}
+		storeIn, _ := ingredient["storeIn"].(string)
+		if expirationDate, ok := lifespanExpiration(ingredient, storeIn, now); ok {
+			fields["expirationDate"] = expirationDate
+		}
+		if p := purchases[i]; p.Quantity > 0 {
+			fields["amount"] = restockAmount(ingredient, p)
+		}
+
+		// Remember how to undo this update
+		set := bson.M{}
+		unset := bson.M{}
+		for _, key := range []string{"amount", "expirationDate", "haveStocked", "stockedDate", "updated"} {
+			if value, ok := before[key]; ok {
+				set[key] = value
+			} else {
+				unset[key] = ""
+			}
+		}
+		original := bson.M{"$set": set}
+		if len(unset) > 0 {
+			original["$unset"] = unset
+		}
+
+		filter := bson.D{{"_id", id}}
+		_, _, err := configuration.Mongo.UpdateOneDocument(ctx, config.MongoCollectionIngredients, filter, bson.M{"$set": fields})
+		if err != nil {
+			log.WithFields(logrus.Fields{"ingredient": id}).WithError(err).Error("Failed to restock ingredient")
+			for j, restore := range originals {
+				_, _, rollbackErr := configuration.Mongo.UpdateOneDocument(ctx, config.MongoCollectionIngredients, bson.D{{"_id", originalIds[j]}}, restore)
+				if rollbackErr != nil {
+					log.WithFields(logrus.Fields{"ingredient": originalIds[j]}).WithError(rollbackErr).Error("Failed to roll back ingredient")
+				}
+			}
+			return nil, err
+		}
+
+		if !seen {
+			originals = append(originals, original)
+			originalIds = append(originalIds, id)
+		}
+		updated = bson.M{}
+		for key, value := range ingredient {
+			updated[key] = value
+		}
+		for key, value := range fields {
+			updated[key] = value
+		}
+		current[id] = updated
+		if name, ok := ingredient["name"].(string); ok && !utils.Contains(restocked, name) {
+			restocked = append(restocked, name)
+		}
+	}
+	return restocked, nil
+}
+
+// Remember what was bought off the open shopping list, its rollover still counts these once they're off the list
+func recordRestockedItems(ctx context.Context, itemText []string) error {
+	card, err := configuration.ShoppingList.GetShoppingList()
+	if err != nil || card == nil {
+		return err
+	}
+
+	filter := bson.D{{"listId", card.ID}}
+	update := bson.M{"$addToSet": bson.M{"restocked": bson.M{"$each": itemText}}}
+	matched, _, err := configuration.Mongo.UpdateOneDocument(ctx, config.MongoCollectionShoppingRestocked, filter, update)
+	if err != nil || matched > 0 {
+		return err
+	}
+
+	open := bson.M{"_id": primitive.NewObjectID(), "listId": card.ID, "restocked": itemText}
+	return configuration.Mongo.InsertManyDocuments(ctx, config.MongoCollectionShoppingRestocked, []interface{}{open})
+}
+
+// Restock the ingredients named by checked off shopping list items, then take those items off the list.
+// Items that don't match an ingredient are left on the list and returned.
+func restockShoppingItems(ctx context.Context, itemText []string) ([]string, []string, error) {
+	log := logrus.WithFields(logrus.Fields{"at": "api.restockShoppingItems"})
+
+	ingredients, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, bson.M{}, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	names := ingredientNameIndex(ingredients)
+
+	matched := []bson.M{}
+	purchases := []purchase{}
+	matchedText := []string{}
+	unmatched := []string{}
+	for _, text := range itemText {
+		p := purchase{Name: utils.ShoppingItemName(text)}
+		if ingredient, ok := matchPurchase(p, ingredients, names, matched); ok {
+			matched = append(matched, ingredient)
+			purchases = append(purchases, p)
+			matchedText = append(matchedText, text)
+		} else {
+			unmatched = append(unmatched, text)
+		}
+	}
+	if len(matched) == 0 {
+		return []string{}, unmatched, nil
+	}
+
+	restocked, err := restockIngredients(ctx, matched, purchases)
+	if err != nil {
+		return nil, unmatched, err
+	}
+
+	err = recordRestockedItems(ctx, matchedText)
+	if err != nil {
+		log.WithError(err).Error("Failed to record restocked items")
+	}
+
+	// Removing the items keeps the next sync from restocking them again
+	err = configuration.ShoppingList.RemoveFromShoppingList(matchedText)
+	if err != nil {
+		log.WithError(err).Error("Failed to remove items from shopping list")
+	}
+
+	cookable, err := refreshCookable(ctx, restocked)
+	if err != nil {
+		return restocked, unmatched, err
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(cookable), "value": cookable}).Debug("Cookable")
+	}
+	return restocked, unmatched, nil
+}
+
+// Restock what was bought: every purchase must match an ingredient before anything is written
+func postShoppingTrip(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postShoppingTrip",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse purchases
+	var purchases []purchase
+	err = json.Unmarshal(bytes, &purchases)
+	if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+		// Invalid request body
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode purchases")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode purchases")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(purchases), "state": "unmarshalled", "value": purchases}).Debug("Request body")
+	}
+
+	// Grab every ingredient to match names, aliases and barcodes against
+	ingredients, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, bson.M{}, nil)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get ingredients")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	names := ingredientNameIndex(ingredients)
+
+	matched := []bson.M{}
+	unmatched := []string{}
+	for _, p := range purchases {
+		if ingredient, ok := matchPurchase(p, ingredients, names, matched); ok {
+			matched = append(matched, ingredient)
+		} else {
+			unmatched = append(unmatched, p.String())
+		}
+	}
+	if len(unmatched) > 0 {
+		err := fmt.Errorf("%s: %s", errorUnmatchedPurchases, strings.Join(unmatched, ", "))
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to match purchases")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Restock each ingredient
+	restocked, err := restockIngredients(ctx, matched, purchases)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to restock ingredients")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(restocked), "value": restocked}).Debug("Restocked")
+	}
+
+	// Remember what was paid, the trip already happened so a failure here doesn't undo it
+	recorded, err := recordPrices(ctx, matched, purchases, "", time.Now(), priceSourceTrip)
+	if err != nil {
+		log.WithError(err).Error("Failed to record prices")
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(recorded), "value": recorded}).Debug("Recorded prices")
+	}
+
+	// Tick the purchases off the shopping list
+	checkedOff, err := configuration.ShoppingList.CheckOffShoppingList(restocked)
+	if err != nil {
+		log.WithError(err).Error("Failed to check off shopping list")
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(checkedOff), "value": checkedOff}).Debug("Checked off shopping list")
+	}
+
+	// Recipes using what was bought may be cookable now
+	cookable, err := refreshCookable(ctx, restocked)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to determine cookable")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Prepare to respond with a summary of the trip
+	marshalled, err := json.Marshal(bson.M{
+		"checkedOff": checkedOff,
+		"cookable":   cookable,
+		"restocked":  restocked,
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode summary")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(restocked), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
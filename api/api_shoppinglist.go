@@ -0,0 +1,345 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const errorShoppingListNotFound = "shopping list not found"
+const errorShoppingListNoItems = "no items given"
+
+// Changes to the shopping list: items to check off and items to take off, both by name
+type shoppingListPatch struct {
+	Check  []string `json:"check"`
+	Remove []string `json:"remove"`
+}
+
+// The current shopping list with its items, whichever provider keeps it, and the same items grouped by section.
+// Items are priced at what was last paid for them, and the estimated total covers what's left to buy.
+func marshalShoppingList(list *config.ShoppingList, items []config.ShoppingItem, prices map[string]primitive.M) ([]byte, error) {
+	entries := []bson.M{}
+	names, grouped := utils.GroupShoppingItems(items)
+	sections := []bson.M{}
+	estimatedTotal := 0.0
+	unpriced := []string{}
+	for _, name := range names {
+		sectionEntries := []bson.M{}
+		for _, item := range grouped[name] {
+			entry := bson.M{"checked": item.Checked, "name": item.Name, "section": name}
+			itemName := utils.ShoppingItemName(item.Name)
+			if price, ok := estimateCost(prices[itemName], 0, "", false); ok {
+				entry["price"] = roundCents(price)
+				if !item.Checked {
+					estimatedTotal += price
+				}
+			} else if !item.Checked {
+				unpriced = append(unpriced, itemName)
+			}
+			entries = append(entries, entry)
+			sectionEntries = append(sectionEntries, entry)
+		}
+		sections = append(sections, bson.M{"items": sectionEntries, "name": name})
+	}
+
+	document := bson.M{
+		"estimatedTotal": roundCents(estimatedTotal),
+		"id":             list.ID,
+		"items":          entries,
+		"name":           list.Name,
+		"sections":       sections,
+		"unpriced":       unpriced,
+		"url":            list.URL,
+	}
+	if list.Due != nil {
+		document["due"] = int64(list.Due.UTC().UnixNano()) / int64(time.Millisecond)
+	}
+	return json.Marshal(document)
+}
+
+// Respond with the current shopping list, or 404 when there isn't one
+func writeShoppingList(ctx context.Context, response http.ResponseWriter, log *logrus.Entry, status int) {
+	list, err := configuration.ShoppingList.GetShoppingList()
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get shopping list")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else if list == nil {
+		err := errors.New(errorShoppingListNotFound)
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to get shopping list")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	items, err := configuration.ShoppingList.GetShoppingListItems(list)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get shopping list items")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	names := []string{}
+	for _, item := range items {
+		names = append(names, utils.ShoppingItemName(item.Name))
+	}
+	prices, err := latestPrices(ctx, names)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get prices")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	marshalled, err := marshalShoppingList(list, items, prices)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode shopping list")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(items), "size": len(marshalled), "status": status}).Info("Succeeded")
+		response.WriteHeader(status)
+		response.Write(marshalled)
+	}
+}
+
+func getShoppingList(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getShoppingList",
+		"method": "GET",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	writeShoppingList(ctx, response, log, http.StatusOK)
+}
+
+// Add items to the current shopping list, starting a new one when there isn't one
+func postShoppingList(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postShoppingList",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse items, all listed under the given section (or the default one)
+	var body struct {
+		Items   []string `json:"items"`
+		Section string   `json:"section"`
+	}
+	err = json.Unmarshal(bytes, &body)
+	if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+		// Invalid request body
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode items")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode items")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else if len(body.Items) == 0 {
+		err := errors.New(errorShoppingListNoItems)
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode items")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(body.Items), "state": "unmarshalled", "value": body.Items}).Debug("Request body")
+	}
+
+	list, err := configuration.ShoppingList.GetShoppingList()
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get shopping list")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	adding := []config.ShoppingItem{}
+	for _, name := range body.Items {
+		adding = append(adding, config.ShoppingItem{Name: name, Section: body.Section})
+	}
+
+	// No list yet, start one due like the expiration job's
+	if list == nil {
+		dueDate := shoppingListDueDate(time.Now())
+		url, err := configuration.ShoppingList.CreateShoppingList(&dueDate, adding)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to create shopping list")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		} else {
+			log.WithFields(logrus.Fields{"url": url}).Debug("Created shopping list")
+		}
+		writeShoppingList(ctx, response, log, http.StatusCreated)
+		return
+	}
+
+	// Adding reconciles the whole list, so keep everything already on it
+	items, err := configuration.ShoppingList.GetShoppingListItems(list)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get shopping list items")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+	wanted := []config.ShoppingItem{}
+	for _, item := range items {
+		if !item.Checked {
+			wanted = append(wanted, item)
+		}
+	}
+	wanted = append(wanted, adding...)
+
+	url, err := configuration.ShoppingList.AddToShoppingList(wanted)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to add to shopping list")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"url": url}).Debug("Updated shopping list")
+	}
+	writeShoppingList(ctx, response, log, http.StatusOK)
+}
+
+// Check items off or take them off the current shopping list
+func patchShoppingList(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.patchShoppingList",
+		"method": "PATCH",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Parse changes
+	var patch shoppingListPatch
+	err = json.Unmarshal(bytes, &patch)
+	if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+		// Invalid request body
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode changes")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode changes")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"state": "unmarshalled", "value": patch}).Debug("Request body")
+	}
+
+	list, err := configuration.ShoppingList.GetShoppingList()
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get shopping list")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else if list == nil {
+		err := errors.New(errorShoppingListNotFound)
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to get shopping list")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	if len(patch.Check) > 0 {
+		checked, err := configuration.ShoppingList.CheckOffShoppingList(patch.Check)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to check off shopping list")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		} else {
+			log.WithFields(logrus.Fields{"quantity": len(checked), "value": checked}).Debug("Checked off shopping list")
+		}
+	}
+
+	if len(patch.Remove) > 0 {
+		// Items are removed by their full text, so look up which ones carry the given names
+		items, err := configuration.ShoppingList.GetShoppingListItems(list)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get shopping list items")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		}
+		removing := []string{}
+		for _, item := range items {
+			for _, name := range patch.Remove {
+				if strings.EqualFold(utils.ShoppingItemName(item.Name), utils.ShoppingItemName(name)) {
+					removing = append(removing, item.Name)
+					break
+				}
+			}
+		}
+
+		err = configuration.ShoppingList.RemoveFromShoppingList(removing)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to remove from shopping list")
+			response.WriteHeader(http.StatusInternalServerError)
+			response.Write([]byte(err.Error()))
+			return
+		} else {
+			log.WithFields(logrus.Fields{"quantity": len(removing), "value": removing}).Debug("Removed from shopping list")
+		}
+	}
+
+	writeShoppingList(ctx, response, log, http.StatusOK)
+}
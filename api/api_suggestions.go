@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Each member who favours the recipe counts once, well-rated recipes get up to one more
+// point, and anything cooked since "recent" loses two
+func scoreRecipe(recipe bson.M, h household, recent int64) float64 {
+	score := float64(recipeFavorites(recipe, h))
+	if rating, ok := utils.Float64FromNumber(recipe["rating"]); ok {
+		score += rating / 5
+	}
+	if lastCooked, ok := utils.Float64FromNumber(recipe["lastCooked"]); ok && int64(lastCooked) >= recent {
+		score -= 2
+	}
+	return score
+}
+
+func getSuggestions(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.getSuggestions",
+		"method": "GET",
+	})
+	qpNameRecent := "recent"
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Extract query parameters
+	queryParams := request.URL.Query()
+	log.WithFields(logrus.Fields{"value": queryParams}).Debug("Query parameters")
+
+	query, err := parseRecipeQuery(queryParams)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse recipe query")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"value": query}).Debug("Recipe query")
+	}
+
+	// Recipes cooked within the last few days are de-prioritized
+	recentDays := 3
+	if qpRecent := queryParams.Get(qpNameRecent); qpRecent != "" {
+		l := log.WithFields(logrus.Fields{"name": qpNameRecent, "value": qpRecent})
+		l.Trace("Query parameter handling")
+		recentDays, err = strconv.Atoi(qpRecent)
+		if err != nil {
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Error("Failed to parse number")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		} else if recentDays < 0 {
+			err := fmt.Errorf("recent must not be negative: %d", recentDays)
+			l.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Invalid number of days")
+			response.WriteHeader(http.StatusBadRequest)
+			response.Write([]byte(err.Error()))
+			return
+		}
+	}
+	recent := int64(time.Now().Add(-time.Duration(recentDays)*24*time.Hour).UTC().UnixNano()) / int64(time.Millisecond)
+
+	// Grab the cookable recipes
+	documents, h, err := findCookableRecipes(ctx, query)
+	if err != nil && strings.HasPrefix(err.Error(), utils.ErrorMemberNotFound) {
+		log.WithFields(logrus.Fields{"status": http.StatusNotFound}).WithError(err).Warn("Failed to find member")
+		response.WriteHeader(http.StatusNotFound)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to identify cookable recipes")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(documents), "value": documents}).Debug("Documents found")
+	}
+
+	// Rank the recipes
+	for _, document := range documents {
+		document["score"] = scoreRecipe(document, h, recent)
+	}
+	sort.SliceStable(documents, func(i, j int) bool {
+		return documents[i]["score"].(float64) > documents[j]["score"].(float64)
+	})
+
+	// Prepare to respond with documents
+	marshalled, err := json.Marshal(documents)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode documents")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(documents), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
@@ -1,12 +1,22 @@
 package api
 
 import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -17,6 +27,7 @@ import (
 	"github.com/tyler-cromwell/forage/config"
 	"github.com/tyler-cromwell/forage/tests/mocks"
 	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type testRequest struct {
@@ -24,6 +35,7 @@ type testRequest struct {
 	endpoint        string
 	routeVariables  map[string]string
 	queryParameters map[string]string
+	headers         map[string]string
 	body            io.ReadCloser
 }
 
@@ -38,6 +50,34 @@ func (errReader) Read(p []byte) (n int, err error) {
 	return 0, errors.New(errorIoReadAll)
 }
 
+// Build a zip archive of the given files, e.g. a recipe manager's export
+func zipArchive(files map[string][]byte) []byte {
+	var buffer bytes.Buffer
+	writer := zip.NewWriter(&buffer)
+	for name, contents := range files {
+		file, _ := writer.Create(name)
+		file.Write(contents)
+	}
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func gzipped(contents string) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	writer.Write([]byte(contents))
+	writer.Close()
+	return buffer.Bytes()
+}
+
+// Sign a webhook body the way Trello does
+func trelloSigned(body string) map[string]string {
+	mac := hmac.New(sha1.New, []byte(configuration.TrelloSecret))
+	mac.Write([]byte(body))
+	mac.Write([]byte(configuration.TrelloCallback))
+	return map[string]string{"X-Trello-Webhook": base64.StdEncoding.EncodeToString(mac.Sum(nil))}
+}
+
 func TestAPI(t *testing.T) {
 	// Discard logging output
 	logrus.SetOutput(io.Discard)
@@ -53,6 +93,10 @@ func TestAPI(t *testing.T) {
 		LogrusLevel:    logrus.DebugLevel,
 		ListenSocket:   listenSocket,
 		Scheduler:      gocron.NewScheduler(loc),
+		ShoppingList:   &mocks.MockTrello{},
+		TrelloSecret:   "secret",
+		TrelloCallback: "http://localhost:8001/webhooks/trello",
+		CalendarToken:  "calendartoken",
 	}
 
 	subtests := []struct {
@@ -62,6 +106,69 @@ func TestAPI(t *testing.T) {
 		response    testResponse
 		mongoClient mocks.MockMongo
 	}{
+		{
+			/*
+			 */
+			"getCalendar200#1",
+			getCalendar,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/calendar.ics",
+				queryParameters: map[string]string{"storeIn": "freezer", "token": "calendartoken"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//Forage//Expirations//EN\r\nCALSCALE:GREGORIAN\r\nMETHOD:PUBLISH\r\nX-WR-CALNAME:Forage\r\nEND:VCALENDAR\r\n",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCalendar401#1",
+			getCalendar,
+			testRequest{
+				method:   "GET",
+				endpoint: "/calendar.ics",
+			},
+			testResponse{
+				status: http.StatusUnauthorized,
+				body:   errorCalendarToken,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCalendar401#2",
+			getCalendar,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/calendar.ics",
+				queryParameters: map[string]string{"token": "nope"},
+			},
+			testResponse{
+				status: http.StatusUnauthorized,
+				body:   errorCalendarToken,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCalendar500#1",
+			getCalendar,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/calendar.ics",
+				queryParameters: map[string]string{"token": "calendartoken"},
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic},
+		},
 		{
 			/*
 			 */
@@ -206,151 +313,3280 @@ func TestAPI(t *testing.T) {
 		{
 			/*
 			 */
-			"getCookable500#1",
+			"getCookable200#2",
 			getCookable,
 			testRequest{
-				method:   "GET",
-				endpoint: "/getCookable",
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"servings": "2"},
 			},
 			testResponse{
-				status: http.StatusInternalServerError,
-				body:   errorBasic,
+				status: http.StatusOK,
+				body:   bodyCookablesServings2,
 			},
 			mocks.MockMongo{
-				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsServings,
 			},
 		},
 		{
 			/*
 			 */
-			"getCookable500#2",
+			"getCookable200#3",
 			getCookable,
 			testRequest{
-				method:   "GET",
-				endpoint: "/getCookable",
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"servings": "4"},
 			},
 			testResponse{
-				status: http.StatusInternalServerError,
-				body:   errorDecodeFail,
+				status: http.StatusOK,
+				body:   bodyEmpty,
 			},
 			mocks.MockMongo{
-				OverrideFindManyDocuments: OverrideFindManyDocumentsDecodeFail,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsServings,
 			},
 		},
 		{
 			/*
 			 */
-			"getExpired200#1",
-			getExpired,
+			"getCookable200#4",
+			getCookable,
 			testRequest{
-				method:   "GET",
-				endpoint: "/expired",
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"excludeAllergens": "dairy"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[" + bodyTaggedSalad + "]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTagged,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable200#4b",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"excludeAllergens": "dairy"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[" + bodyTaggedSalad + "]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTaggedUnknown,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable200#5",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"diet": "Vegetarian"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[" + bodyTaggedPancakes + "," + bodyTaggedSalad + "]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTagged,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable200#6",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"diet": "vegan,vegetarian", "excludeAllergens": "nuts"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[" + bodyTaggedSalad + "]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTagged,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable200#7",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"for": "Alice"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[" + bodyTaggedSalad + "," + bodyTaggedPancakes + "]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHousehold,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable200#8",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"for": "Alice,Bob"},
 			},
 			testResponse{
 				status: http.StatusOK,
 				body:   bodyEmpty,
 			},
-			mocks.MockMongo{},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHousehold,
+			},
 		},
 		{
 			/*
 			 */
-			"getExpired500#1",
-			getExpired,
+			"getCookable200#9",
+			getCookable,
 			testRequest{
 				method:   "GET",
-				endpoint: "/expired",
+				endpoint: "/cookable",
 			},
 			testResponse{
-				status: http.StatusInternalServerError,
-				body:   errorBasic,
+				status: http.StatusOK,
+				body:   bodyEquipmentBread,
 			},
 			mocks.MockMongo{
-				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsEquipment,
 			},
 		},
 		{
 			/*
 			 */
-			"getExpired500#2",
-			getExpired,
+			"getCookable200#10",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"servings": "2"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyEquipmentBread,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsEquipment,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable200#11",
+			getCookable,
 			testRequest{
 				method:   "GET",
-				endpoint: "/expired",
+				endpoint: "/cookable",
 			},
 			testResponse{
-				status: http.StatusInternalServerError,
-				body:   errorDecodeFail,
+				status: http.StatusOK,
+				body:   "[{\"ingredients\":[\"Flour\"],\"isCookable\":true,\"name\":\"Pizza\",\"requires\":[\"Oven\"]}]",
 			},
 			mocks.MockMongo{
-				OverrideFindManyDocuments: OverrideFindManyDocumentsDecodeFail,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsEquipmentRestored,
 			},
 		},
 		{
 			/*
 			 */
-			"getExpiring200#1",
-			getExpiring,
+			"getCookable200#11",
+			getCookable,
 			testRequest{
 				method:          "GET",
-				endpoint:        "/expiring",
-				queryParameters: queryParams1020,
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"maxMinutes": "20"},
 			},
 			testResponse{
 				status: http.StatusOK,
-				body:   bodyEmpty,
+				body:   bodyQuickOmelette,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsQuick,
 			},
-			mocks.MockMongo{},
 		},
 		{
 			/*
 			 */
-			"getExpiring200#2",
-			getExpiring,
+			"getCookable200#12",
+			getCookable,
 			testRequest{
 				method:          "GET",
-				endpoint:        "/expiring",
-				queryParameters: queryParams2030,
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"maxMinutes": "10"},
 			},
 			testResponse{
 				status: http.StatusOK,
-				body:   bodyExpiring,
+				body:   bodyQuickOmelette,
 			},
 			mocks.MockMongo{
-				OverrideFindManyDocuments: OverrideFindManyDocumentsIngredientRange,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsQuick,
 			},
 		},
 		{
 			/*
 			 */
-			"getExpiring400#1",
-			getExpiring,
+			"getCookable400#1",
+			getCookable,
 			testRequest{
-				method:   "GET",
-				endpoint: "/expiring",
-				queryParameters: map[string]string{
-					"from": "x",
-					"to":   "20",
-				},
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"servings": "0"},
 			},
 			testResponse{
 				status: http.StatusBadRequest,
-				body:   errorStrconvX,
+				body:   errorServingsZero,
 			},
 			mocks.MockMongo{},
 		},
 		{
 			/*
 			 */
-			"getExpiring400#2",
-			getExpiring,
+			"getCookable400#2",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"servings": "x"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "strconv.Atoi: parsing \"x\": invalid syntax",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCookable400#3",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"maxMinutes": "0"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "maxMinutes must be positive: 0",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCookable400#4",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"maxMinutes": "x"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "strconv.Atoi: parsing \"x\": invalid syntax",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCookable404#1",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"for": "Alice,Carol"},
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   "member not found: Carol",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHousehold,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable500#1",
+			getCookable,
 			testRequest{
 				method:   "GET",
-				endpoint: "/expiring",
-				queryParameters: map[string]string{
-					"from": "10",
-					"to":   "y",
-				},
+				endpoint: "/getCookable",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable500#3",
+			getCookable,
+			testRequest{
+				method:   "GET",
+				endpoint: "/cookable",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsRecipeOrErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable500#4",
+			getCookable,
+			testRequest{
+				method:   "GET",
+				endpoint: "/cookable",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsEquipmentError,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable500#5",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"servings": "2"},
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsEquipmentError,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable500#6",
+			getCookable,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/cookable",
+				queryParameters: map[string]string{"maxMinutes": "20"},
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsQuickError,
+			},
+		},
+		{
+			/*
+			 */
+			"getCookable500#2",
+			getCookable,
+			testRequest{
+				method:   "GET",
+				endpoint: "/getCookable",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDecodeFail,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsDecodeFail,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions200#1",
+			getSuggestions,
+			testRequest{
+				method:   "GET",
+				endpoint: "/suggestions",
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[{\"allergens\":[\"dairy\",\"gluten\"],\"diets\":[\"vegetarian\"],\"ingredients\":[\"Flour\",\"Milk\"],\"isCookable\":true,\"name\":\"Pancakes\",\"score\":0},{\"diets\":[\"vegan\",\"vegetarian\"],\"ingredients\":[\"Lettuce\"],\"isCookable\":true,\"name\":\"Salad\",\"score\":0}]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHousehold,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions200#2",
+			getSuggestions,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/suggestions",
+				queryParameters: map[string]string{"for": "Alice"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[{\"diets\":[\"vegan\",\"vegetarian\"],\"ingredients\":[\"Lettuce\"],\"isCookable\":true,\"name\":\"Salad\",\"score\":1},{\"allergens\":[\"dairy\",\"gluten\"],\"diets\":[\"vegetarian\"],\"ingredients\":[\"Flour\",\"Milk\"],\"isCookable\":true,\"name\":\"Pancakes\",\"score\":0}]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHousehold,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions200#3",
+			getSuggestions,
+			testRequest{
+				method:   "GET",
+				endpoint: "/suggestions",
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[{\"ingredients\":[\"Lettuce\"],\"isCookable\":true,\"lastCooked\":0,\"name\":\"Salad\",\"rating\":2.5,\"score\":0.5},{\"ingredients\":[\"Flour\",\"Milk\"],\"isCookable\":true,\"lastCooked\":4102444800000,\"name\":\"Pancakes\",\"rating\":5,\"score\":-1}]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHistory,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions200#4",
+			getSuggestions,
+			testRequest{
+				method:   "GET",
+				endpoint: "/suggestions",
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[{\"ingredients\":[\"Flour\"],\"isCookable\":true,\"name\":\"Bread\",\"requires\":[\"Oven\",\"Stand Mixer\"],\"score\":0}]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsEquipment,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions200#5",
+			getSuggestions,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/suggestions",
+				queryParameters: map[string]string{"maxMinutes": "20"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[{\"cookTime\":5,\"ingredients\":[\"Egg\"],\"isCookable\":true,\"name\":\"Omelette\",\"prepTime\":5,\"score\":0}]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsQuick,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions400#1",
+			getSuggestions,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/suggestions",
+				queryParameters: map[string]string{"servings": "0"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorServingsZero,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getSuggestions400#2",
+			getSuggestions,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/suggestions",
+				queryParameters: map[string]string{"recent": "-1"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "recent must not be negative: -1",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getSuggestions404#1",
+			getSuggestions,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/suggestions",
+				queryParameters: map[string]string{"for": "Carol"},
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   "member not found: Carol",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHousehold,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions500#1",
+			getSuggestions,
+			testRequest{
+				method:   "GET",
+				endpoint: "/suggestions",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getSuggestions500#2",
+			getSuggestions,
+			testRequest{
+				method:   "GET",
+				endpoint: "/suggestions",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDecodeFail,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsDecodeFail,
+			},
+		},
+		{
+			/*
+			 */
+			"postCook201#1",
+			postCook,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/cook",
+				routeVariables:  routeVarsRecipe,
+				body:            io.NopCloser(strings.NewReader("{\"date\": 200, \"notes\": \"Great\", \"rating\": 5}")),
+				queryParameters: map[string]string{"servings": "4"},
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   "{\"date\":200,\"name\":null,\"notes\":\"Great\",\"rating\":5,\"recipe\":null,\"servings\":4,\"source\":\"cook\"}",
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeServings,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHistory,
+			},
+		},
+		{
+			/*
+			 */
+			"postCook400#1",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipeInvalid,
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorDocumentIdInvalid,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCook400#2",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCook400#3",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"rating\": 7}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "rating must be between 1 and 5: 7",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCook400#4",
+			postCook,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/cook",
+				routeVariables:  routeVarsRecipe,
+				queryParameters: map[string]string{"servings": "0"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorServingsZero,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCook404#1",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"date\": 200, \"notes\": \"Great\", \"rating\": 5}")),
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   utils.ErrorMongoNoDocuments,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentNone,
+			},
+		},
+		{
+			/*
+			 */
+			"postCook500#1",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipeEncodeFail,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDocumentIdEncodeFail,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCook500#2",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCook500#3",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"rating\": \"5\"}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   "json: cannot unmarshal string into Go struct field .rating of type float64",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCook500#4",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"date\": 200, \"notes\": \"Great\", \"rating\": 5}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postCook500#5",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"date\": 200, \"notes\": \"Great\", \"rating\": 5}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeServings,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postCook500#6",
+			postCook,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/cook",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"date\": 200, \"notes\": \"Great\", \"rating\": 5}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeServings,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHistory,
+				OverrideUpdateOneDocument: OverrideUpdateOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postCookLog201#1",
+			postCookLog,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/history",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"date\": 300}")),
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   "{\"date\":300,\"name\":null,\"recipe\":null,\"source\":\"log\"}",
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeServings,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsHistory,
+			},
+		},
+		{
+			/*
+			 */
+			"getExpired200#1",
+			getExpired,
+			testRequest{
+				method:   "GET",
+				endpoint: "/expired",
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyEmpty,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getExpired500#1",
+			getExpired,
+			testRequest{
+				method:   "GET",
+				endpoint: "/expired",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getExpired500#2",
+			getExpired,
+			testRequest{
+				method:   "GET",
+				endpoint: "/expired",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDecodeFail,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsDecodeFail,
+			},
+		},
+		{
+			/*
+			 */
+			"getExpiring200#1",
+			getExpiring,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/expiring",
+				queryParameters: queryParams1020,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyEmpty,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getExpiring200#2",
+			getExpiring,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/expiring",
+				queryParameters: queryParams2030,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyExpiring,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsIngredientRange,
+			},
+		},
+		{
+			/*
+			 */
+			"getExpiring400#1",
+			getExpiring,
+			testRequest{
+				method:   "GET",
+				endpoint: "/expiring",
+				queryParameters: map[string]string{
+					"from": "x",
+					"to":   "20",
+				},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvX,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getExpiring400#2",
+			getExpiring,
+			testRequest{
+				method:   "GET",
+				endpoint: "/expiring",
+				queryParameters: map[string]string{
+					"from": "10",
+					"to":   "y",
+				},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvY,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getExpiring500#1",
+			getExpiring,
+			testRequest{
+				method:   "GET",
+				endpoint: "/expiring",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getExpiring500#2",
+			getExpiring,
+			testRequest{
+				method:   "GET",
+				endpoint: "/expiring",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDecodeFail,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsDecodeFail,
+			},
+		},
+		{
+			/*
+			 */
+			"getOneDocument200#1a",
+			getOneDocument,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsIngredientsDoc,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "null",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getOneDocument200#1b",
+			getOneDocument,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsRecipesDoc,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyCookable,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipe,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsIngredient,
+			},
+		},
+		{
+			/*
+			 */
+			"getOneDocument200#1c",
+			getOneDocument,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/documents",
+				routeVariables:  routeVarsRecipesDoc,
+				queryParameters: map[string]string{"servings": "2"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyCookableServings2,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeServings,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsIngredientAmount,
+				OverrideUpdateOneDocument: OverrideUpdateOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getOneDocument200#1d",
+			getOneDocument,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/documents",
+				routeVariables:  routeVarsRecipesDoc,
+				queryParameters: map[string]string{"servings": "4"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyCookableServings4,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeServings,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsIngredientAmount,
+			},
+		},
+		{
+			/*
+			 */
+			"getOneDocument200#1e",
+			getOneDocument,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsRecipesDoc,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyNutritionRecipe,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeNutrition,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsNutrition,
+			},
+		},
+		{
+			/*
+			 */
+			"getOneDocument500#7",
+			getOneDocument,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsRecipesDoc,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeNutrition,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsNutritionErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getOneDocument200#1f",
+			getOneDocument,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsRecipesDoc,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyCostRecipe,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeNutrition,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsPrices,
+			},
+		},
+		{
+			/*
+			 */
+			"getOneDocument200#1g",
+			getOneDocument,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsRecipesDoc,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyUnpricedRecipe,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeNutrition,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsPricesErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport200#1",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader(jsonLDRecipeUnmatched)),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"inserted\":false,\"recipes\":" + bodyImportPaella,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport201#1",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader(jsonLDRecipe)),
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   bodyImportPancakes,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport201#2",
+			postRecipeImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import",
+				body:            io.NopCloser(strings.NewReader(jsonLDRecipeUnmatched)),
+				queryParameters: map[string]string{"force": "true"},
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   "{\"inserted\":true,\"recipes\":" + bodyImportPaella,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport400#1",
+			postRecipeImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import",
+				body:            io.NopCloser(strings.NewReader(jsonLDRecipe)),
+				queryParameters: map[string]string{"force": "lol"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvLol,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport400#2",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport400#3",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader("{\"@type\": \"WebPage\"}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorNoRecipes,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport201#3",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader("[{\"@type\": \"Recipe\", \"name\": \"Toast\"}, {\"@type\": \"Recipe\", \"name\": \"Flatbread\", \"recipeIngredient\": [\"2 cups flour\"]}]")),
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   "{\"inserted\":true,\"recipes\":[{\"ingredients\":[{\"amount\":{\"unit\":\"cup\",\"value\":2},\"name\":\"Flour\"}],\"isCookable\":true,\"name\":\"Flatbread\"}],\"rejected\":[{\"error\":\"no ingredients found\",\"recipe\":\"Toast\"}],\"unmatched\":[]}",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport400#4",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader("{\"@type\": \"Recipe\", \"name\": \"Toast\"}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorNoIngredients + ": Toast",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport500#1",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport500#2",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader(jsonLDRecipe)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport500#3",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader(jsonLDRecipe)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImportCookableError,
+			},
+		},
+		{
+			/*
+			 */
+			"postRecipeImport500#4",
+			postRecipeImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import",
+				body:     io.NopCloser(strings.NewReader(jsonLDRecipe)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments:   OverrideFindManyDocumentsImport,
+				OverrideInsertManyDocuments: OverrideInsertManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postCooklangImport200#1",
+			postCooklangImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/cooklang",
+				body:            io.NopCloser(strings.NewReader(cooklangRecipe + " Add @saffron.")),
+				queryParameters: map[string]string{"name": "Omelette"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyImportOmeletteSaffron,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postCooklangImport201#1",
+			postCooklangImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/cooklang",
+				body:            io.NopCloser(strings.NewReader(cooklangRecipe)),
+				queryParameters: map[string]string{"name": "Omelette"},
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   bodyImportOmelette,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postCooklangImport400#1",
+			postCooklangImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/cooklang",
+				body:            io.NopCloser(strings.NewReader(cooklangRecipe)),
+				queryParameters: map[string]string{"force": "lol"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvLol,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCooklangImport400#2",
+			postCooklangImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/cooklang",
+				body:     io.NopCloser(strings.NewReader(cooklangRecipe)),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorNoRecipes,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postCooklangImport500#1",
+			postCooklangImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/cooklang",
+				body:     io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postCooklangImport500#2",
+			postCooklangImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/cooklang",
+				body:            io.NopCloser(strings.NewReader(cooklangRecipe)),
+				queryParameters: map[string]string{"name": "Omelette"},
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getCooklangExport200#1",
+			getCooklangExport,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/recipes/cooklang",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   ">> servings: 2\n\n@hello{1%pound}\n",
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentRecipeServings,
+			},
+		},
+		{
+			/*
+			 */
+			"getCooklangExport400#1",
+			getCooklangExport,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/recipes/cooklang",
+				routeVariables: routeVarsRecipeInvalid,
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorDocumentIdInvalid,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCooklangExport404#1",
+			getCooklangExport,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/recipes/cooklang",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   utils.ErrorMongoNoDocuments,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentNone,
+			},
+		},
+		{
+			/*
+			 */
+			"getCooklangExport500#1",
+			getCooklangExport,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/recipes/cooklang",
+				routeVariables: routeVarsRecipeEncodeFail,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDocumentIdEncodeFail,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getCooklangExport500#2",
+			getCooklangExport,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/recipes/cooklang",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postMealieImport200#1",
+			postMealieImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/mealie",
+				body:            io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"recipes/pancakes/pancakes.json": []byte(mealieRecipe)}))),
+				queryParameters: map[string]string{"dryRun": "true"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"inserted\":false,\"recipes\":" + bodyImportMealie,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postMealieImport201#1",
+			postMealieImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/mealie",
+				body:     io.NopCloser(strings.NewReader("[" + mealieRecipe + "]")),
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   "{\"inserted\":true,\"recipes\":" + bodyImportMealie,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postMealieImport400#1",
+			postMealieImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/mealie",
+				body:            io.NopCloser(strings.NewReader(mealieRecipe)),
+				queryParameters: map[string]string{"dryRun": "lol"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvLol,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postMealieImport400#2",
+			postMealieImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/mealie",
+				body:     io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postMealieImport400#3",
+			postMealieImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/mealie",
+				body:     io.NopCloser(strings.NewReader("{}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorNoRecipes,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postMealieImport400#4",
+			postMealieImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/mealie",
+				body:     io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"huge.json": make([]byte, importMaxFileSize+1)}))),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorImportTooLarge,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postMealieImport400#5",
+			postMealieImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/mealie",
+				body:     io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"a.json": make([]byte, 9<<20), "b.json": make([]byte, 9<<20), "c.json": make([]byte, 9<<20), "d.json": make([]byte, 9<<20), "e.json": make([]byte, 9<<20), "f.json": make([]byte, 9<<20)}))),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorImportTooLarge,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postMealieImport400#6",
+			postMealieImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/mealie",
+				body:     io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"outer.zip": zipArchive(map[string][]byte{"inner.zip": zipArchive(map[string][]byte{"recipe.json": []byte(mealieRecipe)})})}))),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorImportTooDeep,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPaprikaImport200#1",
+			postPaprikaImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/paprika",
+				body:            io.NopCloser(bytes.NewReader(gzipped(paprikaRecipe))),
+				queryParameters: map[string]string{"dryRun": "true"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"inserted\":false,\"recipes\":" + bodyImportPaprika,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postPaprikaImport201#1",
+			postPaprikaImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/paprika",
+				body:            io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"Pancakes.paprikarecipe": gzipped(paprikaRecipe)}))),
+				queryParameters: map[string]string{"force": "true"},
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   "{\"inserted\":true,\"recipes\":" + bodyImportPaprika,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postPaprikaImport400#1",
+			postPaprikaImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/paprika",
+				body:     io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"Pancakes.paprikarecipe": []byte("{:}")}))),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPaprikaImport400#2",
+			postPaprikaImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/paprika",
+				body:     io.NopCloser(bytes.NewReader([]byte{0x1f, 0x8b, 0x00})),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "unexpected EOF",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPaprikaImport400#3",
+			postPaprikaImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/paprika",
+				body:     io.NopCloser(bytes.NewReader(gzipped(string(make([]byte, importMaxFileSize+1))))),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorImportTooLarge,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPaprikaImport400#4",
+			postPaprikaImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/paprika",
+				body:     io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"a.paprikarecipe": gzipped("{}" + strings.Repeat(" ", 9<<20)), "b.paprikarecipe": gzipped("{}" + strings.Repeat(" ", 9<<20)), "c.paprikarecipe": gzipped("{}" + strings.Repeat(" ", 9<<20)), "d.paprikarecipe": gzipped("{}" + strings.Repeat(" ", 9<<20)), "e.paprikarecipe": gzipped("{}" + strings.Repeat(" ", 9<<20)), "f.paprikarecipe": gzipped("{}" + strings.Repeat(" ", 9<<20))}))),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorImportTooLarge,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPaprikaImport413#1",
+			postPaprikaImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/paprika",
+				body:     io.NopCloser(bytes.NewReader(make([]byte, importMaxTotalSize+1))),
+			},
+			testResponse{
+				status: http.StatusRequestEntityTooLarge,
+				body:   errorImportTooLarge,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTandoorImport200#1",
+			postTandoorImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/tandoor",
+				body:            io.NopCloser(strings.NewReader(tandoorRecipe)),
+				queryParameters: map[string]string{"dryRun": "true"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"inserted\":false,\"recipes\":" + bodyImportTandoor,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postTandoorImport201#1",
+			postTandoorImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/import/tandoor",
+				body:            io.NopCloser(bytes.NewReader(zipArchive(map[string][]byte{"1.zip": zipArchive(map[string][]byte{"recipe.json": []byte(tandoorRecipe), "image.jpg": {}})}))),
+				queryParameters: map[string]string{"force": "true"},
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   "{\"inserted\":true,\"recipes\":" + bodyImportTandoor,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsImport,
+			},
+		},
+		{
+			/*
+			 */
+			"postTandoorImport400#1",
+			postTandoorImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/recipes/import/tandoor",
+				body:     io.NopCloser(strings.NewReader("PK\x03\x04 truncated")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "zip: not a valid zip file",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getGrocyExport200#1",
+			getGrocyExport,
+			testRequest{
+				method:   "GET",
+				endpoint: "/ingredients/export/grocy",
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyGrocyExport,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsGrocy,
+			},
+		},
+		{
+			/*
+			 */
+			"getGrocyExport500#1",
+			getGrocyExport,
+			testRequest{
+				method:   "GET",
+				endpoint: "/ingredients/export/grocy",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postGrocyImport200#1",
+			postGrocyImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/ingredients/import/grocy",
+				body:     io.NopCloser(strings.NewReader("{}")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"ingredients\":[],\"inserted\":false}",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postGrocyImport400#1",
+			postGrocyImport,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/ingredients/import/grocy",
+				body:            io.NopCloser(strings.NewReader(grocyExportBasic)),
+				queryParameters: map[string]string{"dryRun": "lol"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvLol,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postGrocyImport400#2",
+			postGrocyImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/ingredients/import/grocy",
+				body:     io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postGrocyImport500#1",
+			postGrocyImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/ingredients/import/grocy",
+				body:     io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postGrocyImport500#2",
+			postGrocyImport,
+			testRequest{
+				method:   "POST",
+				endpoint: "/ingredients/import/grocy",
+				body:     io.NopCloser(strings.NewReader(grocyExportBasic)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideInsertManyDocuments: OverrideInsertManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postSession400#1",
+			postSession,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/sessions",
+				routeVariables: routeVarsRecipeInvalid,
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorDocumentIdInvalid,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSession400#2",
+			postSession,
+			testRequest{
+				method:          "POST",
+				endpoint:        "/recipes/sessions",
+				routeVariables:  routeVarsRecipe,
+				queryParameters: map[string]string{"servings": "0"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorServingsZero,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSession400#3",
+			postSession,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/sessions",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorRecipeNoSteps,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentRecipeServings,
+			},
+		},
+		{
+			/*
+			 */
+			"postSession404#1",
+			postSession,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/sessions",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   utils.ErrorMongoNoDocuments,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentNone,
+			},
+		},
+		{
+			/*
+			 */
+			"postSession500#1",
+			postSession,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/sessions",
+				routeVariables: routeVarsRecipeEncodeFail,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDocumentIdEncodeFail,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSession500#2",
+			postSession,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/recipes/sessions",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getSession404#1",
+			getSession,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/sessions",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   errorSessionNotFound,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"deleteSession404#1",
+			deleteSession,
+			testRequest{
+				method:         "DELETE",
+				endpoint:       "/sessions",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   errorSessionNotFound,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSessionAdvance404#1",
+			postSessionAdvance,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/sessions/advance",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   errorSessionNotFound,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getSessionEvents404#1",
+			getSessionEvents,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/sessions/events",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   errorSessionNotFound,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSessionTimer400#1",
+			postSessionTimer,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/sessions/timers",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSessionTimer400#2",
+			postSessionTimer,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/sessions/timers",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"duration\": 0}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "duration must be positive: 0",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSessionTimer404#1",
+			postSessionTimer,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/sessions/timers",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"duration\": 60}")),
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   errorSessionNotFound,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSessionTimer500#1",
+			postSessionTimer,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/sessions/timers",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postSessionTimer500#2",
+			postSessionTimer,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/sessions/timers",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"duration\": \"60\"}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   "json: cannot unmarshal string into Go struct field .duration of type int",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove400#1",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipeInvalid,
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorDocumentIdInvalid,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove400#2",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove400#3",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"storeIn\": \"garage\"}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "invalid storage location: garage",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove400#4",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"location\": \"" + documentId + "\"}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "unknown location: " + documentId,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentNone,
+			},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove400#5",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"location\": \"chest\"}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "unknown location: chest",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove500#6",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"location\": \"" + documentId + "\"}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove404#1",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("")),
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   utils.ErrorMongoNoDocuments,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentNone,
+			},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove500#1",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipeEncodeFail,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDocumentIdEncodeFail,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove500#2",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove500#3",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("{\"storeIn\": 1}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   "json: cannot unmarshal number into Go struct field .storeIn of type string",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove500#4",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postIngredientMove500#5",
+			postIngredientMove,
+			testRequest{
+				method:         "POST",
+				endpoint:       "/ingredients/move",
+				routeVariables: routeVarsRecipe,
+				body:           io.NopCloser(strings.NewReader("")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideUpdateOneDocument: OverrideUpdateOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getLocationContents200#1",
+			getLocationContents,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/locations/contents",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyLocationContents,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentLocation,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsLocation,
+			},
+		},
+		{
+			/*
+			 */
+			"getLocationContents400#1",
+			getLocationContents,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/locations/contents",
+				routeVariables: routeVarsRecipeInvalid,
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorDocumentIdInvalid,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getLocationContents404#1",
+			getLocationContents,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/locations/contents",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusNotFound,
+				body:   utils.ErrorMongoNoDocuments,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentNone,
+			},
+		},
+		{
+			/*
+			 */
+			"getLocationContents500#1",
+			getLocationContents,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/locations/contents",
+				routeVariables: routeVarsRecipeEncodeFail,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorDocumentIdEncodeFail,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getLocationContents500#2",
+			getLocationContents,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/locations/contents",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument: OverrideFindOneDocumentErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getLocationContents500#3",
+			getLocationContents,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/locations/contents",
+				routeVariables: routeVarsRecipe,
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentLocation,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"headTrelloWebhook200#1",
+			headTrelloWebhook,
+			testRequest{
+				method:   "HEAD",
+				endpoint: "/webhooks/trello",
+				body:     io.NopCloser(strings.NewReader("")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook200#1",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk (expiring)\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk (expiring)\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"restocked\":[\"Milk\"],\"unmatched\":[]}",
+			},
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTrip},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook200#2",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Saffron\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Saffron\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"restocked\":[],\"unmatched\":[\"Saffron\"]}",
+			},
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTrip},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook200#3",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk (expiring)\", \"state\": \"incomplete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk (expiring)\", \"state\": \"incomplete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook200#4",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk\", \"state\": \"complete\"}, \"card\": {\"name\": \"Camping Trip\"}, \"checklist\": {\"name\": \"Groceries\"}}}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk\", \"state\": \"complete\"}, \"card\": {\"name\": \"Camping Trip\"}, \"checklist\": {\"name\": \"Groceries\"}}}}")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook200#5",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"createCard\"}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"createCard\"}}")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook401#1",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"createCard\"}}")),
+			},
+			testResponse{
+				status: http.StatusUnauthorized,
+				body:   errorWebhookSignature,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook400#1",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{:}"),
+				body:     io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook500#1",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				body:     io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook500#2",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("[]"),
+				body:     io.NopCloser(strings.NewReader("[]")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   "json: cannot unmarshal array into Go value of type api.trelloWebhook",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook500#3",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook500#4",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Flour\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Flour\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTrip, OverrideUpdateOneDocument: OverrideUpdateOneDocumentTripError},
+		},
+		{
+			/*
+			 */
+			"postTrelloWebhook500#5",
+			postTrelloWebhook,
+			testRequest{
+				method:   "POST",
+				endpoint: "/webhooks/trello",
+				headers:  trelloSigned("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Flour\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}"),
+				body:     io.NopCloser(strings.NewReader("{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Flour\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}, \"checklist\": {\"name\": \"Produce\"}}}}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTripRecipesError},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip200#1",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("[{\"name\": \"whole milk\", \"quantity\": 1, \"unit\": \"gallon\"}, {\"barcode\": \"0002\"}]")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"checkedOff\":[],\"cookable\":[\"Pancakes\"],\"restocked\":[\"Milk\",\"Flour\"]}",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTrip,
+			},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip200#2",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("[{\"name\": \"whole milk\", \"price\": 3.49, \"quantity\": 1, \"unit\": \"gallon\", \"store\": \"Aldi\"}, {\"barcode\": \"0002\"}]")),
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "{\"checkedOff\":[],\"cookable\":[\"Pancakes\"],\"restocked\":[\"Milk\",\"Flour\"]}",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments:   OverrideFindManyDocumentsTrip,
+				OverrideInsertManyDocuments: OverrideInsertManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip400#1",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip400#2",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("[{\"name\": \"caviar\"}, {\"barcode\": \"9999\"}]")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "unmatched purchases: caviar, 9999",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTrip,
+			},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip500#1",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip500#2",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("{}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   "json: cannot unmarshal object into Go value of type []api.purchase",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip500#3",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("[{\"name\": \"whole milk\", \"quantity\": 1, \"unit\": \"gallon\"}, {\"barcode\": \"0002\"}]")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip500#4",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("[{\"name\": \"whole milk\", \"quantity\": 1, \"unit\": \"gallon\"}, {\"barcode\": \"0002\"}]")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTrip,
+				OverrideUpdateOneDocument: OverrideUpdateOneDocumentTripError,
+			},
+		},
+		{
+			/*
+			 */
+			"postShoppingTrip500#5",
+			postShoppingTrip,
+			testRequest{
+				method:   "POST",
+				endpoint: "/shopping-trips",
+				body:     io.NopCloser(strings.NewReader("[{\"name\": \"whole milk\", \"quantity\": 1, \"unit\": \"gallon\"}, {\"barcode\": \"0002\"}]")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTripRecipesError,
+			},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport200#1",
+			getNutritionReport,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/reports/nutrition",
+				queryParameters: queryParams1020,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyNutritionReport,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeNutrition,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsNutrition,
+			},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport200#2",
+			getNutritionReport,
+			testRequest{
+				method:   "GET",
+				endpoint: "/reports/nutrition",
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyNutritionReportMissing,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentNone,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsNutrition,
+			},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport200#3",
+			getNutritionReport,
+			testRequest{
+				method:   "GET",
+				endpoint: "/reports/nutrition",
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyNutritionReportMissing,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentNone,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsNutritionTwice,
+			},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport400#1",
+			getNutritionReport,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/reports/nutrition",
+				queryParameters: map[string]string{"from": "x"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvX,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport400#2",
+			getNutritionReport,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/reports/nutrition",
+				queryParameters: map[string]string{"to": "y"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvY,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport500#1",
+			getNutritionReport,
+			testRequest{
+				method:   "GET",
+				endpoint: "/reports/nutrition",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport500#2",
+			getNutritionReport,
+			testRequest{
+				method:   "GET",
+				endpoint: "/reports/nutrition",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentErrorBasic,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsNutrition,
+			},
+		},
+		{
+			/*
+			 */
+			"getNutritionReport500#3",
+			getNutritionReport,
+			testRequest{
+				method:   "GET",
+				endpoint: "/reports/nutrition",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindOneDocument:   OverrideFindOneDocumentRecipeNutrition,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsMealPlansOrErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getPrices200#1",
+			getPrices,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/prices",
+				queryParameters: map[string]string{"name": "hello"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyPrices,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsPrices,
+			},
+		},
+		{
+			/*
+			 */
+			"getPrices500#1",
+			getPrices,
+			testRequest{
+				method:   "GET",
+				endpoint: "/prices",
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postPrices201#1",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("{\"store\": \"Aldi\", \"date\": 1636243200000, \"items\": [{\"name\": \"whole milk\", \"price\": 3.49, \"quantity\": 1, \"unit\": \"gallon\"}, {\"barcode\": \"0002\", \"price\": 2.5, \"store\": \"Costco\"}]}")),
+			},
+			testResponse{
+				status: http.StatusCreated,
+				body:   bodyReceipt,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTrip,
+			},
+		},
+		{
+			/*
+			 */
+			"postPrices400#1",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("{:}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorJsonUndecodable,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPrices400#2",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("{\"items\": []}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorPricesNoItems,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPrices400#3",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("{\"items\": [{\"name\": \"whole milk\"}, {\"barcode\": \"0002\", \"price\": 2.5}]}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "purchases without a price: whole milk",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPrices400#4",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("{\"items\": [{\"name\": \"caviar\", \"price\": 99}]}")),
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   "unmatched purchases: caviar",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTrip,
+			},
+		},
+		{
+			/*
+			 */
+			"postPrices500#1",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(errReader(0)),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorIoReadAll,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPrices500#2",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("[]")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   "json: cannot unmarshal array into Go value of type api.receipt",
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"postPrices500#3",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("{\"store\": \"Aldi\", \"date\": 1636243200000, \"items\": [{\"name\": \"whole milk\", \"price\": 3.49, \"quantity\": 1, \"unit\": \"gallon\"}, {\"barcode\": \"0002\", \"price\": 2.5, \"store\": \"Costco\"}]}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"postPrices500#4",
+			postPrices,
+			testRequest{
+				method:   "POST",
+				endpoint: "/prices",
+				body:     io.NopCloser(strings.NewReader("{\"store\": \"Aldi\", \"date\": 1636243200000, \"items\": [{\"name\": \"whole milk\", \"price\": 3.49, \"quantity\": 1, \"unit\": \"gallon\"}, {\"barcode\": \"0002\", \"price\": 2.5, \"store\": \"Costco\"}]}")),
+			},
+			testResponse{
+				status: http.StatusInternalServerError,
+				body:   errorBasic,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments:   OverrideFindManyDocumentsTrip,
+				OverrideInsertManyDocuments: OverrideInsertManyDocumentsErrorBasic,
+			},
+		},
+		{
+			/*
+			 */
+			"getWasteReport200#1",
+			getWasteReport,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/reports/waste",
+				queryParameters: queryParams1020,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyWasteReport,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsPrices,
+			},
+		},
+		{
+			/*
+			 */
+			"getWasteReport400#1",
+			getWasteReport,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/reports/waste",
+				queryParameters: map[string]string{"from": "x"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorStrconvX,
+			},
+			mocks.MockMongo{},
+		},
+		{
+			/*
+			 */
+			"getWasteReport400#2",
+			getWasteReport,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/reports/waste",
+				queryParameters: map[string]string{"to": "y"},
 			},
 			testResponse{
 				status: http.StatusBadRequest,
@@ -361,11 +3597,11 @@ func TestAPI(t *testing.T) {
 		{
 			/*
 			 */
-			"getExpiring500#1",
-			getExpiring,
+			"getWasteReport500#1",
+			getWasteReport,
 			testRequest{
 				method:   "GET",
-				endpoint: "/expiring",
+				endpoint: "/reports/waste",
 			},
 			testResponse{
 				status: http.StatusInternalServerError,
@@ -378,68 +3614,50 @@ func TestAPI(t *testing.T) {
 		{
 			/*
 			 */
-			"getExpiring500#2",
-			getExpiring,
+			"getWasteReport500#2",
+			getWasteReport,
 			testRequest{
 				method:   "GET",
-				endpoint: "/expiring",
+				endpoint: "/reports/waste",
 			},
 			testResponse{
 				status: http.StatusInternalServerError,
-				body:   errorDecodeFail,
+				body:   errorBasic,
 			},
 			mocks.MockMongo{
-				OverrideFindManyDocuments: OverrideFindManyDocumentsDecodeFail,
+				OverrideFindManyDocuments: OverrideFindManyDocumentsPricesErrorBasic,
 			},
 		},
 		{
 			/*
 			 */
-			"getOneDocument200#1a",
+			"getOneDocument400#1",
 			getOneDocument,
 			testRequest{
 				method:         "GET",
 				endpoint:       "/documents",
-				routeVariables: routeVarsIngredientsDoc,
+				routeVariables: routeVarsIngredientsDocInvalid,
 			},
 			testResponse{
-				status: http.StatusOK,
-				body:   "null",
+				status: http.StatusBadRequest,
+				body:   errorDocumentIdInvalid,
 			},
 			mocks.MockMongo{},
 		},
 		{
 			/*
 			 */
-			"getOneDocument200#1b",
-			getOneDocument,
-			testRequest{
-				method:         "GET",
-				endpoint:       "/documents",
-				routeVariables: routeVarsRecipesDoc,
-			},
-			testResponse{
-				status: http.StatusOK,
-				body:   bodyCookable,
-			},
-			mocks.MockMongo{
-				OverrideFindOneDocument:   OverrideFindOneDocumentRecipe,
-				OverrideFindManyDocuments: OverrideFindManyDocumentsIngredient,
-			},
-		},
-		{
-			/*
-			 */
-			"getOneDocument400#1",
+			"getOneDocument400#2",
 			getOneDocument,
 			testRequest{
-				method:         "GET",
-				endpoint:       "/documents",
-				routeVariables: routeVarsIngredientsDocInvalid,
+				method:          "GET",
+				endpoint:        "/documents",
+				routeVariables:  routeVarsRecipesDoc,
+				queryParameters: map[string]string{"servings": "0"},
 			},
 			testResponse{
 				status: http.StatusBadRequest,
-				body:   errorDocumentIdInvalid,
+				body:   errorServingsZero,
 			},
 			mocks.MockMongo{},
 		},
@@ -644,6 +3862,99 @@ func TestAPI(t *testing.T) {
 			},
 			mocks.MockMongo{},
 		},
+		{
+			/*
+			 */
+			"getManyDocuments200#3b",
+			getManyDocuments,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/documents",
+				routeVariables:  routeVarsRecipes,
+				queryParameters: map[string]string{"isCookable": "true", "servings": "2"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyCookablesServings2,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsServings,
+			},
+		},
+		{
+			/*
+			 */
+			"getManyDocuments200#4b",
+			getManyDocuments,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/documents",
+				routeVariables:  routeVarsRecipes,
+				queryParameters: map[string]string{"isCookable": "true", "servings": "4"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyEmpty,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsServings,
+			},
+		},
+		{
+			/*
+			 */
+			"getManyDocuments200#5b",
+			getManyDocuments,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/documents",
+				routeVariables:  routeVarsRecipes,
+				queryParameters: map[string]string{"diet": "vegetarian", "excludeAllergens": "gluten"},
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   "[" + bodyTaggedSalad + "]",
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsTagged,
+			},
+		},
+		{
+			/*
+			 */
+			"getManyDocuments200#6b",
+			getManyDocuments,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsRecipes,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyCostRecipes,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsPricesBatched,
+			},
+		},
+		{
+			/*
+			 */
+			"getManyDocuments200#7b",
+			getManyDocuments,
+			testRequest{
+				method:         "GET",
+				endpoint:       "/documents",
+				routeVariables: routeVarsRecipes,
+			},
+			testResponse{
+				status: http.StatusOK,
+				body:   bodyUnpricedRecipes,
+			},
+			mocks.MockMongo{
+				OverrideFindManyDocuments: OverrideFindManyDocumentsPricesBatchedErrorBasic,
+			},
+		},
 		{
 			/*
 			 */
@@ -730,6 +4041,23 @@ func TestAPI(t *testing.T) {
 			},
 			mocks.MockMongo{},
 		},
+		{
+			/*
+			 */
+			"getManyDocuments400#5",
+			getManyDocuments,
+			testRequest{
+				method:          "GET",
+				endpoint:        "/documents",
+				routeVariables:  routeVarsRecipes,
+				queryParameters: map[string]string{"servings": "0"},
+			},
+			testResponse{
+				status: http.StatusBadRequest,
+				body:   errorServingsZero,
+			},
+			mocks.MockMongo{},
+		},
 		{
 			/*
 			 */
@@ -1470,6 +4798,10 @@ func TestAPI(t *testing.T) {
 				req.URL.RawQuery = q.Encode()
 			}
 
+			for k, v := range st.request.headers {
+				req.Header.Set(k, v)
+			}
+
 			if st.request.routeVariables != nil {
 				req = mux.SetURLVars(req, st.request.routeVariables)
 			}
@@ -1491,3 +4823,489 @@ func TestAPI(t *testing.T) {
 	// Reverse logrus output change
 	log.SetOutput(os.Stdout)
 }
+
+func TestSessions(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	if configuration == nil {
+		configuration = &config.Configuration{}
+	}
+	originalMongo := configuration.Mongo
+	defer func() { configuration.Mongo = originalMongo }()
+	configuration.Mongo = &mocks.MockMongo{
+		OverrideFindOneDocument:   OverrideFindOneDocumentRecipeSteps,
+		OverrideFindManyDocuments: OverrideFindManyDocumentsHistory,
+	}
+
+	router := mux.NewRouter()
+	router.HandleFunc("/recipes/{id}/sessions", postSession).Methods("POST")
+	router.HandleFunc("/sessions/{id}", getSession).Methods("GET")
+	router.HandleFunc("/sessions/{id}", deleteSession).Methods("DELETE")
+	router.HandleFunc("/sessions/{id}/advance", postSessionAdvance).Methods("POST")
+	router.HandleFunc("/sessions/{id}/events", getSessionEvents).Methods("GET")
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// Read the next server-sent event from a stream
+	nextEvent := func(reader *bufio.Reader) (string, map[string]interface{}) {
+		var name string
+		data := map[string]interface{}{}
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("failed to read event: %v", err)
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				return name, data
+			} else if strings.HasPrefix(line, "event: ") {
+				name = strings.TrimPrefix(line, "event: ")
+			} else if strings.HasPrefix(line, "data: ") {
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &data)
+			}
+		}
+	}
+
+	t.Run("cook", func(t *testing.T) {
+		res, err := http.Post(server.URL+"/recipes/"+documentId+"/sessions?servings=4", "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var session map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&session)
+		res.Body.Close()
+		id, _ := session["id"].(string)
+		if res.StatusCode != http.StatusCreated || session["servings"] != 4.0 || len(session["timers"].([]interface{})) != 1 {
+			t.Fatalf("postSession, got (%d, %v)", res.StatusCode, session)
+		}
+
+		stream, err := http.Get(server.URL + "/sessions/" + id + "/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stream.Body.Close()
+		reader := bufio.NewReader(stream.Body)
+		if name, _ := nextEvent(reader); name != "session" {
+			t.Errorf("getSessionEvents, got (%s), want (session)", name)
+		}
+
+		// Timers announce themselves when they elapse
+		sessionsLock.Lock()
+		startSessionTimer(sessions[id], "Eggs", 0, 10*time.Millisecond)
+		sessionsLock.Unlock()
+		if name, data := nextEvent(reader); name != "timer" || data["name"] != "Eggs" || data["elapsed"] != true {
+			t.Errorf("getSessionEvents, got (%s, %v), want (timer)", name, data)
+		}
+
+		res, _ = http.Post(server.URL+"/sessions/"+id+"/advance", "", nil)
+		res.Body.Close()
+		if name, data := nextEvent(reader); res.StatusCode != http.StatusOK || name != "step" || data["step"] != 1.0 {
+			t.Errorf("postSessionAdvance, got (%d, %s, %v), want (200, step, 1)", res.StatusCode, name, data)
+		}
+
+		// Advancing past the last step cooks the recipe and ends the session
+		res, _ = http.Post(server.URL+"/sessions/"+id+"/advance", "", nil)
+		res.Body.Close()
+		if name, data := nextEvent(reader); res.StatusCode != http.StatusOK || name != "done" || data["finished"] != true || data["cook"] == nil {
+			t.Errorf("postSessionAdvance, got (%d, %s, %v), want (200, done)", res.StatusCode, name, data)
+		}
+
+		res, _ = http.Get(server.URL + "/sessions/" + id)
+		res.Body.Close()
+		if res.StatusCode != http.StatusNotFound {
+			t.Errorf("getSession, got (%d), want (%d)", res.StatusCode, http.StatusNotFound)
+		}
+	})
+
+	t.Run("abandon", func(t *testing.T) {
+		res, _ := http.Post(server.URL+"/recipes/"+documentId+"/sessions", "", nil)
+		var session map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&session)
+		res.Body.Close()
+		id, _ := session["id"].(string)
+
+		res, _ = http.Get(server.URL + "/sessions/" + id)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("getSession, got (%d), want (%d)", res.StatusCode, http.StatusOK)
+		}
+
+		request, _ := http.NewRequest("DELETE", server.URL+"/sessions/"+id, nil)
+		res, _ = http.DefaultClient.Do(request)
+		res.Body.Close()
+		sessionsLock.Lock()
+		_, found := sessions[id]
+		sessionsLock.Unlock()
+		if res.StatusCode != http.StatusOK || found {
+			t.Errorf("deleteSession, got (%d, %t), want (%d, false)", res.StatusCode, found, http.StatusOK)
+		}
+	})
+
+	t.Run("expire", func(t *testing.T) {
+		originalTimeout := sessionIdleTimeout
+		defer func() { sessionIdleTimeout = originalTimeout }()
+		sessionIdleTimeout = 100 * time.Millisecond
+
+		res, _ := http.Post(server.URL+"/recipes/"+documentId+"/sessions", "", nil)
+		var session map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&session)
+		res.Body.Close()
+		id, _ := session["id"].(string)
+
+		stream, err := http.Get(server.URL + "/sessions/" + id + "/events")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer stream.Body.Close()
+		reader := bufio.NewReader(stream.Body)
+		nextEvent(reader)
+
+		// An abandoned session is ended once it has gone untouched for the idle period
+		if name, data := nextEvent(reader); name != "expired" || data["id"] != id {
+			t.Errorf("getSessionEvents, got (%s, %v), want (expired)", name, data)
+		}
+		sessionsLock.Lock()
+		_, found := sessions[id]
+		sessionsLock.Unlock()
+		if found {
+			t.Errorf("expireSession, got (%t), want (false)", found)
+		}
+	})
+
+	t.Run("finishError", func(t *testing.T) {
+		res, _ := http.Post(server.URL+"/recipes/"+documentId+"/sessions", "", nil)
+		var session map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&session)
+		res.Body.Close()
+		id, _ := session["id"].(string)
+
+		http.Post(server.URL+"/sessions/"+id+"/advance", "", nil)
+		configuration.Mongo = &mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic}
+		res, _ = http.Post(server.URL+"/sessions/"+id+"/advance", "", nil)
+		res.Body.Close()
+
+		// The session survives so finishing can be retried
+		sessionsLock.Lock()
+		_, found := sessions[id]
+		sessionsLock.Unlock()
+		if res.StatusCode != http.StatusInternalServerError || !found {
+			t.Errorf("postSessionAdvance, got (%d, %t), want (%d, true)", res.StatusCode, found, http.StatusInternalServerError)
+		}
+	})
+
+	t.Run("slowListener", func(t *testing.T) {
+		// A listener whose buffer is already full still learns the session ended
+		listener := make(chan sessionEvent, 1)
+		listener <- sessionEvent{"timer", nil}
+		session := &cookingSession{ID: "slow", listeners: map[chan sessionEvent]bool{listener: true}}
+
+		sessionsLock.Lock()
+		sessions[session.ID] = session
+		publishSessionEvent(session, "step", nil)
+		endSession(session, "done", nil)
+		sessionsLock.Unlock()
+
+		names := []string{}
+		for event := range listener {
+			names = append(names, event.Name)
+		}
+		if strings.Join(names, ",") != "timer" || session.ended == nil || session.ended.Name != "done" {
+			t.Errorf("endSession, got (%v, %v), want (%v, done)", names, session.ended, []string{"timer"})
+		}
+	})
+
+	// Reverse logrus output change
+	log.SetOutput(os.Stdout)
+}
+
+func TestTimezoneInvalid(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	if configuration == nil {
+		configuration = &config.Configuration{}
+	}
+	originalTimezone, originalMongo := configuration.Timezone, configuration.Mongo
+	defer func() { configuration.Timezone, configuration.Mongo = originalTimezone, originalMongo }()
+	configuration.Timezone = "Nowhere/Invalid"
+	configuration.Mongo = &mocks.MockMongo{}
+
+	subtests := []struct {
+		handler func(http.ResponseWriter, *http.Request)
+		method  string
+		target  string
+		body    string
+	}{
+		{getNutritionReport, "GET", "/reports/nutrition", ""},
+		{postGrocyImport, "POST", "/ingredients/import/grocy", "{}"},
+		{getGrocyExport, "GET", "/ingredients/export/grocy", ""},
+	}
+	for _, st := range subtests {
+		request, _ := http.NewRequest(st.method, st.target, strings.NewReader(st.body))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(st.handler).ServeHTTP(rr, request)
+		want := "unknown time zone Nowhere/Invalid"
+		if rr.Code != http.StatusInternalServerError || rr.Body.String() != want {
+			t.Errorf("%s %s, got (%d, %s), want (%d, %s)", st.method, st.target, rr.Code, rr.Body.String(), http.StatusInternalServerError, want)
+		}
+	}
+}
+
+func TestIngredientMove(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	if configuration == nil {
+		configuration = &config.Configuration{}
+	}
+	originalMongo := configuration.Mongo
+	defer func() { configuration.Mongo = originalMongo }()
+
+	// Chicken sits on the top shelf of a freezer
+	var update bson.M
+	configuration.Mongo = &mocks.MockMongo{
+		OverrideFindOneDocument: OverrideFindOneDocumentIngredientShelved,
+		OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, instructions interface{}) (int64, int64, error) {
+			update = instructions.(bson.M)
+			return 1, 1, nil
+		},
+	}
+
+	// Thawing it in the fridge leaves the freezer's location and shelf behind
+	request, _ := http.NewRequest("POST", "/ingredients/move", strings.NewReader("{\"storeIn\": \"refrigerator\"}"))
+	request = mux.SetURLVars(request, routeVarsRecipe)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(postIngredientMove).ServeHTTP(rr, request)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("got %d (%s), want %d", rr.Code, rr.Body.String(), http.StatusOK)
+	}
+
+	want := bson.M{"location": "", "shelf": ""}
+	if unset, ok := update["$unset"].(bson.M); !ok || !reflect.DeepEqual(unset, want) {
+		t.Errorf("got $unset %v, want %v", update["$unset"], want)
+	}
+	var ingredient map[string]interface{}
+	json.Unmarshal(rr.Body.Bytes(), &ingredient)
+	if _, ok := ingredient["location"]; ok {
+		t.Errorf("got location %v, want none", ingredient["location"])
+	}
+	if _, ok := ingredient["shelf"]; ok {
+		t.Errorf("got shelf %v, want none", ingredient["shelf"])
+	}
+	if ingredient["storeIn"] != "refrigerator" {
+		t.Errorf("got storeIn %v, want refrigerator", ingredient["storeIn"])
+	}
+}
+
+func TestTrelloWebhookDisabled(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	if configuration == nil {
+		configuration = &config.Configuration{}
+	}
+	originalSecret := configuration.TrelloSecret
+	defer func() { configuration.TrelloSecret = originalSecret }()
+	configuration.TrelloSecret = ""
+
+	body := "{\"action\": {\"type\": \"updateCheckItemStateOnCard\", \"data\": {\"checkItem\": {\"name\": \"Milk\", \"state\": \"complete\"}, \"card\": {\"name\": \"Shopping List\"}}}}"
+	request, _ := http.NewRequest("POST", "/webhooks/trello", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(postTrelloWebhook).ServeHTTP(rr, request)
+	if rr.Code != http.StatusForbidden || rr.Body.String() != errorWebhookDisabled {
+		t.Errorf("got (%d, %s), want (%d, %s)", rr.Code, rr.Body.String(), http.StatusForbidden, errorWebhookDisabled)
+	}
+}
+
+func TestCookRecordFailure(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	if configuration == nil {
+		configuration = &config.Configuration{}
+	}
+	originalMongo := configuration.Mongo
+	defer func() { configuration.Mongo = originalMongo }()
+
+	// The meal can't be logged, so nothing may be used up
+	consumed := 0
+	configuration.Mongo = &mocks.MockMongo{
+		OverrideFindOneDocument:     OverrideFindOneDocumentRecipeServings,
+		OverrideFindManyDocuments:   OverrideFindManyDocumentsHistory,
+		OverrideInsertManyDocuments: OverrideInsertManyDocumentsErrorBasic,
+		OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+			if collection == config.MongoCollectionIngredients {
+				consumed++
+			}
+			return 1, 1, nil
+		},
+	}
+
+	request, _ := http.NewRequest("POST", "/recipes/cook", strings.NewReader(""))
+	request = mux.SetURLVars(request, routeVarsRecipe)
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(postCook).ServeHTTP(rr, request)
+	if rr.Code != http.StatusInternalServerError || consumed != 0 {
+		t.Errorf("got (%d, %d consumed), want (%d, %d consumed)", rr.Code, consumed, http.StatusInternalServerError, 0)
+	}
+}
+
+func TestShoppingList(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	if configuration == nil {
+		configuration = &config.Configuration{}
+	}
+	originalShoppingList := configuration.ShoppingList
+	originalMongo := configuration.Mongo
+	defer func() {
+		configuration.ShoppingList = originalShoppingList
+		configuration.Mongo = originalMongo
+	}()
+
+	// Milk is the only item bought before
+	configuration.Mongo = &mocks.MockMongo{
+		OverrideFindManyDocuments: OverrideFindManyDocumentsPricesMilk,
+	}
+
+	// A provider keeping a single list in memory
+	var list *config.ShoppingList
+	var items []config.ShoppingItem
+	provider := &mocks.MockTrello{
+		OverrideGetShoppingList: func() (*config.ShoppingList, error) {
+			return list, nil
+		},
+		OverrideCreateShoppingList: func(dueDate *time.Time, listItems []config.ShoppingItem) (string, error) {
+			list = &config.ShoppingList{ID: "1", Name: "Shopping List", URL: "www.mock.url.com", Due: dueDate}
+			items = append([]config.ShoppingItem{}, listItems...)
+			return list.URL, nil
+		},
+		OverrideAddToShoppingList: func(listItems []config.ShoppingItem) (string, error) {
+			kept := []config.ShoppingItem{}
+			for _, item := range items {
+				if item.Checked {
+					kept = append(kept, item)
+				}
+			}
+			items = append(kept, listItems...)
+			return list.URL, nil
+		},
+		OverrideCheckOffShoppingList: func(names []string) ([]string, error) {
+			checked := []string{}
+			for i, item := range items {
+				if utils.Contains(names, utils.ShoppingItemName(item.Name)) {
+					items[i].Checked = true
+					checked = append(checked, item.Name)
+				}
+			}
+			return checked, nil
+		},
+		OverrideRemoveFromShoppingList: func(itemText []string) error {
+			kept := []config.ShoppingItem{}
+			for _, item := range items {
+				if !utils.Contains(itemText, item.Name) {
+					kept = append(kept, item)
+				}
+			}
+			items = kept
+			return nil
+		},
+		OverrideGetShoppingListItems: func(*config.ShoppingList) ([]config.ShoppingItem, error) {
+			return items, nil
+		},
+	}
+	configuration.ShoppingList = provider
+
+	serve := func(handler func(http.ResponseWriter, *http.Request), method, body string) (int, string) {
+		request, _ := http.NewRequest(method, "/shopping-list", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+		http.HandlerFunc(handler).ServeHTTP(rr, request)
+		return rr.Code, rr.Body.String()
+	}
+	withoutDue := func(body string) string {
+		var document map[string]interface{}
+		json.Unmarshal([]byte(body), &document)
+		delete(document, "due")
+		marshalled, _ := json.Marshal(document)
+		return string(marshalled)
+	}
+
+	t.Run("getShoppingList404", func(t *testing.T) {
+		status, body := serve(getShoppingList, "GET", "")
+		if status != http.StatusNotFound || body != errorShoppingListNotFound {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusNotFound, errorShoppingListNotFound)
+		}
+	})
+
+	t.Run("patchShoppingList404", func(t *testing.T) {
+		status, body := serve(patchShoppingList, "PATCH", "{\"check\": [\"Milk\"]}")
+		if status != http.StatusNotFound || body != errorShoppingListNotFound {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusNotFound, errorShoppingListNotFound)
+		}
+	})
+
+	t.Run("postShoppingList400", func(t *testing.T) {
+		status, body := serve(postShoppingList, "POST", "{:}")
+		if status != http.StatusBadRequest || body != errorJsonUndecodable {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusBadRequest, errorJsonUndecodable)
+		}
+
+		status, body = serve(postShoppingList, "POST", "{\"items\": []}")
+		if status != http.StatusBadRequest || body != errorShoppingListNoItems {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusBadRequest, errorShoppingListNoItems)
+		}
+	})
+
+	t.Run("postShoppingList201", func(t *testing.T) {
+		status, body := serve(postShoppingList, "POST", "{\"items\": [\"Milk\", \"Eggs\"]}")
+		want := "{\"estimatedTotal\":3.49,\"id\":\"1\",\"items\":[{\"checked\":false,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"},{\"checked\":false,\"name\":\"Eggs\",\"section\":\"Groceries\"}],\"name\":\"Shopping List\",\"sections\":[{\"items\":[{\"checked\":false,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"},{\"checked\":false,\"name\":\"Eggs\",\"section\":\"Groceries\"}],\"name\":\"Groceries\"}],\"unpriced\":[\"Eggs\"],\"url\":\"www.mock.url.com\"}"
+		if status != http.StatusCreated || withoutDue(body) != want {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusCreated, want)
+		}
+		if !strings.Contains(body, "\"due\":") {
+			t.Errorf("got (%s), want a due date", body)
+		}
+	})
+
+	t.Run("postShoppingList200", func(t *testing.T) {
+		status, body := serve(postShoppingList, "POST", "{\"items\": [\"Bread\"], \"section\": \"Bakery\"}")
+		want := "{\"estimatedTotal\":3.49,\"id\":\"1\",\"items\":[{\"checked\":false,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"},{\"checked\":false,\"name\":\"Eggs\",\"section\":\"Groceries\"},{\"checked\":false,\"name\":\"Bread\",\"section\":\"Bakery\"}],\"name\":\"Shopping List\",\"sections\":[{\"items\":[{\"checked\":false,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"},{\"checked\":false,\"name\":\"Eggs\",\"section\":\"Groceries\"}],\"name\":\"Groceries\"},{\"items\":[{\"checked\":false,\"name\":\"Bread\",\"section\":\"Bakery\"}],\"name\":\"Bakery\"}],\"unpriced\":[\"Eggs\",\"Bread\"],\"url\":\"www.mock.url.com\"}"
+		if status != http.StatusOK || withoutDue(body) != want {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusOK, want)
+		}
+	})
+
+	t.Run("patchShoppingList200", func(t *testing.T) {
+		status, body := serve(patchShoppingList, "PATCH", "{\"check\": [\"Milk\"], \"remove\": [\"eggs\"]}")
+		want := "{\"estimatedTotal\":0,\"id\":\"1\",\"items\":[{\"checked\":true,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"},{\"checked\":false,\"name\":\"Bread\",\"section\":\"Bakery\"}],\"name\":\"Shopping List\",\"sections\":[{\"items\":[{\"checked\":true,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"}],\"name\":\"Groceries\"},{\"items\":[{\"checked\":false,\"name\":\"Bread\",\"section\":\"Bakery\"}],\"name\":\"Bakery\"}],\"unpriced\":[\"Bread\"],\"url\":\"www.mock.url.com\"}"
+		if status != http.StatusOK || withoutDue(body) != want {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusOK, want)
+		}
+	})
+
+	t.Run("getShoppingList200", func(t *testing.T) {
+		status, body := serve(getShoppingList, "GET", "")
+		want := "{\"estimatedTotal\":0,\"id\":\"1\",\"items\":[{\"checked\":true,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"},{\"checked\":false,\"name\":\"Bread\",\"section\":\"Bakery\"}],\"name\":\"Shopping List\",\"sections\":[{\"items\":[{\"checked\":true,\"name\":\"Milk\",\"price\":3.49,\"section\":\"Groceries\"}],\"name\":\"Groceries\"},{\"items\":[{\"checked\":false,\"name\":\"Bread\",\"section\":\"Bakery\"}],\"name\":\"Bakery\"}],\"unpriced\":[\"Bread\"],\"url\":\"www.mock.url.com\"}"
+		if status != http.StatusOK || withoutDue(body) != want {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusOK, want)
+		}
+	})
+
+	t.Run("getShoppingList500", func(t *testing.T) {
+		configuration.Mongo = &mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic}
+		status, body := serve(getShoppingList, "GET", "")
+		if status != http.StatusInternalServerError || body != errorBasic {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusInternalServerError, errorBasic)
+		}
+
+		provider.OverrideGetShoppingListItems = func(*config.ShoppingList) ([]config.ShoppingItem, error) {
+			return nil, errors.New(errorBasic)
+		}
+		status, body = serve(getShoppingList, "GET", "")
+		if status != http.StatusInternalServerError || body != errorBasic {
+			t.Errorf("got (%d, %s), want (%d, %s)", status, body, http.StatusInternalServerError, errorBasic)
+		}
+	})
+}
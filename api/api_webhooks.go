@@ -0,0 +1,150 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const errorWebhookDisabled = "webhook disabled"
+const errorWebhookSignature = "invalid webhook signature"
+
+// The parts of a Trello webhook payload needed to spot a checked off grocery
+type trelloWebhook struct {
+	Action struct {
+		Type string `json:"type"`
+		Data struct {
+			Card struct {
+				Name string `json:"name"`
+			} `json:"card"`
+			CheckItem struct {
+				Name  string `json:"name"`
+				State string `json:"state"`
+			} `json:"checkItem"`
+		} `json:"data"`
+	} `json:"action"`
+}
+
+// Trello signs the body followed by the callback URL with the API secret
+func validTrelloSignature(body []byte, signature string) bool {
+	mac := hmac.New(sha1.New, []byte(configuration.TrelloSecret))
+	mac.Write(body)
+	mac.Write([]byte(configuration.TrelloCallback))
+	expected := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Trello checks that the callback URL exists before creating a webhook
+func headTrelloWebhook(response http.ResponseWriter, request *http.Request) {
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.headTrelloWebhook",
+		"method": "HEAD",
+	})
+	log.Trace("Begin function")
+	defer log.Trace("End function")
+
+	log.WithFields(logrus.Fields{"status": http.StatusOK}).Info("Succeeded")
+	response.WriteHeader(http.StatusOK)
+}
+
+// Restock an ingredient as soon as its item is checked off any section's checklist on the shopping list card
+func postTrelloWebhook(response http.ResponseWriter, request *http.Request) {
+	// Setup
+	ctx := request.Context()
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.postTrelloWebhook",
+		"method": "POST",
+	})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	log.WithFields(logrus.Fields{"value": request}).Debug("Request data")
+	defer log.Trace("End function")
+
+	// Read in request body
+	bytes, err := io.ReadAll(request.Body)
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to read request body")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"size": len(bytes), "state": "marshalled", "value": string(bytes)}).Debug("Request body")
+	}
+
+	// Without a secret there's no telling whether the request came from Trello
+	if configuration.TrelloSecret == "" {
+		err := errors.New(errorWebhookDisabled)
+		log.WithFields(logrus.Fields{"status": http.StatusForbidden}).WithError(err).Warn("Failed to verify webhook")
+		response.WriteHeader(http.StatusForbidden)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Verify the request came from Trello
+	if !validTrelloSignature(bytes, request.Header.Get("X-Trello-Webhook")) {
+		err := errors.New(errorWebhookSignature)
+		log.WithFields(logrus.Fields{"status": http.StatusUnauthorized}).WithError(err).Warn("Failed to verify webhook")
+		response.WriteHeader(http.StatusUnauthorized)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Parse webhook payload
+	var webhook trelloWebhook
+	err = json.Unmarshal(bytes, &webhook)
+	if err != nil && strings.HasPrefix(err.Error(), "invalid character") {
+		// Invalid request body
+		log.WithFields(logrus.Fields{"status": http.StatusBadRequest}).WithError(err).Warn("Failed to decode webhook")
+		response.WriteHeader(http.StatusBadRequest)
+		response.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		// Something else failed
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to decode webhook")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	} else {
+		log.WithFields(logrus.Fields{"state": "unmarshalled", "value": webhook}).Debug("Request body")
+	}
+
+	// Every other board event is acknowledged and ignored
+	action := webhook.Action
+	if action.Type != "updateCheckItemStateOnCard" || action.Data.CheckItem.State != "complete" || action.Data.Card.Name != "Shopping List" {
+		log.WithFields(logrus.Fields{"status": http.StatusOK, "type": action.Type}).Info("Ignored")
+		response.WriteHeader(http.StatusOK)
+		return
+	}
+
+	restocked, unmatched, err := restockShoppingItems(ctx, []string{action.Data.CheckItem.Name})
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to restock shopping items")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+		return
+	}
+
+	// Prepare to respond with what was restocked
+	marshalled, err := json.Marshal(bson.M{
+		"restocked": restocked,
+		"unmatched": unmatched,
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to encode summary")
+		response.WriteHeader(http.StatusInternalServerError)
+		response.Write([]byte(err.Error()))
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(restocked), "size": len(marshalled), "status": http.StatusOK}).Info("Succeeded")
+		response.WriteHeader(http.StatusOK)
+		response.Write(marshalled)
+	}
+}
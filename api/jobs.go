@@ -4,12 +4,15 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func checkExpirations() {
@@ -74,6 +77,27 @@ func checkExpirations() {
 		// Skip if nothing is expiring
 		if quantityExpiring == 0 && quantityExpired == 0 {
 			log.WithFields(logrus.Fields{"expiring": quantityExpiring, "expired": quantityExpired}).Info("Restocking not required")
+
+			// Items restocked since the last run no longer belong on the list
+			shoppingListCard, carried, err := currentShoppingList(ctx, log)
+			if err != nil {
+				log.WithError(err).Error("Failed to get shopping list")
+			} else if shoppingListCard != nil {
+				url, err := configuration.ShoppingList.AddToShoppingList([]config.ShoppingItem{})
+				if err != nil {
+					log.WithError(err).Error("Failed to update shopping list")
+				} else {
+					log.WithFields(logrus.Fields{"url": url}).Info("Updated shopping list")
+				}
+			} else if items := carryOver([]config.ShoppingItem{}, carried); len(items) > 0 {
+				dueDate := shoppingListDueDate(time.Now())
+				url, err := configuration.ShoppingList.CreateShoppingList(&dueDate, items)
+				if err != nil {
+					log.WithError(err).Error("Failed to create shopping list")
+				} else {
+					log.WithFields(logrus.Fields{"url": url}).Info("Created shopping list")
+				}
+			}
 			return
 		} else {
 			log.WithFields(logrus.Fields{"expiring": quantityExpiring, "expired": quantityExpired}).Info("Restocking required")
@@ -82,69 +106,44 @@ func checkExpirations() {
 		log.WithFields(logrus.Fields{"quantity": quantityExpired, "value": documentsExpired}).Debug("Expired items")
 		log.WithFields(logrus.Fields{"quantity": quantityExpiring, "value": documentsExpiring}).Debug("Expiring items")
 
-		// Construct list of names of items to shop for
-		var groceries []string
+		// Construct list of items to shop for
+		var groceries []config.ShoppingItem
 		for _, document := range documentsExpired {
-			name := document["name"]
-			stage := "expired"
-			text := fmt.Sprintf("%s (%s)", name, stage)
-
-			if _, ok := document["attributes"]; ok {
-				brand := document["attributes"].(map[string]string)["brand"]
-				flavor := document["attributes"].(map[string]string)["flavor"]
-				text = fmt.Sprintf("%s (%s, %s, %s)", name, brand, flavor, stage)
-			}
-
-			groceries = append(groceries, text)
+			groceries = append(groceries, groceryItem(document, "expired"))
 		}
 		for _, document := range documentsExpiring {
-			name := document["name"]
-			stage := "expiring"
-			text := fmt.Sprintf("%s (%s)", name, stage)
-
-			if _, ok := document["attributes"]; ok {
-				brand := document["attributes"].(map[string]string)["brand"]
-				flavor := document["attributes"].(map[string]string)["flavor"]
-				text = fmt.Sprintf("%s (%s, %s, %s)", name, brand, flavor, stage)
-			}
-
-			groceries = append(groceries, text)
+			groceries = append(groceries, groceryItem(document, "expiring"))
 		}
 		log.WithFields(logrus.Fields{"quantity": len(groceries), "value": groceries}).Debug("Groceries")
 
 		// Construct shopping list due date
-		now := time.Now()
-		rounded := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-		dueDate := rounded.Add(configuration.Lookahead + (time.Hour * 24))
+		dueDate := shoppingListDueDate(time.Now())
 		log.WithFields(logrus.Fields{"value": dueDate}).Debug("Card due date")
 
 		var url string
-		shoppingListCard, err := configuration.Trello.GetShoppingList()
+		shoppingListCard, carried, err := currentShoppingList(ctx, log)
 		if err != nil {
-			log.WithError(err).Error("Failed to get Trello card")
+			log.WithError(err).Error("Failed to get shopping list")
 		} else if shoppingListCard != nil {
 			// Add to shopping list card on Trello
-			url, err = configuration.Trello.AddToShoppingList(groceries)
+			url, err = configuration.ShoppingList.AddToShoppingList(groceries)
 			if err != nil {
-				log.WithError(err).Error("Failed to add to Trello card")
+				log.WithError(err).Error("Failed to add to shopping list")
 			} else {
-				log.WithFields(logrus.Fields{"url": url}).Info("Added to Trello card")
+				log.WithFields(logrus.Fields{"url": url}).Info("Added to shopping list")
 			}
 		} else {
 			// Create shopping list card on Trello
-			innerTrello := reflect.ValueOf(configuration.Trello).Elem()
-			labelsStr := *innerTrello.FieldByName("LabelsStr").Addr().Interface().(*string)
-			labels := strings.Split(labelsStr, ",")
-			url, err = configuration.Trello.CreateShoppingList(&dueDate, labels, groceries)
+			url, err = configuration.ShoppingList.CreateShoppingList(&dueDate, carryOver(groceries, carried))
 			if err != nil {
-				log.WithError(err).Error("Failed to create Trello card")
+				log.WithError(err).Error("Failed to create shopping list")
 			} else {
-				log.WithFields(logrus.Fields{"url": url}).Info("Created Trello card")
+				log.WithFields(logrus.Fields{"url": url}).Info("Created shopping list")
 			}
 		}
 
 		// Compose Twilio message
-		var message = configuration.Twilio.ComposeMessage(quantityExpiring, quantityExpired, url)
+		var message = configuration.Twilio.ComposeMessage(quantityExpiring, quantityExpired, url, groceries)
 
 		// Send the Twilio message
 		if !configuration.Silence {
@@ -162,3 +161,351 @@ func checkExpirations() {
 		}
 	}
 }
+
+// Frozen ingredients for tomorrow's meals need a night in the fridge
+func checkThawing() {
+	// Setup
+	ctx := context.Background()
+	log := logrus.WithFields(logrus.Fields{"at": "api.checkThawing"})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	defer log.Trace("End function")
+
+	loc, err := time.LoadLocation(configuration.Timezone)
+	if err != nil {
+		log.WithFields(logrus.Fields{"timezone": configuration.Timezone}).WithError(err).Error("Failed to obtain timezone")
+		return
+	}
+
+	// Filter by meals planned for tomorrow
+	now := time.Now().In(loc)
+	tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, loc)
+	filterPlans := bson.M{"date": bson.M{
+		"$gte": int64(tomorrow.UTC().UnixNano()) / int64(time.Millisecond),
+		"$lt":  int64(tomorrow.AddDate(0, 0, 1).UTC().UnixNano()) / int64(time.Millisecond),
+	}}
+	log.WithFields(logrus.Fields{"type": "plans", "value": filterPlans}).Debug("Filter data")
+
+	plans, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionMealPlans, filterPlans, nil)
+	if err != nil {
+		log.WithError(err).Error("Failed to identify planned meals")
+		return
+	}
+
+	recipeNames := []string{}
+	for _, plan := range plans {
+		for _, entry := range mealPlanEntries(plan) {
+			if planned, ok := parseRecipeIngredient(entry); ok {
+				recipeNames = append(recipeNames, planned.Name)
+			}
+		}
+	}
+	if len(recipeNames) == 0 {
+		log.Info("Thawing not required")
+		return
+	}
+
+	// Grab the planned recipes and what they're made of
+	recipes, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionRecipes, bson.M{"name": bson.M{"$in": recipeNames}}, nil)
+	if err != nil {
+		log.WithError(err).Error("Failed to identify planned recipes")
+		return
+	}
+
+	usedBy := map[string][]string{}
+	ingredientNames := []string{}
+	for _, recipe := range recipes {
+		recipeName, _ := recipe["name"].(string)
+		for _, entry := range recipeIngredients(&recipe) {
+			if ingredient, ok := parseRecipeIngredient(entry); ok {
+				if _, seen := usedBy[ingredient.Name]; !seen {
+					ingredientNames = append(ingredientNames, ingredient.Name)
+				}
+				usedBy[ingredient.Name] = append(usedBy[ingredient.Name], recipeName)
+			}
+		}
+	}
+
+	filterIngredients := bson.M{"$and": []bson.M{
+		{
+			"haveStocked": bson.M{
+				"$eq": true,
+			},
+		},
+		{
+			"name": bson.M{
+				"$in": ingredientNames,
+			},
+		},
+	}}
+	log.WithFields(logrus.Fields{"type": "ingredients", "value": filterIngredients}).Debug("Filter data")
+
+	ingredients, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filterIngredients, nil)
+	if err != nil {
+		log.WithError(err).Error("Failed to identify stocked ingredients")
+		return
+	}
+
+	frozen := frozenIngredients(ingredients, usedBy)
+	if len(frozen) == 0 {
+		log.Info("Thawing not required")
+		return
+	} else {
+		log.WithFields(logrus.Fields{"quantity": len(frozen), "value": frozen}).Info("Thawing required")
+	}
+
+	// Send the Twilio message
+	message := composeThawMessage(frozen)
+	if !configuration.Silence {
+		innerTwilio := reflect.ValueOf(configuration.Twilio).Elem()
+		from := *innerTwilio.FieldByName("From").Addr().Interface().(*string)
+		to := *innerTwilio.FieldByName("To").Addr().Interface().(*string)
+		_, err = configuration.Twilio.SendMessage(from, to, message)
+		if err != nil {
+			log.WithFields(logrus.Fields{"from": from, "to": to}).WithError(err).Error("Failed to send Twilio message")
+		} else {
+			log.WithFields(logrus.Fields{"from": from, "to": to}).Info("Sent Twilio message")
+		}
+	} else {
+		log.WithFields(logrus.Fields{"silence": configuration.Silence}).Info("Skipped Twilio message")
+	}
+}
+
+// The shopping list item for an ingredient, listed under its store and aisle when it has them
+func groceryItem(document bson.M, stage string) config.ShoppingItem {
+	name := document["name"]
+	text := fmt.Sprintf("%s (%s)", name, stage)
+
+	if _, ok := document["attributes"]; ok {
+		brand := document["attributes"].(map[string]string)["brand"]
+		flavor := document["attributes"].(map[string]string)["flavor"]
+		text = fmt.Sprintf("%s (%s, %s, %s)", name, brand, flavor, stage)
+	}
+
+	store, _ := document["store"].(string)
+	aisle, _ := document["aisle"].(string)
+	if aisle == "" {
+		aisle, _ = document["section"].(string)
+	}
+	return config.ShoppingItem{Name: text, Section: utils.ShoppingSection(store, aisle)}
+}
+
+// Shopping lists are due the day after the lookahead window closes
+func shoppingListDueDate(now time.Time) time.Time {
+	rounded := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return rounded.Add(configuration.Lookahead + (time.Hour * 24))
+}
+
+// The shopping list card to keep using, or nil (with the items to carry forward) once it's been rolled over
+func currentShoppingList(ctx context.Context, log *logrus.Entry) (*config.ShoppingList, []config.ShoppingItem, error) {
+	card, err := configuration.ShoppingList.GetShoppingList()
+	if err != nil || card == nil {
+		return card, nil, err
+	}
+
+	rolled, carried, err := rolloverShoppingList(ctx, card, time.Now())
+	if err != nil {
+		// Keep using the current card, the next run tries again
+		log.WithError(err).Error("Failed to roll over shopping list")
+		return card, nil, nil
+	} else if rolled {
+		log.WithFields(logrus.Fields{"carried": carried, "url": card.URL}).Info("Rolled over shopping list")
+		return nil, carried, nil
+	}
+	return card, nil, nil
+}
+
+// Items the sync job or webhook restocked and took off a card before it was rolled over
+func restockedShoppingItems(ctx context.Context, card *config.ShoppingList) ([]string, error) {
+	filter := bson.M{"listId": card.ID}
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionShoppingRestocked, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	restocked := []string{}
+	for _, document := range documents {
+		restocked = utils.AppendUnique(restocked, stringValues(document["restocked"])...)
+	}
+	return restocked, nil
+}
+
+// A card is done with once every item is checked off or it's past due. Checked off items are restocked
+// first, then the card is recorded in the shopping history and archived. Returns whether the card was
+// rolled over and the unchecked items.
+func rolloverShoppingList(ctx context.Context, card *config.ShoppingList, now time.Time) (bool, []config.ShoppingItem, error) {
+	items, err := configuration.ShoppingList.GetShoppingListItems(card)
+	if err != nil {
+		return false, nil, err
+	}
+
+	checked := []string{}
+	unchecked := []config.ShoppingItem{}
+	entries := []bson.M{}
+	present := map[string]bool{}
+	for _, item := range items {
+		if item.Checked {
+			checked = append(checked, item.Name)
+		} else {
+			unchecked = append(unchecked, item)
+		}
+		entry := bson.M{"checked": item.Checked, "name": item.Name}
+		if item.Section != "" {
+			entry["section"] = item.Section
+		}
+		entries = append(entries, entry)
+		present[strings.ToLower(item.Name)] = true
+	}
+
+	// What's been restocked already was bought off this card too
+	restocked, err := restockedShoppingItems(ctx, card)
+	if err != nil {
+		return false, nil, err
+	}
+	for _, name := range restocked {
+		if !present[strings.ToLower(name)] {
+			present[strings.ToLower(name)] = true
+			entries = append(entries, bson.M{"checked": true, "name": name})
+		}
+	}
+
+	var reason string
+	if len(entries) > 0 && len(unchecked) == 0 {
+		reason = "completed"
+	} else if card.Due != nil && card.Due.Before(now) {
+		reason = "overdue"
+	} else {
+		return false, nil, nil
+	}
+
+	// Archiving the card hides what's been bought from the sync job
+	if len(checked) > 0 {
+		_, _, err := restockShoppingItems(ctx, checked)
+		if err != nil {
+			return false, nil, err
+		}
+	}
+
+	history := bson.M{
+		"_id":      primitive.NewObjectID(),
+		"archived": int64(now.UTC().UnixNano()) / int64(time.Millisecond),
+		"items":    entries,
+		"name":     card.Name,
+		"reason":   reason,
+		"url":      card.URL,
+	}
+	if card.Due != nil {
+		history["due"] = int64(card.Due.UTC().UnixNano()) / int64(time.Millisecond)
+	}
+	err = configuration.Mongo.InsertManyDocuments(ctx, config.MongoCollectionShoppingHistory, []interface{}{history})
+	if err != nil {
+		return false, nil, err
+	}
+
+	err = configuration.ShoppingList.ArchiveShoppingList(card)
+	if err != nil {
+		// The card is still open, so it shouldn't be in the history yet
+		deleteErr := configuration.Mongo.DeleteOneDocument(ctx, config.MongoCollectionShoppingHistory, bson.D{{"_id", history["_id"]}})
+		if deleteErr != nil {
+			logrus.WithFields(logrus.Fields{"at": "api.rolloverShoppingList", "id": history["_id"]}).WithError(deleteErr).Error("Failed to remove shopping history")
+		}
+		return false, nil, err
+	}
+
+	// The card's history is complete, so what was recorded while it was open is no longer needed
+	_, err = configuration.Mongo.DeleteManyDocuments(ctx, config.MongoCollectionShoppingRestocked, bson.M{"listId": card.ID})
+	if err != nil {
+		logrus.WithFields(logrus.Fields{"at": "api.rolloverShoppingList", "id": card.ID}).WithError(err).Error("Failed to remove restocked items")
+	}
+	return true, unchecked, nil
+}
+
+// The items for a new card: the current groceries, plus carried over items added by hand.
+// Carried items the expiration job wrote are either in the groceries again or were restocked.
+func carryOver(groceries, carried []config.ShoppingItem) []config.ShoppingItem {
+	items := append([]config.ShoppingItem{}, groceries...)
+	present := map[string]bool{}
+	for _, item := range groceries {
+		present[strings.ToLower(utils.ShoppingItemName(item.Name))] = true
+	}
+
+	for _, item := range carried {
+		key := strings.ToLower(utils.ShoppingItemName(item.Name))
+		if present[key] || utils.ShoppingItemStage(item.Name) != "" {
+			continue
+		}
+		present[key] = true
+		items = append(items, item)
+	}
+	return items
+}
+
+// Restock whatever has been checked off the shopping list since the last run
+func syncShoppingList() {
+	// Setup
+	ctx := context.Background()
+	log := logrus.WithFields(logrus.Fields{"at": "api.syncShoppingList"})
+
+	// Log diagnostic information
+	log.Trace("Begin function")
+	defer log.Trace("End function")
+
+	completed, err := configuration.ShoppingList.GetCompletedShoppingItems()
+	if err != nil {
+		log.WithError(err).Error("Failed to get completed shopping items")
+		return
+	} else if len(completed) == 0 {
+		log.Info("Shopping list sync not required")
+		return
+	}
+
+	restocked, unmatched, err := restockShoppingItems(ctx, completed)
+	if err != nil {
+		log.WithError(err).Error("Failed to restock shopping items")
+		return
+	}
+	if len(unmatched) > 0 {
+		log.WithFields(logrus.Fields{"quantity": len(unmatched), "value": unmatched}).Warn("Unmatched shopping items")
+	}
+	log.WithFields(logrus.Fields{"quantity": len(restocked), "value": restocked}).Info("Restocked ingredients")
+}
+
+// An ingredient needs thawing when everything stocked of it is in the freezer
+func frozenIngredients(ingredients []bson.M, usedBy map[string][]string) map[string][]string {
+	storage := map[string][]string{}
+	for _, ingredient := range ingredients {
+		name, _ := ingredient["name"].(string)
+		storeIn, _ := ingredient["storeIn"].(string)
+		storage[name] = append(storage[name], storeIn)
+	}
+
+	frozen := map[string][]string{}
+	for name, places := range storage {
+		thawed := false
+		for _, storeIn := range places {
+			if storeIn != "freezer" {
+				thawed = true
+				break
+			}
+		}
+		if !thawed {
+			frozen[name] = usedBy[name]
+		}
+	}
+	return frozen
+}
+
+func composeThawMessage(frozen map[string][]string) string {
+	names := []string{}
+	for name := range frozen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	items := []string{}
+	for _, name := range names {
+		items = append(items, fmt.Sprintf("%s (%s)", name, strings.Join(frozen[name], ", ")))
+	}
+	return fmt.Sprintf("Move to the fridge tonight for tomorrow's meals: %s", strings.Join(items, "; "))
+}
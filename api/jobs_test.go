@@ -1,15 +1,22 @@
 package api
 
 import (
+	"context"
+	"fmt"
 	"io"
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/require"
+	"github.com/tyler-cromwell/forage/config"
 	"github.com/tyler-cromwell/forage/tests/mocks"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 func TestJobs(t *testing.T) {
@@ -48,14 +55,41 @@ func TestJobs(t *testing.T) {
 			[]string{"Failed to identify expiring items"},
 		},
 		{
-			// Success #1, No expired/expiring items, no need to proceed.
+			// Success #1, No expired/expiring items, restocked items pruned from the existing Trello card.
 			"checkExpirationsSuccess#1",
 			mocks.MockMongo{},
 			mocks.MockTrello{},
 			mocks.MockTwilio{},
+			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel},
+			[]string{"Restocking not required", "Updated shopping list"},
+		},
+		{
+			// Success #5, No expired/expiring items and no Trello card, no need to proceed.
+			"checkExpirationsSuccess#5",
+			mocks.MockMongo{},
+			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListNil},
+			mocks.MockTwilio{},
 			[]logrus.Level{logrus.InfoLevel},
 			[]string{"Restocking not required"},
 		},
+		{
+			// Error #7, No expired/expiring items but could not obtain Trello card.
+			"checkExpirationsError#7",
+			mocks.MockMongo{},
+			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListErrorBasic},
+			mocks.MockTwilio{},
+			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel},
+			[]string{"Restocking not required", "Failed to get shopping list"},
+		},
+		{
+			// Error #8, No expired/expiring items but could not prune the Trello card.
+			"checkExpirationsError#8",
+			mocks.MockMongo{},
+			mocks.MockTrello{OverrideAddToShoppingList: OverrideAddToShoppingListErroBasic},
+			mocks.MockTwilio{},
+			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel},
+			[]string{"Restocking not required", "Failed to update shopping list"},
+		},
 		{
 			// Success #2, items expired/expiring added to existing Trello card and SMS message sent.
 			"checkExpirationsSuccess#2",
@@ -63,7 +97,7 @@ func TestJobs(t *testing.T) {
 			mocks.MockTrello{},
 			mocks.MockTwilio{},
 			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel, logrus.InfoLevel},
-			[]string{"Restocking required", "Added to Trello card", "Sent Twilio message"},
+			[]string{"Restocking required", "Added to shopping list", "Sent Twilio message"},
 		},
 		{
 			// Error #3, items expired/expiring but could not obtain Trello card, SMS message still sent.
@@ -72,7 +106,7 @@ func TestJobs(t *testing.T) {
 			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListErrorBasic},
 			mocks.MockTwilio{},
 			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel, logrus.InfoLevel},
-			[]string{"Restocking required", "Failed to get Trello card", "Sent Twilio message"},
+			[]string{"Restocking required", "Failed to get shopping list", "Sent Twilio message"},
 		},
 		{
 			// Success #3, items expired/expiring added to new Trello card and SMS message sent.
@@ -81,7 +115,7 @@ func TestJobs(t *testing.T) {
 			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListNil},
 			mocks.MockTwilio{},
 			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel, logrus.InfoLevel},
-			[]string{"Restocking required", "Created Trello card", "Sent Twilio message"},
+			[]string{"Restocking required", "Created shopping list", "Sent Twilio message"},
 		},
 		{
 			// Error #4, items expired/expiring but could not add to existing card Trello card, SMS message still sent.
@@ -90,7 +124,7 @@ func TestJobs(t *testing.T) {
 			mocks.MockTrello{OverrideAddToShoppingList: OverrideAddToShoppingListErroBasic},
 			mocks.MockTwilio{},
 			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel, logrus.InfoLevel},
-			[]string{"Restocking required", "Failed to add to Trello card", "Sent Twilio message"},
+			[]string{"Restocking required", "Failed to add to shopping list", "Sent Twilio message"},
 		},
 		{
 			// Error #5, items expired/expiring but could not create new card Trello card, SMS message still sent.
@@ -99,7 +133,7 @@ func TestJobs(t *testing.T) {
 			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListNil, OverrideCreateShoppingList: OverrideCreateShoppingListErrorBasic},
 			mocks.MockTwilio{},
 			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel, logrus.InfoLevel},
-			[]string{"Restocking required", "Failed to create Trello card", "Sent Twilio message"},
+			[]string{"Restocking required", "Failed to create shopping list", "Sent Twilio message"},
 		},
 		{
 			// Error #6, items expired/expiring but could not create new card Trello card or send SMS message.
@@ -108,7 +142,43 @@ func TestJobs(t *testing.T) {
 			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListNil, OverrideCreateShoppingList: OverrideCreateShoppingListErrorBasic},
 			mocks.MockTwilio{OverrideComposeMessage: OverrideComposeMessageEmpty, OverrideSendMessage: OverrideSendMessageErrorBasic},
 			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel, logrus.ErrorLevel},
-			[]string{"Restocking required", "Failed to create Trello card", "Failed to send Twilio message"},
+			[]string{"Restocking required", "Failed to create shopping list", "Failed to send Twilio message"},
+		},
+		{
+			// Success #6, No expired/expiring items, overdue Trello card rolled over with its hand-added items.
+			"checkExpirationsSuccess#6",
+			mocks.MockMongo{},
+			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListOverdue, OverrideGetShoppingListItems: OverrideGetShoppingListItemsRollover},
+			mocks.MockTwilio{},
+			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel, logrus.InfoLevel},
+			[]string{"Restocking not required", "Rolled over shopping list", "Created shopping list"},
+		},
+		{
+			// Success #7, items expired/expiring, completed Trello card rolled over to a new card and SMS message sent.
+			"checkExpirationsSuccess#7",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsSuccess},
+			mocks.MockTrello{OverrideGetShoppingListItems: OverrideGetShoppingListItemsComplete},
+			mocks.MockTwilio{},
+			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel, logrus.InfoLevel, logrus.InfoLevel},
+			[]string{"Restocking required", "Rolled over shopping list", "Created shopping list", "Sent Twilio message"},
+		},
+		{
+			// Error #9, overdue Trello card could not be archived, so it's kept.
+			"checkExpirationsError#9",
+			mocks.MockMongo{},
+			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListOverdue, OverrideGetShoppingListItems: OverrideGetShoppingListItemsRollover, OverrideArchiveShoppingList: OverrideArchiveShoppingListErrorBasic},
+			mocks.MockTwilio{},
+			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel, logrus.InfoLevel},
+			[]string{"Restocking not required", "Failed to roll over shopping list", "Updated shopping list"},
+		},
+		{
+			// Error #10, overdue Trello card could not be recorded in the shopping history, so it's kept.
+			"checkExpirationsError#10",
+			mocks.MockMongo{OverrideInsertManyDocuments: OverrideInsertManyDocumentsErrorBasic},
+			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListOverdue, OverrideGetShoppingListItems: OverrideGetShoppingListItemsRollover},
+			mocks.MockTwilio{},
+			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel, logrus.InfoLevel},
+			[]string{"Restocking not required", "Failed to roll over shopping list", "Updated shopping list"},
 		},
 		{
 			// Success #4, items expired/expiring added to new Trello card and SMS message skipped.
@@ -117,7 +187,7 @@ func TestJobs(t *testing.T) {
 			mocks.MockTrello{OverrideGetShoppingList: OverrideGetShoppingListNil},
 			mocks.MockTwilio{},
 			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel, logrus.InfoLevel},
-			[]string{"Restocking required", "Created Trello card", "Skipped Twilio message"},
+			[]string{"Restocking required", "Created shopping list", "Skipped Twilio message"},
 		},
 	}
 
@@ -125,7 +195,7 @@ func TestJobs(t *testing.T) {
 		t.Run(st.name, func(t *testing.T) {
 			// Arrange
 			configuration.Mongo = &st.mongoClient
-			configuration.Trello = &st.mocksClient
+			configuration.ShoppingList = &st.mocksClient
 			configuration.Twilio = &st.twilioClient
 
 			if st.name == "checkExpirationsSuccess#4" {
@@ -154,3 +224,333 @@ func TestJobs(t *testing.T) {
 		log.SetOutput(os.Stdout)
 	}
 }
+
+func TestThawReminders(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	// Capture logrus output so we can assert
+	_, hook := test.NewNullLogger()
+	logrus.AddHook(hook)
+	base := len(hook.AllEntries())
+
+	var message string
+	sendMessage := func(from, to, body string) (string, error) {
+		message = body
+		return "", nil
+	}
+
+	subtests := []struct {
+		name         string
+		mongoClient  mocks.MockMongo
+		twilioClient mocks.MockTwilio
+		silence      bool
+		logLevels    []logrus.Level
+		logMessages  []string
+	}{
+		{
+			// Error #1, Could not obtain planned meals
+			"checkThawingError#1",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic},
+			mocks.MockTwilio{},
+			false,
+			[]logrus.Level{logrus.ErrorLevel},
+			[]string{"Failed to identify planned meals"},
+		},
+		{
+			// Error #2, Could not obtain planned recipes
+			"checkThawingError#2",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsThawRecipesError},
+			mocks.MockTwilio{},
+			false,
+			[]logrus.Level{logrus.ErrorLevel},
+			[]string{"Failed to identify planned recipes"},
+		},
+		{
+			// Error #3, Could not obtain stocked ingredients
+			"checkThawingError#3",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsThawIngredientsError},
+			mocks.MockTwilio{},
+			false,
+			[]logrus.Level{logrus.ErrorLevel},
+			[]string{"Failed to identify stocked ingredients"},
+		},
+		{
+			// Success #1, Nothing planned for tomorrow
+			"checkThawingSuccess#1",
+			mocks.MockMongo{},
+			mocks.MockTwilio{},
+			false,
+			[]logrus.Level{logrus.InfoLevel},
+			[]string{"Thawing not required"},
+		},
+		{
+			// Success #2, Frozen ingredients found and SMS message sent
+			"checkThawingSuccess#2",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsThaw},
+			mocks.MockTwilio{OverrideSendMessage: sendMessage},
+			false,
+			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel},
+			[]string{"Thawing required", "Sent Twilio message"},
+		},
+		{
+			// Error #4, Frozen ingredients found but could not send SMS message
+			"checkThawingError#4",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsThaw},
+			mocks.MockTwilio{OverrideSendMessage: OverrideSendMessageErrorBasic},
+			false,
+			[]logrus.Level{logrus.InfoLevel, logrus.ErrorLevel},
+			[]string{"Thawing required", "Failed to send Twilio message"},
+		},
+		{
+			// Success #3, Frozen ingredients found and SMS message skipped
+			"checkThawingSuccess#3",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsThaw},
+			mocks.MockTwilio{},
+			true,
+			[]logrus.Level{logrus.InfoLevel, logrus.InfoLevel},
+			[]string{"Thawing required", "Skipped Twilio message"},
+		},
+	}
+
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			// Arrange
+			configuration.Mongo = &st.mongoClient
+			configuration.Twilio = &st.twilioClient
+			configuration.Silence = st.silence
+			defer func() { configuration.Silence = false }()
+
+			// Act
+			checkThawing()
+
+			// Assert (preliminary)
+			require.Equal(t, len(st.logLevels), len(st.logMessages))
+
+			// Assert (primary)
+			for i, _ := range st.logLevels {
+				index := base + i
+				require.Equal(t, st.logLevels[i], hook.AllEntries()[index].Level)
+				require.Equal(t, st.logMessages[i], hook.AllEntries()[index].Message)
+			}
+
+			base += len(st.logLevels)
+		})
+	}
+	require.Equal(t, "Move to the fridge tonight for tomorrow's meals: Beef (Hamburgers, Stew)", message)
+
+	// Reverse logrus output change
+	log.SetOutput(os.Stdout)
+}
+
+func TestShoppingListSync(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	// Capture logrus output so we can assert
+	_, hook := test.NewNullLogger()
+	logrus.AddHook(hook)
+	base := len(hook.AllEntries())
+
+	var removed []string
+	completed := func(items ...string) func() ([]string, error) {
+		return func() ([]string, error) { return items, nil }
+	}
+	remove := func(itemText []string) error {
+		removed = itemText
+		return nil
+	}
+
+	subtests := []struct {
+		name         string
+		mongoClient  mocks.MockMongo
+		trelloClient mocks.MockTrello
+		logLevels    []logrus.Level
+		logMessages  []string
+	}{
+		{
+			// Error #1, Could not obtain completed items
+			"syncShoppingListError#1",
+			mocks.MockMongo{},
+			mocks.MockTrello{OverrideGetCompletedShoppingItems: func() ([]string, error) { return nil, fmt.Errorf(errorBasic) }},
+			[]logrus.Level{logrus.ErrorLevel},
+			[]string{"Failed to get completed shopping items"},
+		},
+		{
+			// Success #1, Nothing checked off
+			"syncShoppingListSuccess#1",
+			mocks.MockMongo{},
+			mocks.MockTrello{},
+			[]logrus.Level{logrus.InfoLevel},
+			[]string{"Shopping list sync not required"},
+		},
+		{
+			// Error #2, Could not obtain ingredients
+			"syncShoppingListError#2",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsErrorBasic},
+			mocks.MockTrello{OverrideGetCompletedShoppingItems: completed("Milk")},
+			[]logrus.Level{logrus.ErrorLevel},
+			[]string{"Failed to restock shopping items"},
+		},
+		{
+			// Error #3, Could not restock an ingredient
+			"syncShoppingListError#3",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTrip, OverrideUpdateOneDocument: OverrideUpdateOneDocumentTripError},
+			mocks.MockTrello{OverrideGetCompletedShoppingItems: completed("Flour (expired)")},
+			[]logrus.Level{logrus.ErrorLevel, logrus.ErrorLevel},
+			[]string{"Failed to restock ingredient", "Failed to restock shopping items"},
+		},
+		{
+			// Error #4, Restocked but could not remove the items from the list
+			"syncShoppingListError#4",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTrip},
+			mocks.MockTrello{OverrideGetCompletedShoppingItems: completed("Milk"), OverrideRemoveFromShoppingList: func([]string) error { return fmt.Errorf(errorBasic) }},
+			[]logrus.Level{logrus.ErrorLevel, logrus.InfoLevel},
+			[]string{"Failed to remove items from shopping list", "Restocked ingredients"},
+		},
+		{
+			// Success #2, Matched items restocked and removed, unmatched items left alone
+			"syncShoppingListSuccess#2",
+			mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTrip},
+			mocks.MockTrello{OverrideGetCompletedShoppingItems: completed("Milk (expiring)", "Saffron"), OverrideRemoveFromShoppingList: remove},
+			[]logrus.Level{logrus.WarnLevel, logrus.InfoLevel},
+			[]string{"Unmatched shopping items", "Restocked ingredients"},
+		},
+	}
+
+	trello := configuration.ShoppingList
+	defer func() { configuration.ShoppingList = trello }()
+	for _, st := range subtests {
+		t.Run(st.name, func(t *testing.T) {
+			// Arrange
+			configuration.Mongo = &st.mongoClient
+			configuration.ShoppingList = &st.trelloClient
+
+			// Act
+			syncShoppingList()
+
+			// Assert (preliminary)
+			require.Equal(t, len(st.logLevels), len(st.logMessages))
+
+			// Assert (primary)
+			for i, _ := range st.logLevels {
+				index := base + i
+				require.Equal(t, st.logLevels[i], hook.AllEntries()[index].Level)
+				require.Equal(t, st.logMessages[i], hook.AllEntries()[index].Message)
+			}
+
+			base += len(st.logLevels)
+		})
+	}
+	require.Equal(t, []string{"Milk (expiring)"}, removed)
+
+	// Reverse logrus output change
+	log.SetOutput(os.Stdout)
+}
+
+func TestShoppingListSyncRollover(t *testing.T) {
+	// Discard logging output
+	logrus.SetOutput(io.Discard)
+
+	mongo, shoppingList := configuration.Mongo, configuration.ShoppingList
+	defer func() { configuration.Mongo, configuration.ShoppingList = mongo, shoppingList }()
+
+	// A card with both items checked off, and the shopping history and restocked items kept in memory
+	card := config.ShoppingList{ID: "card", Name: "Shopping List", URL: "www.mock.url.com"}
+	items := []config.ShoppingItem{{Name: "Milk (expiring)", Checked: true}, {Name: "Flour (expired)", Checked: true}}
+	history := []bson.M{}
+	restocked := []bson.M{}
+	configuration.Mongo = &mocks.MockMongo{
+		OverrideFindManyDocuments: func(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+			if collection != config.MongoCollectionShoppingRestocked {
+				return OverrideFindManyDocumentsTrip(ctx, collection, filter, opts)
+			}
+			found := []bson.M{}
+			for _, document := range restocked {
+				if document["listId"] == filter["listId"] {
+					found = append(found, document)
+				}
+			}
+			return found, nil
+		},
+		OverrideInsertManyDocuments: func(ctx context.Context, collection string, docs []interface{}) error {
+			for _, doc := range docs {
+				if collection == config.MongoCollectionShoppingRestocked {
+					restocked = append(restocked, doc.(bson.M))
+				} else if collection == config.MongoCollectionShoppingHistory {
+					history = append(history, doc.(bson.M))
+				}
+			}
+			return nil
+		},
+		OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+			if collection != config.MongoCollectionShoppingRestocked {
+				return 1, 1, nil
+			}
+			for _, document := range restocked {
+				if document["listId"] == filter[0].Value {
+					added := update.(bson.M)["$addToSet"].(bson.M)["restocked"].(bson.M)["$each"].([]string)
+					document["restocked"] = utils.AppendUnique(document["restocked"].([]string), added...)
+					return 1, 1, nil
+				}
+			}
+			return 0, 0, nil
+		},
+		OverrideDeleteManyDocuments: func(ctx context.Context, collection string, filter bson.M) (int64, error) {
+			if collection != config.MongoCollectionShoppingRestocked {
+				return 0, nil
+			}
+			kept := []bson.M{}
+			for _, document := range restocked {
+				if document["listId"] != filter["listId"] {
+					kept = append(kept, document)
+				}
+			}
+			deleted := int64(len(restocked) - len(kept))
+			restocked = kept
+			return deleted, nil
+		},
+	}
+	configuration.ShoppingList = &mocks.MockTrello{
+		OverrideGetShoppingList: func() (*config.ShoppingList, error) {
+			return &card, nil
+		},
+		OverrideGetCompletedShoppingItems: func() ([]string, error) {
+			completed := []string{}
+			for _, item := range items {
+				if item.Checked {
+					completed = append(completed, item.Name)
+				}
+			}
+			return completed, nil
+		},
+		OverrideRemoveFromShoppingList: func(itemText []string) error {
+			kept := []config.ShoppingItem{}
+			for _, item := range items {
+				if !utils.Contains(itemText, item.Name) {
+					kept = append(kept, item)
+				}
+			}
+			items = kept
+			return nil
+		},
+		OverrideGetShoppingListItems: func(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+			return items, nil
+		},
+	}
+
+	// The sync takes everything off the card, which still counts as completed, without touching the history
+	syncShoppingList()
+	require.Empty(t, items)
+	require.Empty(t, history)
+	require.Len(t, restocked, 1)
+
+	rolled, carried, err := rolloverShoppingList(context.Background(), &card, time.Now())
+	require.NoError(t, err)
+	require.True(t, rolled)
+	require.Empty(t, carried)
+	require.Len(t, history, 1)
+	require.Equal(t, "completed", history[0]["reason"])
+	require.Equal(t, []bson.M{{"checked": true, "name": "Milk (expiring)"}, {"checked": true, "name": "Flour (expired)"}}, history[0]["items"])
+	require.Empty(t, restocked)
+}
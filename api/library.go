@@ -3,38 +3,245 @@ package api
 import (
 	"context"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+type recipeIngredient struct {
+	Name      string
+	Value     float64
+	Unit      string
+	HasAmount bool
+}
+
+// Recipe ingredients are either plain names or {name, amount: {value, unit}} documents
+func parseRecipeIngredient(entry interface{}) (recipeIngredient, bool) {
+	var fields map[string]interface{}
+	switch v := entry.(type) {
+	case string:
+		return recipeIngredient{Name: v}, true
+	case primitive.M:
+		fields = v
+	case map[string]interface{}:
+		fields = v
+	default:
+		return recipeIngredient{}, false
+	}
+
+	name, ok := fields["name"].(string)
+	if !ok {
+		return recipeIngredient{}, false
+	}
+
+	ingredient := recipeIngredient{Name: name}
+	if value, unit, ok := parseAmount(fields["amount"]); ok {
+		ingredient.Value = value
+		ingredient.Unit = unit
+		ingredient.HasAmount = true
+	}
+	return ingredient, true
+}
+
+func parseAmount(amount interface{}) (float64, string, bool) {
+	var fields map[string]interface{}
+	switch v := amount.(type) {
+	case primitive.M:
+		fields = v
+	case map[string]interface{}:
+		fields = v
+	default:
+		return 0, "", false
+	}
+
+	value, ok := utils.Float64FromNumber(fields["value"])
+	if !ok {
+		return 0, "", false
+	}
+	unit, _ := fields["unit"].(string)
+	return value, unit, true
+}
+
+func recipeIngredients(recipe *primitive.M) []interface{} {
+	if v, ok := (*recipe)["ingredients"].([]interface{}); ok {
+		return v
+	} else if v, ok := (*recipe)["ingredients"].(primitive.A); ok {
+		return v
+	}
+	return nil
+}
+
+// Planned recipes are either plain names or {name, servings} documents
+func mealPlanEntries(plan bson.M) []interface{} {
+	if v, ok := plan["recipes"].([]interface{}); ok {
+		return v
+	} else if v, ok := plan["recipes"].(primitive.A); ok {
+		return v
+	}
+	return nil
+}
+
+// Lifespans are given per storage location as {value, unit} in days, weeks, months or years
+func lifespanExpiration(ingredient bson.M, storeIn string, from time.Time) (int64, bool) {
+	lifespan, ok := ingredient["lifespan"].(primitive.M)
+	if !ok {
+		if m, isMap := ingredient["lifespan"].(map[string]interface{}); isMap {
+			lifespan, ok = m, true
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+
+	value, unit, ok := parseAmount(lifespan[storeIn])
+	if !ok {
+		return 0, false
+	}
+
+	var expires time.Time
+	switch strings.TrimSuffix(strings.ToLower(unit), "s") {
+	case "day":
+		expires = from.Add(time.Duration(value * float64(24*time.Hour)))
+	case "week":
+		expires = from.Add(time.Duration(value * float64(7*24*time.Hour)))
+	case "month":
+		expires = from.AddDate(0, int(value), 0)
+	case "year":
+		expires = from.AddDate(int(value), 0, 0)
+	default:
+		return 0, false
+	}
+	return int64(expires.UTC().UnixNano()) / int64(time.Millisecond), true
+}
+
+type recipeStep struct {
+	Text            string
+	Duration        int // seconds
+	Temperature     float64
+	TemperatureUnit string
+}
+
+// Recipe steps are either plain text or {text, duration, temperature: {value, unit}} documents
+func recipeSteps(recipe *primitive.M) []recipeStep {
+	var entries []interface{}
+	if v, ok := (*recipe)["steps"].([]interface{}); ok {
+		entries = v
+	} else if v, ok := (*recipe)["steps"].(primitive.A); ok {
+		entries = v
+	}
+
+	steps := []recipeStep{}
+	for _, entry := range entries {
+		var fields map[string]interface{}
+		switch v := entry.(type) {
+		case string:
+			steps = append(steps, recipeStep{Text: v})
+			continue
+		case primitive.M:
+			fields = v
+		case map[string]interface{}:
+			fields = v
+		default:
+			continue
+		}
+
+		step := recipeStep{}
+		step.Text, _ = fields["text"].(string)
+		if duration, ok := utils.Float64FromNumber(fields["duration"]); ok && duration > 0 {
+			step.Duration = int(duration)
+		}
+		if value, unit, ok := parseAmount(fields["temperature"]); ok {
+			step.Temperature = value
+			step.TemperatureUnit = unit
+		}
+		steps = append(steps, step)
+	}
+	return steps
+}
+
+func parseServings(value string) (int, error) {
+	servings, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	} else if servings <= 0 {
+		return 0, fmt.Errorf("servings must be positive: %d", servings)
+	}
+	return servings, nil
+}
+
+func scaleRecipe(recipe *primitive.M, servings int) float64 {
+	// Recipes without a serving size cannot be scaled
+	base, ok := utils.Float64FromNumber((*recipe)["servings"])
+	if !ok || base <= 0 || servings <= 0 {
+		return 1
+	}
+
+	factor := float64(servings) / base
+	for _, entry := range recipeIngredients(recipe) {
+		var fields map[string]interface{}
+		switch v := entry.(type) {
+		case primitive.M:
+			fields = v
+		case map[string]interface{}:
+			fields = v
+		default:
+			continue
+		}
+
+		if value, unit, ok := parseAmount(fields["amount"]); ok {
+			fields["amount"] = bson.M{"value": value * factor, "unit": unit}
+		}
+	}
+
+	(*recipe)["servings"] = servings
+	return factor
+}
+
 func isCookable(ctx context.Context, recipe *primitive.M) (bool, error) {
+	return checkCookable(ctx, recipe, false)
+}
+
+// Quick meals can't wait for frozen ingredients to thaw, so they only count once moved out of the freezer
+func isCookableWithoutThawing(ctx context.Context, recipe *primitive.M) (bool, error) {
+	return checkCookable(ctx, recipe, true)
+}
+
+func checkCookable(ctx context.Context, recipe *primitive.M, excludeFrozen bool) (bool, error) {
 	// Setup
 	log := logrus.WithFields(logrus.Fields{
-		"at":     "api.isCookable",
-		"recipe": (*recipe)["_id"],
+		"at":            "api.isCookable",
+		"excludeFrozen": excludeFrozen,
+		"recipe":        (*recipe)["_id"],
 	})
 
 	// Determine if recipe is cookable
 	log.Trace("Begin cookable determination")
 	defer log.Trace("End cookable determination")
-	ingredientNames := (*recipe)["ingredients"]
 
-	if ingredientNames == nil {
+	if (*recipe)["ingredients"] == nil {
 		return false, fmt.Errorf("no ingredients specified")
 	}
 
-	var length int
-	if v, ok := ingredientNames.([]interface{}); ok {
-		length = len(v)
-	} else if v, ok := ingredientNames.(primitive.A); ok {
-		length = len(v)
+	var required []recipeIngredient
+	ingredientNames := []string{}
+	for _, entry := range recipeIngredients(recipe) {
+		if ingredient, ok := parseRecipeIngredient(entry); ok {
+			required = append(required, ingredient)
+			ingredientNames = append(ingredientNames, ingredient.Name)
+		}
 	}
-	//iids := ingreidentNames.([]interface{}) // "ingredients" is a requried attribute
+
 	filterMany := bson.M{"$and": []bson.M{
 		{
 			"expirationDate": bson.M{
@@ -52,15 +259,1024 @@ func isCookable(ctx context.Context, recipe *primitive.M) (bool, error) {
 			},
 		},
 	}}
+	if excludeFrozen {
+		filterMany["$and"] = append(filterMany["$and"].([]bson.M), bson.M{"storeIn": bson.M{"$ne": "freezer"}})
+	}
 	log.WithFields(logrus.Fields{"value": filterMany}).Debug("Filter data")
 
 	ingredients, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filterMany, nil)
 	if err != nil {
 		log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get documents")
 		return false, err
-	} else {
-		result := len(ingredients) >= length
-		log.WithFields(logrus.Fields{"expect": length, "have": len(ingredients), "value": result}).Debug("Determined")
-		return result, nil
 	}
+
+	// Check each required ingredient is stocked in sufficient quantity
+	result := true
+	for _, r := range required {
+		found := false
+		unknown := false
+		total := 0.0
+		for _, ingredient := range ingredients {
+			if ingredient["name"] != r.Name {
+				continue
+			}
+			found = true
+
+			value, unit, ok := parseAmount(ingredient["amount"])
+			if !ok {
+				// Quantity isn't tracked for this item, assume there's enough
+				unknown = true
+				continue
+			}
+
+			converted, err := utils.ConvertUnits(value, unit, r.Unit)
+			if err != nil {
+				// The stocked amount can't count towards what's needed
+				log.WithFields(logrus.Fields{"ingredient": r.Name, "from": unit, "to": r.Unit}).WithError(err).Warn("Failed to convert units")
+			} else {
+				total += converted
+			}
+		}
+
+		if !found || (r.HasAmount && !unknown && total < r.Value) {
+			log.WithFields(logrus.Fields{"ingredient": r.Name, "need": r.Value, "have": total, "unit": r.Unit}).Debug("Insufficient ingredient")
+			result = false
+			break
+		}
+	}
+
+	// Check the equipment the recipe calls for is on hand
+	if requires := stringList((*recipe)["requires"]); result && len(requires) > 0 {
+		available, err := equipmentAvailability(ctx)
+		if err != nil {
+			log.WithFields(logrus.Fields{"status": http.StatusInternalServerError}).WithError(err).Error("Failed to get equipment")
+			return false, err
+		}
+
+		if missing := missingEquipment(recipe, available); len(missing) > 0 {
+			log.WithFields(logrus.Fields{"equipment": missing}).Debug("Unavailable equipment")
+			result = false
+		}
+	}
+
+	log.WithFields(logrus.Fields{"expect": len(required), "have": len(ingredients), "value": result}).Debug("Determined")
+	return result, nil
+}
+
+// Equipment is available unless marked otherwise (e.g. broken or away at the cabin)
+func equipmentAvailability(ctx context.Context) (map[string]bool, error) {
+	equipment, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionEquipment, bson.M{}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	available := map[string]bool{}
+	for _, item := range equipment {
+		name, ok := item["name"].(string)
+		if !ok {
+			continue
+		}
+		flag, ok := item["available"].(bool)
+		available[strings.ToLower(name)] = !ok || flag
+	}
+	return available, nil
+}
+
+// Equipment that isn't in the inventory at all counts as missing
+func missingEquipment(recipe *primitive.M, available map[string]bool) []string {
+	missing := []string{}
+	for _, name := range stringList((*recipe)["requires"]) {
+		if !available[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
+
+// Nutrition facts are numeric fields of an ingredient's "nutrition" document,
+// given per the amount in "per" (e.g. {value: 100, unit: "gram"} or a serving size)
+func nutritionTemplates(ctx context.Context, names []string) (map[string]primitive.M, error) {
+	filter := bson.M{"$and": []bson.M{
+		{
+			"name": bson.M{
+				"$in": names,
+			},
+		},
+		{
+			"nutrition": bson.M{
+				"$exists": true,
+			},
+		},
+	}}
+
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := map[string]primitive.M{}
+	for _, document := range documents {
+		name, _ := document["name"].(string)
+		if _, found := templates[name]; found {
+			continue
+		}
+
+		switch v := document["nutrition"].(type) {
+		case primitive.M:
+			templates[name] = v
+		case map[string]interface{}:
+			templates[name] = v
+		}
+	}
+	return templates, nil
+}
+
+func computeNutrition(ingredients []recipeIngredient, templates map[string]primitive.M) (map[string]float64, []string) {
+	totals := map[string]float64{}
+	missing := []string{}
+	for _, ingredient := range ingredients {
+		template, found := templates[ingredient.Name]
+		perValue, perUnit, ok := parseAmount(template["per"])
+		if !found || !ok || perValue <= 0 || !ingredient.HasAmount {
+			missing = append(missing, ingredient.Name)
+			continue
+		}
+
+		amount, err := utils.ConvertUnits(ingredient.Value, ingredient.Unit, perUnit)
+		if err != nil {
+			missing = append(missing, ingredient.Name)
+			continue
+		}
+
+		factor := amount / perValue
+		for nutrient, value := range template {
+			if v, ok := utils.Float64FromNumber(value); ok {
+				totals[nutrient] += v * factor
+			}
+		}
+	}
+	return totals, missing
+}
+
+func recipeNutrition(ctx context.Context, recipe *primitive.M) (bson.M, error) {
+	var ingredients []recipeIngredient
+	names := []string{}
+	for _, entry := range recipeIngredients(recipe) {
+		if ingredient, ok := parseRecipeIngredient(entry); ok {
+			ingredients = append(ingredients, ingredient)
+			names = append(names, ingredient.Name)
+		}
+	}
+
+	templates, err := nutritionTemplates(ctx, names)
+	if err != nil {
+		return nil, err
+	} else if len(templates) == 0 {
+		// Nothing known about these ingredients
+		return nil, nil
+	}
+
+	totals, missing := computeNutrition(ingredients, templates)
+	servings, ok := utils.Float64FromNumber((*recipe)["servings"])
+	if !ok || servings <= 0 {
+		servings = 1
+	}
+
+	perServing := map[string]float64{}
+	for nutrient, value := range totals {
+		perServing[nutrient] = value / servings
+	}
+
+	return bson.M{
+		"missing":    missing,
+		"perServing": perServing,
+		"total":      totals,
+	}, nil
+}
+
+// Price documents record what was paid for an ingredient and, when known, the amount bought ({value, unit}).
+// Only the most recent price of each ingredient is used for estimates.
+func latestPrices(ctx context.Context, names []string) (map[string]primitive.M, error) {
+	prices := map[string]primitive.M{}
+	if len(names) == 0 {
+		return prices, nil
+	}
+
+	filter := bson.M{
+		"name": bson.M{
+			"$in": names,
+		},
+	}
+	opts := options.Find()
+	opts.SetSort(bson.D{{"date", -1}})
+
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionPrices, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, document := range documents {
+		name, _ := document["name"].(string)
+		if _, found := prices[name]; found {
+			continue
+		} else if _, ok := utils.Float64FromNumber(document["price"]); ok {
+			prices[name] = document
+		}
+	}
+	return prices, nil
+}
+
+// Scale a price to the given amount of the ingredient, or take the whole price when no amount is given
+func estimateCost(price primitive.M, value float64, unit string, hasAmount bool) (float64, bool) {
+	paid, ok := utils.Float64FromNumber(price["price"])
+	if !ok {
+		return 0, false
+	} else if !hasAmount {
+		return paid, true
+	}
+
+	perValue, perUnit, ok := parseAmount(price["amount"])
+	if !ok || perValue <= 0 {
+		return 0, false
+	}
+
+	amount, err := utils.ConvertUnits(value, unit, perUnit)
+	if err != nil {
+		return 0, false
+	}
+	return paid * amount / perValue, true
+}
+
+func roundCents(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// The latest prices of every ingredient used by the given recipes, in a single lookup
+func recipesPrices(ctx context.Context, recipes []bson.M) (map[string]primitive.M, error) {
+	names := []string{}
+	for i := range recipes {
+		for _, entry := range recipeIngredients(&recipes[i]) {
+			if ingredient, ok := parseRecipeIngredient(entry); ok {
+				names = append(names, ingredient.Name)
+			}
+		}
+	}
+	return latestPrices(ctx, names)
+}
+
+// What a recipe costs to make, from the latest prices of its ingredients.
+// Ingredients without an amount or a usable price are reported as missing.
+func recipeCost(recipe *primitive.M, prices map[string]primitive.M) bson.M {
+	if len(prices) == 0 {
+		// Nothing has been bought yet
+		return nil
+	}
+
+	var ingredients []recipeIngredient
+	for _, entry := range recipeIngredients(recipe) {
+		if ingredient, ok := parseRecipeIngredient(entry); ok {
+			ingredients = append(ingredients, ingredient)
+		}
+	}
+
+	total := 0.0
+	missing := []string{}
+	for _, ingredient := range ingredients {
+		price, found := prices[ingredient.Name]
+		if !found || !ingredient.HasAmount {
+			missing = append(missing, ingredient.Name)
+			continue
+		}
+
+		cost, ok := estimateCost(price, ingredient.Value, ingredient.Unit, true)
+		if !ok {
+			missing = append(missing, ingredient.Name)
+			continue
+		}
+		total += cost
+	}
+
+	servings, ok := utils.Float64FromNumber((*recipe)["servings"])
+	if !ok || servings <= 0 {
+		servings = 1
+	}
+
+	return bson.M{
+		"missing":    missing,
+		"perServing": roundCents(total / servings),
+		"total":      roundCents(total),
+	}
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func splitTags(value string) []string {
+	return splitList(strings.ToLower(value))
+}
+
+func stringValues(value interface{}) []string {
+	list := []string{}
+	var items []interface{}
+	if v, ok := value.([]interface{}); ok {
+		items = v
+	} else if v, ok := value.(primitive.A); ok {
+		items = v
+	} else if v, ok := value.([]string); ok {
+		return v
+	}
+
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
+func stringList(value interface{}) []string {
+	list := []string{}
+	for _, s := range stringValues(value) {
+		list = append(list, strings.ToLower(s))
+	}
+	return list
+}
+
+// A recipe contains every allergen of its ingredients, and fits only the diets all of its ingredients fit.
+// Ingredients that aren't known or carry no allergen data are returned too, since they may contain anything.
+func recipeTags(ctx context.Context, recipe *primitive.M) ([]string, []string, []string, error) {
+	names := []string{}
+	for _, entry := range recipeIngredients(recipe) {
+		if ingredient, ok := parseRecipeIngredient(entry); ok {
+			names = append(names, ingredient.Name)
+		}
+	}
+
+	filter := bson.M{"name": bson.M{"$in": names}}
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filter, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	templates := map[string]primitive.M{}
+	for _, document := range documents {
+		if name, ok := document["name"].(string); ok {
+			if _, found := templates[name]; !found {
+				templates[name] = document
+			}
+		}
+	}
+
+	allergens := []string{}
+	unknown := []string{}
+	var diets []string
+	for i, name := range names {
+		template, found := templates[name]
+		if _, ok := template["allergens"]; !found || !ok {
+			unknown = utils.AppendUnique(unknown, name)
+		}
+		for _, allergen := range stringList(template["allergens"]) {
+			if !utils.Contains(allergens, allergen) {
+				allergens = append(allergens, allergen)
+			}
+		}
+
+		fits := stringList(template["diets"])
+		if i == 0 {
+			diets = fits
+		} else {
+			common := []string{}
+			for _, diet := range diets {
+				if utils.Contains(fits, diet) {
+					common = append(common, diet)
+				}
+			}
+			diets = common
+		}
+	}
+	if diets == nil {
+		diets = []string{}
+	}
+
+	sort.Strings(allergens)
+	sort.Strings(diets)
+	return allergens, diets, unknown, nil
+}
+
+func tagRecipes(ctx context.Context, recipes []bson.M, excludeAllergens, diets []string) ([]bson.M, error) {
+	tagged := []bson.M{}
+	for _, recipe := range recipes {
+		allergens, fits, unknown, err := recipeTags(ctx, &recipe)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(allergens) > 0 {
+			recipe["allergens"] = allergens
+		}
+		if len(fits) > 0 {
+			recipe["diets"] = fits
+		}
+
+		// Apply dietary restrictions, an ingredient without allergen data can't be ruled safe
+		suitable := !(len(excludeAllergens) > 0 && len(unknown) > 0)
+		for _, allergen := range excludeAllergens {
+			if utils.Contains(allergens, allergen) {
+				suitable = false
+			}
+		}
+		for _, diet := range diets {
+			if !utils.Contains(fits, diet) {
+				suitable = false
+			}
+		}
+
+		if suitable {
+			tagged = append(tagged, recipe)
+		}
+	}
+	return tagged, nil
+}
+
+type household struct {
+	Allergens []string
+	Diets     []string
+	Dislikes  []string
+	Favorites map[string]int
+}
+
+func loadHousehold(ctx context.Context, names []string) (household, error) {
+	h := household{
+		Allergens: []string{},
+		Diets:     []string{},
+		Dislikes:  []string{},
+		Favorites: map[string]int{},
+	}
+	if len(names) == 0 {
+		return h, nil
+	}
+
+	filter := bson.M{"name": bson.M{"$in": names}}
+	members, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionMembers, filter, nil)
+	if err != nil {
+		return h, err
+	}
+
+	// Everyone at the table must be known
+	found := []string{}
+	for _, member := range members {
+		if name, ok := member["name"].(string); ok {
+			found = append(found, name)
+		}
+	}
+	for _, name := range names {
+		if !utils.Contains(found, name) {
+			return h, fmt.Errorf("%s: %s", utils.ErrorMemberNotFound, name)
+		}
+	}
+
+	// Combine everyone's restrictions and preferences
+	for _, member := range members {
+		for _, allergen := range stringList(member["allergens"]) {
+			if !utils.Contains(h.Allergens, allergen) {
+				h.Allergens = append(h.Allergens, allergen)
+			}
+		}
+		for _, diet := range stringList(member["diets"]) {
+			if !utils.Contains(h.Diets, diet) {
+				h.Diets = append(h.Diets, diet)
+			}
+		}
+		for _, dislike := range stringList(member["dislikes"]) {
+			if !utils.Contains(h.Dislikes, dislike) {
+				h.Dislikes = append(h.Dislikes, dislike)
+			}
+		}
+		for _, favorite := range stringList(member["favorites"]) {
+			h.Favorites[favorite]++
+		}
+	}
+	return h, nil
+}
+
+type recipeQuery struct {
+	Servings         int
+	MaxMinutes       int
+	Diets            []string
+	ExcludeAllergens []string
+	Members          []string
+}
+
+func parseRecipeQuery(queryParams url.Values) (recipeQuery, error) {
+	q := recipeQuery{
+		Diets:            splitTags(queryParams.Get("diet")),
+		ExcludeAllergens: splitTags(queryParams.Get("excludeAllergens")),
+		Members:          splitList(queryParams.Get("for")),
+	}
+
+	if qpServings := queryParams.Get("servings"); qpServings != "" {
+		servings, err := parseServings(qpServings)
+		if err != nil {
+			return q, err
+		}
+		q.Servings = servings
+	}
+
+	if qpMaxMinutes := queryParams.Get("maxMinutes"); qpMaxMinutes != "" {
+		minutes, err := strconv.Atoi(qpMaxMinutes)
+		if err != nil {
+			return q, err
+		} else if minutes <= 0 {
+			return q, fmt.Errorf("maxMinutes must be positive: %d", minutes)
+		}
+		q.MaxMinutes = minutes
+	}
+	return q, nil
+}
+
+// Total time is prep plus cook time, recipes with neither are of unknown length
+func recipeMinutes(recipe bson.M) (float64, bool) {
+	prep, okPrep := utils.Float64FromNumber(recipe["prepTime"])
+	cook, okCook := utils.Float64FromNumber(recipe["cookTime"])
+	return prep + cook, okPrep || okCook
+}
+
+func findCookableRecipes(ctx context.Context, q recipeQuery) ([]bson.M, household, error) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{"at": "api.findCookableRecipes"})
+
+	h, err := loadHousehold(ctx, q.Members)
+	if err != nil {
+		return nil, h, err
+	}
+
+	// Stored cookability reflects the default servings, so consider every recipe when scaling.
+	// It also goes stale when equipment breaks or comes back, so recipes that need any are always considered.
+	filter := bson.M{"$or": []bson.M{
+		{
+			"isCookable": true,
+		},
+		{
+			"requires.0": bson.M{
+				"$exists": true,
+			},
+		},
+	}}
+	if q.Servings > 0 {
+		filter = bson.M{}
+	}
+	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionRecipes, filter, nil)
+	if err != nil {
+		return nil, h, err
+	}
+
+	// Judge cookability against the scaled amounts
+	if q.Servings > 0 {
+		cookable := []bson.M{}
+		for _, document := range documents {
+			factor := scaleRecipe(&document, q.Servings)
+			log.WithFields(logrus.Fields{"factor": factor, "recipe": document["_id"], "servings": q.Servings}).Debug("Scaled recipe")
+
+			ok, err := isCookable(ctx, &document)
+			if err != nil {
+				return nil, h, err
+			} else if ok {
+				document["isCookable"] = true
+				cookable = append(cookable, document)
+			}
+		}
+		documents = cookable
+	}
+
+	// Recheck recipes that need equipment, scaled ones were checked in full above
+	if q.Servings == 0 {
+		equipped := []bson.M{}
+		for _, document := range documents {
+			if len(stringList(document["requires"])) > 0 {
+				ok, err := isCookable(ctx, &document)
+				if err != nil {
+					return nil, h, err
+				} else if !ok {
+					log.WithFields(logrus.Fields{"recipe": document["_id"]}).Debug("Not cookable with the equipment on hand")
+					continue
+				}
+				document["isCookable"] = true
+			}
+			equipped = append(equipped, document)
+		}
+		documents = equipped
+	}
+
+	// Only quick recipes that don't wait on the freezer
+	if q.MaxMinutes > 0 {
+		quick := []bson.M{}
+		for _, document := range documents {
+			minutes, ok := recipeMinutes(document)
+			if !ok || minutes > float64(q.MaxMinutes) {
+				continue
+			}
+
+			ok, err := isCookableWithoutThawing(ctx, &document)
+			if err != nil {
+				return nil, h, err
+			} else if !ok {
+				log.WithFields(logrus.Fields{"recipe": document["_id"]}).Debug("Needs thawing")
+				continue
+			}
+			quick = append(quick, document)
+		}
+		documents = quick
+	}
+
+	// Attach dietary tags and apply everyone's restrictions
+	excludeAllergens := append(append([]string{}, q.ExcludeAllergens...), h.Allergens...)
+	diets := append(append([]string{}, q.Diets...), h.Diets...)
+	documents, err = tagRecipes(ctx, documents, excludeAllergens, diets)
+	if err != nil {
+		return nil, h, err
+	}
+
+	// Drop recipes that use a disliked ingredient
+	if len(h.Dislikes) > 0 {
+		liked := []bson.M{}
+		for _, document := range documents {
+			disliked := false
+			for _, entry := range recipeIngredients(&document) {
+				if ingredient, ok := parseRecipeIngredient(entry); ok && utils.Contains(h.Dislikes, strings.ToLower(ingredient.Name)) {
+					disliked = true
+					break
+				}
+			}
+			if !disliked {
+				liked = append(liked, document)
+			}
+		}
+		documents = liked
+	}
+
+	// Favourites first
+	if len(h.Favorites) > 0 {
+		sort.SliceStable(documents, func(i, j int) bool {
+			return recipeFavorites(documents[i], h) > recipeFavorites(documents[j], h)
+		})
+	}
+
+	return documents, h, nil
+}
+
+func recipeFavorites(recipe bson.M, h household) int {
+	name, _ := recipe["name"].(string)
+	return h.Favorites[strings.ToLower(name)]
+}
+
+// Re-evaluate and store isCookable for recipes using any of the given ingredients,
+// returning the names of recipes that became cookable
+func refreshCookable(ctx context.Context, names []string) ([]string, error) {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{"at": "api.refreshCookable"})
+
+	filter := bson.M{"$or": []bson.M{
+		{"ingredients": bson.M{"$in": names}},
+		{"ingredients.name": bson.M{"$in": names}},
+	}}
+	log.WithFields(logrus.Fields{"value": filter}).Debug("Filter data")
+
+	recipes, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionRecipes, filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	cookable := []string{}
+	for _, recipe := range recipes {
+		original, _ := recipe["isCookable"].(bool)
+		ok, err := isCookable(ctx, &recipe)
+		if err != nil {
+			return nil, err
+		} else if ok == original {
+			continue
+		}
+
+		update := bson.M{"$set": bson.M{"isCookable": ok}}
+		_, _, err = configuration.Mongo.UpdateOneDocument(ctx, config.MongoCollectionRecipes, bson.D{{"_id", recipe["_id"]}}, update)
+		if err != nil {
+			return nil, err
+		}
+		log.WithFields(logrus.Fields{"recipe": recipe["_id"], "updated": ok}).Debug("Updated isCookable")
+
+		if name, isString := recipe["name"].(string); ok && isString {
+			cookable = append(cookable, name)
+		}
+	}
+	return cookable, nil
+}
+
+// Use up stocked quantities, soonest to expire first. Untracked quantities are left alone.
+func consumeIngredients(ctx context.Context, recipe *primitive.M) error {
+	// Setup
+	log := logrus.WithFields(logrus.Fields{
+		"at":     "api.consumeIngredients",
+		"recipe": (*recipe)["_id"],
+	})
+
+	opts := options.Find()
+	opts.SetSort(bson.D{{"expirationDate", 1}})
+
+	for _, entry := range recipeIngredients(recipe) {
+		required, ok := parseRecipeIngredient(entry)
+		if !ok || !required.HasAmount {
+			continue
+		}
+
+		// Only what counted towards the recipe being cookable, expired stock is left alone
+		filter := bson.M{"$and": []bson.M{
+			{
+				"expirationDate": bson.M{
+					"$gt": int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond),
+				},
+			},
+			{
+				"haveStocked": bson.M{
+					"$eq": true,
+				},
+			},
+			{
+				"name": bson.M{
+					"$eq": required.Name,
+				},
+			},
+		}}
+		stocked, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, filter, opts)
+		if err != nil {
+			return err
+		}
+
+		remaining := required.Value
+		for _, document := range stocked {
+			if remaining <= 0 {
+				break
+			}
+
+			value, unit, ok := parseAmount(document["amount"])
+			if !ok {
+				continue
+			}
+			have, err := utils.ConvertUnits(value, unit, required.Unit)
+			if err != nil || have <= 0 {
+				continue
+			}
+
+			used := remaining
+			if used > have {
+				used = have
+			}
+			remaining -= used
+
+			// Convert back to the stocked unit
+			left := value * (have - used) / have
+			set := bson.M{"amount": bson.M{"value": left, "unit": unit}}
+			if left <= 0 {
+				set["haveStocked"] = false
+			}
+
+			update := bson.M{"$set": set}
+			_, _, err = configuration.Mongo.UpdateOneDocument(ctx, config.MongoCollectionIngredients, bson.D{{"_id", document["_id"]}}, update)
+			if err != nil {
+				return err
+			}
+			log.WithFields(logrus.Fields{"ingredient": required.Name, "left": left, "unit": unit}).Debug("Consumed ingredient")
+		}
+	}
+	return nil
+}
+
+// Record a cook event and refresh the recipe's cooking statistics
+func recordCook(ctx context.Context, recipe *primitive.M, event bson.M) error {
+	event["recipe"] = (*recipe)["_id"]
+	event["name"] = (*recipe)["name"]
+	err := configuration.Mongo.InsertManyDocuments(ctx, config.MongoCollectionHistory, []interface{}{event})
+	if err != nil {
+		return err
+	}
+
+	events, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionHistory, bson.M{"recipe": (*recipe)["_id"]}, nil)
+	if err != nil {
+		return err
+	}
+
+	var lastCooked float64
+	var ratings, sum float64
+	for _, e := range events {
+		if date, ok := utils.Float64FromNumber(e["date"]); ok && date > lastCooked {
+			lastCooked = date
+		}
+		if rating, ok := utils.Float64FromNumber(e["rating"]); ok {
+			ratings++
+			sum += rating
+		}
+	}
+
+	stats := bson.M{
+		"lastCooked":  int64(lastCooked),
+		"timesCooked": len(events),
+	}
+	if ratings > 0 {
+		stats["rating"] = sum / ratings
+	}
+	for k, v := range stats {
+		(*recipe)[k] = v
+	}
+
+	_, _, err = configuration.Mongo.UpdateOneDocument(ctx, config.MongoCollectionRecipes, bson.D{{"_id", (*recipe)["_id"]}}, bson.M{"$set": stats})
+	return err
+}
+
+// Units that can't be converted but still shouldn't end up in an ingredient's name
+var unitsCount = []string{"bunch", "can", "clove", "dash", "head", "package", "pinch", "slice", "sprig", "stick"}
+
+var fractionsUnicode = map[rune]float64{'¼': 0.25, '½': 0.5, '¾': 0.75, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '⅛': 0.125}
+
+// Parse a number like "2", "1.5", "1/2", "½" or "1½"
+func parseQuantity(token string) (float64, bool) {
+	// Ranges (e.g. "2-3") use the lower bound
+	if i := strings.Index(token, "-"); i > 0 {
+		token = token[:i]
+	}
+
+	if value, err := strconv.ParseFloat(token, 64); err == nil {
+		return value, true
+	} else if parts := strings.Split(token, "/"); len(parts) == 2 {
+		numerator, errN := strconv.ParseFloat(parts[0], 64)
+		denominator, errD := strconv.ParseFloat(parts[1], 64)
+		if errN == nil && errD == nil && denominator != 0 {
+			return numerator / denominator, true
+		}
+		return 0, false
+	}
+
+	runes := []rune(token)
+	if len(runes) == 0 {
+		return 0, false
+	}
+	fraction, ok := fractionsUnicode[runes[len(runes)-1]]
+	if !ok {
+		return 0, false
+	} else if len(runes) == 1 {
+		return fraction, true
+	}
+	whole, err := strconv.ParseFloat(string(runes[:len(runes)-1]), 64)
+	if err != nil {
+		return 0, false
+	}
+	return whole + fraction, true
+}
+
+// Parse a free-form ingredient line such as "1 1/2 cups flour, sifted"
+func parseIngredientLine(line string) recipeIngredient {
+	tokens := strings.Fields(line)
+	ingredient := recipeIngredient{}
+
+	// Leading quantities are summed (e.g. "1 1/2")
+	i := 0
+	for ; i < len(tokens); i++ {
+		value, ok := parseQuantity(tokens[i])
+		if !ok {
+			break
+		}
+		ingredient.Value += value
+		ingredient.HasAmount = true
+	}
+
+	// Followed by an optional unit, possibly two words long (e.g. "fl oz")
+	if ingredient.HasAmount && i < len(tokens) {
+		if i+1 < len(tokens) && utils.IsUnit(tokens[i]+" "+tokens[i+1]) {
+			ingredient.Unit = utils.NormalizeUnit(tokens[i] + " " + tokens[i+1])
+			i += 2
+		} else if utils.IsUnit(tokens[i]) {
+			ingredient.Unit = utils.NormalizeUnit(tokens[i])
+			i++
+		} else if unit := strings.TrimSuffix(strings.ToLower(tokens[i]), "s"); utils.Contains(unitsCount, unit) {
+			ingredient.Unit = unit
+			i++
+		}
+	}
+	if ingredient.HasAmount && ingredient.Unit == "" {
+		ingredient.Unit = "count"
+	}
+	if i < len(tokens) && strings.ToLower(tokens[i]) == "of" {
+		i++
+	}
+
+	// Drop preparation notes and parentheticals
+	name := strings.Join(tokens[i:], " ")
+	if j := strings.Index(name, ","); j >= 0 {
+		name = name[:j]
+	}
+	for {
+		open := strings.Index(name, "(")
+		if open < 0 {
+			break
+		}
+		end := strings.Index(name[open:], ")")
+		if end < 0 {
+			name = name[:open]
+			break
+		}
+		name = name[:open] + name[open+end+1:]
+	}
+	ingredient.Name = strings.ToLower(strings.Join(strings.Fields(name), " "))
+	return ingredient
+}
+
+// Map the lowercase names and aliases of known ingredients to their canonical name
+func ingredientNames(ctx context.Context) (map[string]string, error) {
+	opts := options.Find()
+	opts.SetProjection(bson.M{"aliases": 1, "name": 1})
+
+	documents, err := configuration.Mongo.FindDocuments(ctx, config.MongoCollectionIngredients, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	return ingredientNameIndex(documents), nil
+}
+
+// Lowercased names and aliases to the ingredient name they refer to
+func ingredientNameIndex(documents []bson.M) map[string]string {
+	names := map[string]string{}
+	for _, document := range documents {
+		name, ok := document["name"].(string)
+		if !ok {
+			continue
+		}
+		names[strings.ToLower(name)] = name
+		for _, alias := range stringList(document["aliases"]) {
+			names[alias] = name
+		}
+	}
+	return names
+}
+
+func matchIngredient(name string, names map[string]string) (string, bool) {
+	if match, ok := names[name]; ok {
+		return match, true
+	}
+
+	// Plurals (e.g. "eggs", "tomatoes")
+	for _, suffix := range []string{"es", "s"} {
+		if match, ok := names[strings.TrimSuffix(name, suffix)]; ok && strings.HasSuffix(name, suffix) {
+			return match, true
+		}
+	}
+
+	// Otherwise the longest known name mentioned (e.g. "large eggs" -> "egg"), the first alphabetically on a tie
+	best := ""
+	padded := " " + name + " "
+	for known := range names {
+		for _, form := range []string{known, known + "s", known + "es"} {
+			if strings.Contains(padded, " "+form+" ") && (len(known) > len(best) || (len(known) == len(best) && known < best)) {
+				best = known
+			}
+		}
+	}
+	if best != "" {
+		return names[best], true
+	}
+	return "", false
+}
+
+// Turn ingredient lines into recipe ingredients, reporting lines that matched no known ingredient
+func importIngredients(lines []string, names map[string]string) (primitive.A, []string) {
+	ingredients := primitive.A{}
+	unmatched := []string{}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		parsed := parseIngredientLine(line)
+		if match, ok := matchIngredient(parsed.Name, names); ok {
+			parsed.Name = match
+		} else {
+			unmatched = append(unmatched, line)
+		}
+
+		if parsed.Name == "" {
+			continue
+		} else if parsed.HasAmount {
+			ingredients = append(ingredients, bson.M{"name": parsed.Name, "amount": bson.M{"value": parsed.Value, "unit": parsed.Unit}})
+		} else {
+			ingredients = append(ingredients, parsed.Name)
+		}
+	}
+	return ingredients, unmatched
 }
@@ -2,13 +2,18 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/tyler-cromwell/forage/config"
 	"github.com/tyler-cromwell/forage/tests/mocks"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -29,6 +34,8 @@ func TestLibrary(t *testing.T) {
 			},
 		}
 
+		mcAmount := mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsIngredientAmount}
+
 		cases := []struct {
 			mock   mocks.MockMongo
 			recipe primitive.M
@@ -38,6 +45,11 @@ func TestLibrary(t *testing.T) {
 			{mc, primitive.M{"_id": "hello"}, false, nil},
 			{mcErr, primitive.M{"_id": "hello", "ingredients": []interface{}{}}, false, fmt.Errorf(errorBasic)},
 			{mc, primitive.M{"_id": "hello", "ingredients": []interface{}{}}, true, nil},
+			{mc, primitive.M{"_id": "hello", "ingredients": []interface{}{"hello"}}, false, nil},
+			{mcAmount, primitive.M{"_id": "hello", "ingredients": []interface{}{"hello"}}, true, nil},
+			{mcAmount, primitive.M{"_id": "hello", "ingredients": []interface{}{primitive.M{"name": "hello", "amount": primitive.M{"value": 1.5, "unit": "pound"}}}}, true, nil},
+			{mcAmount, primitive.M{"_id": "hello", "ingredients": []interface{}{primitive.M{"name": "hello", "amount": primitive.M{"value": 2, "unit": "pounds"}}}}, false, nil},
+			{mcAmount, primitive.M{"_id": "hello", "ingredients": []interface{}{primitive.M{"name": "hello", "amount": primitive.M{"value": 1, "unit": "cup"}}}}, false, nil},
 		}
 		for _, c := range cases {
 			configuration.Mongo = &c.mock
@@ -46,6 +58,492 @@ func TestLibrary(t *testing.T) {
 				t.Errorf("isCookable(\"%+v\"), got (\"%t\", \"%s\"), want (\"%t\", \"%s\")", c.recipe, got, err, c.want, c.err)
 			}
 		}
+
+		// Amounts that can't be converted are reported
+		hook := test.NewGlobal()
+		defer hook.Reset()
+		configuration.Mongo = &mcAmount
+		isCookable(ctx, &primitive.M{"_id": "hello", "ingredients": []interface{}{primitive.M{"name": "hello", "amount": primitive.M{"value": 1, "unit": "cup"}}}})
+		warned := false
+		for _, entry := range hook.AllEntries() {
+			if entry.Level == logrus.WarnLevel && entry.Message == "Failed to convert units" {
+				warned = entry.Data["ingredient"] == "hello" && entry.Data["from"] == "ounces" && entry.Data["to"] == "cup"
+			}
+		}
+		if !warned {
+			t.Errorf("isCookable, got no warning for (ounces, cup)")
+		}
+	})
+
+	t.Run("scaleRecipe", func(t *testing.T) {
+		cases := []struct {
+			recipe   primitive.M
+			servings int
+			want     float64
+			amount   float64
+		}{
+			{primitive.M{"servings": int32(2), "ingredients": primitive.A{primitive.M{"name": "hello", "amount": primitive.M{"value": 1.5, "unit": "cup"}}}}, 6, 3, 4.5},
+			{primitive.M{"servings": 4.0, "ingredients": primitive.A{"hello", primitive.M{"name": "hello", "amount": primitive.M{"value": int32(2), "unit": "cup"}}}}, 2, 0.5, 1},
+			{primitive.M{"ingredients": primitive.A{primitive.M{"name": "hello", "amount": primitive.M{"value": 2.0, "unit": "cup"}}}}, 6, 1, 2},
+		}
+		for _, c := range cases {
+			got := scaleRecipe(&c.recipe, c.servings)
+			ingredients := recipeIngredients(&c.recipe)
+			ingredient, _ := parseRecipeIngredient(ingredients[len(ingredients)-1])
+			if got != c.want || ingredient.Value != c.amount {
+				t.Errorf("scaleRecipe(\"%+v\", %d), got (%f, %f), want (%f, %f)", c.recipe, c.servings, got, ingredient.Value, c.want, c.amount)
+			}
+		}
+	})
+
+	t.Run("stringList", func(t *testing.T) {
+		cases := []interface{}{
+			[]string{"Gluten", "DAIRY"},
+			primitive.A{"Gluten", "DAIRY"},
+			[]interface{}{"Gluten", 1, "DAIRY"},
+		}
+		for _, c := range cases {
+			got := stringList(c)
+			if strings.Join(got, ",") != "gluten,dairy" {
+				t.Errorf("stringList(%v), got (%v), want (%v)", c, got, []string{"gluten", "dairy"})
+			}
+		}
+	})
+
+	t.Run("recipeTagsUnknown", func(t *testing.T) {
+		configuration.Mongo = &mocks.MockMongo{OverrideFindManyDocuments: OverrideFindManyDocumentsTaggedUnknown}
+		recipe := primitive.M{"ingredients": primitive.A{"Bread", "Lettuce", "Peanut Butter", "Salt"}}
+		allergens, _, unknown, err := recipeTags(context.Background(), &recipe)
+		if err != nil || strings.Join(allergens, ",") != "gluten" || strings.Join(unknown, ",") != "Peanut Butter,Salt" {
+			t.Errorf("recipeTags(%v), got (%v, %v, %v), want (%v, %v, %v)", recipe, allergens, unknown, err, []string{"gluten"}, []string{"Peanut Butter", "Salt"}, nil)
+		}
+	})
+
+	t.Run("consumeIngredients", func(t *testing.T) {
+		now := int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond)
+		stock := []bson.M{
+			{"_id": "expired", "amount": bson.M{"value": 2.0, "unit": "cup"}, "expirationDate": now - 1000, "haveStocked": true, "name": "Milk"},
+			{"_id": "fresh", "amount": bson.M{"value": 2.0, "unit": "cup"}, "expirationDate": now + 100000, "haveStocked": true, "name": "Milk"},
+		}
+		updated := []interface{}{}
+		configuration.Mongo = &mocks.MockMongo{
+			OverrideFindManyDocuments: func(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+				after := filter["$and"].([]bson.M)[0]["expirationDate"].(bson.M)["$gt"].(int64)
+				found := []bson.M{}
+				for _, document := range stock {
+					if document["expirationDate"].(int64) > after {
+						found = append(found, document)
+					}
+				}
+				return found, nil
+			},
+			OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+				updated = append(updated, filter[0].Value)
+				return 1, 1, nil
+			},
+		}
+
+		recipe := primitive.M{"ingredients": primitive.A{primitive.M{"name": "Milk", "amount": primitive.M{"value": 1, "unit": "cup"}}}}
+		err := consumeIngredients(context.Background(), &recipe)
+		if err != nil || len(updated) != 1 || updated[0] != "fresh" {
+			t.Errorf("consumeIngredients(%v), got (%v, %v), want (%v, %v)", recipe, updated, err, []interface{}{"fresh"}, nil)
+		}
+	})
+
+	t.Run("matchPurchase", func(t *testing.T) {
+		ingredients := []bson.M{
+			{"_id": "stocked", "haveStocked": true, "name": "Milk"},
+			{"_id": "first", "haveStocked": false, "name": "Milk"},
+			{"_id": "second", "haveStocked": false, "name": "Milk"},
+		}
+		names := ingredientNameIndex(ingredients)
+
+		matched := []bson.M{}
+		for _, want := range []string{"first", "second", "stocked"} {
+			ingredient, ok := matchPurchase(purchase{Name: "milk"}, ingredients, names, matched)
+			if !ok || ingredient["_id"] != want {
+				t.Errorf("matchPurchase(milk, %v), got (%v, %v), want (%v, %v)", matched, ingredient["_id"], ok, want, true)
+			}
+			matched = append(matched, ingredient)
+		}
+	})
+
+	t.Run("restockIngredients", func(t *testing.T) {
+		milk := bson.M{"_id": "milk", "amount": bson.M{"value": 0.5, "unit": "gallon"}, "haveStocked": true, "name": "Milk"}
+		updates := []bson.M{}
+		configuration.Mongo = &mocks.MockMongo{
+			OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+				updates = append(updates, update.(bson.M))
+				if len(updates) == 3 {
+					return 0, 0, fmt.Errorf(errorBasic)
+				}
+				return 1, 1, nil
+			},
+		}
+
+		// Both purchases add to what's already in the fridge
+		matched := []bson.M{milk, milk, {"_id": "flour", "name": "Flour"}}
+		purchases := []purchase{{Name: "milk", Quantity: 1, Unit: "gallon"}, {Name: "milk", Quantity: 2, Unit: "quart"}, {Name: "flour"}}
+		_, err := restockIngredients(context.Background(), matched, purchases)
+		if err == nil || len(updates) != 4 {
+			t.Fatalf("restockIngredients(%v), got (%d updates, %v), want (%d updates, %v)", purchases, len(updates), err, 4, errorBasic)
+		}
+		for i, want := range []float64{1.5, 2} {
+			if amount := updates[i]["$set"].(bson.M)["amount"].(bson.M); amount["value"] != want || amount["unit"] != "gallon" {
+				t.Errorf("restockIngredients(%v), got amount %v, want %v gallon", purchases, amount, want)
+			}
+		}
+
+		// Rolling back restores the amount from before the trip, once
+		if amount := updates[3]["$set"].(bson.M)["amount"]; amount.(bson.M)["value"] != 0.5 {
+			t.Errorf("restockIngredients(%v), got rollback amount %v, want %v", purchases, amount, 0.5)
+		}
+	})
+
+	t.Run("computeNutrition", func(t *testing.T) {
+		templates := map[string]primitive.M{
+			"flour": {"per": primitive.M{"value": 100, "unit": "gram"}, "calories": 364},
+			"eggs":  {"per": primitive.M{"value": 1, "unit": "count"}, "calories": 72},
+		}
+		ingredients := []recipeIngredient{
+			{Name: "flour", Value: 0.5, Unit: "kilogram", HasAmount: true},
+			{Name: "eggs", Value: 2, Unit: "count", HasAmount: true},
+			{Name: "salt"},
+		}
+
+		totals, missing := computeNutrition(ingredients, templates)
+		if totals["calories"] != 1964 || len(missing) != 1 || missing[0] != "salt" {
+			t.Errorf("computeNutrition(%+v), got (%v, %v), want (%v, %v)", ingredients, totals, missing, 1964, []string{"salt"})
+		}
+	})
+
+	t.Run("estimateCost", func(t *testing.T) {
+		price := primitive.M{"amount": primitive.M{"value": 2, "unit": "pound"}, "price": 8.0}
+		cases := []struct {
+			price     primitive.M
+			value     float64
+			unit      string
+			hasAmount bool
+			cost      float64
+			ok        bool
+		}{
+			{price, 0.5, "pound", true, 2, true},
+			{price, 16, "ounce", true, 4, true},
+			{price, 0, "", false, 8, true},
+			{price, 1, "cup", true, 0, false},
+			{primitive.M{"price": 8.0}, 1, "pound", true, 0, false},
+			{nil, 0, "", false, 0, false},
+		}
+		for _, c := range cases {
+			cost, ok := estimateCost(c.price, c.value, c.unit, c.hasAmount)
+			if cost != c.cost || ok != c.ok {
+				t.Errorf("estimateCost(%v, %v, %s, %v), got (%v, %v), want (%v, %v)", c.price, c.value, c.unit, c.hasAmount, cost, ok, c.cost, c.ok)
+			}
+		}
+	})
+
+	t.Run("parseIngredientLine", func(t *testing.T) {
+		cases := []struct {
+			line string
+			want recipeIngredient
+		}{
+			{"1 1/2 cups all-purpose flour, sifted", recipeIngredient{Name: "all-purpose flour", Value: 1.5, Unit: "cup", HasAmount: true}},
+			{"½ tsp salt", recipeIngredient{Name: "salt", Value: 0.5, Unit: "teaspoon", HasAmount: true}},
+			{"2-3 large eggs (room temperature)", recipeIngredient{Name: "large eggs", Value: 2, Unit: "count", HasAmount: true}},
+			{"3 cloves of garlic", recipeIngredient{Name: "garlic", Value: 3, Unit: "clove", HasAmount: true}},
+			{"8 fl oz milk", recipeIngredient{Name: "milk", Value: 8, Unit: "fluid ounce", HasAmount: true}},
+			{"Salt and pepper to taste", recipeIngredient{Name: "salt and pepper to taste"}},
+		}
+		for _, c := range cases {
+			got := parseIngredientLine(c.line)
+			if got != c.want {
+				t.Errorf("parseIngredientLine(\"%s\"), got (%+v), want (%+v)", c.line, got, c.want)
+			}
+		}
+	})
+
+	t.Run("matchIngredient", func(t *testing.T) {
+		names := map[string]string{"all-purpose flour": "Flour", "flour": "Flour", "egg": "Egg", "tomato": "Tomato", "lime": "Lime", "salt": "Salt"}
+		cases := []struct {
+			name  string
+			want  string
+			found bool
+		}{
+			{"all-purpose flour", "Flour", true},
+			{"tomatoes", "Tomato", true},
+			{"large eggs", "Egg", true},
+			{"eggplant", "", false},
+		}
+		for _, c := range cases {
+			got, found := matchIngredient(c.name, names)
+			if got != c.want || found != c.found {
+				t.Errorf("matchIngredient(\"%s\"), got (\"%s\", %t), want (\"%s\", %t)", c.name, got, found, c.want, c.found)
+			}
+		}
+
+		// Names of the same length don't depend on map order
+		for i := 0; i < 20; i++ {
+			if got, _ := matchIngredient("salt and lime", names); got != "Lime" {
+				t.Fatalf("matchIngredient(\"salt and lime\"), got (\"%s\"), want (\"%s\")", got, "Lime")
+			}
+		}
+	})
+
+	t.Run("parseISODuration", func(t *testing.T) {
+		cases := []struct {
+			value string
+			want  int
+			ok    bool
+		}{
+			{"PT1H30M", 90, true},
+			{"P0DT0H20M", 20, true},
+			{"pt45m", 45, true},
+			{"PT", 0, false},
+			{"20 minutes", 0, false},
+		}
+		for _, c := range cases {
+			got, ok := parseISODuration(c.value)
+			if got != c.want || ok != c.ok {
+				t.Errorf("parseISODuration(\"%s\"), got (%d, %t), want (%d, %t)", c.value, got, ok, c.want, c.ok)
+			}
+		}
+	})
+
+	t.Run("parseMinutes", func(t *testing.T) {
+		cases := []struct {
+			value interface{}
+			want  int
+			ok    bool
+		}{
+			{"PT20M", 20, true},
+			{"1 hr 30 mins", 90, true},
+			{"45", 45, true},
+			{30.0, 30, true},
+			{"soon", 0, false},
+			{nil, 0, false},
+		}
+		for _, c := range cases {
+			got, ok := parseMinutes(c.value)
+			if got != c.want || ok != c.ok {
+				t.Errorf("parseMinutes(%v), got (%d, %t), want (%d, %t)", c.value, got, ok, c.want, c.ok)
+			}
+		}
+	})
+
+	t.Run("lifespanExpiration", func(t *testing.T) {
+		from := time.Date(2021, time.November, 7, 20, 0, 0, 0, time.UTC)
+		ingredient := bson.M{"lifespan": primitive.M{
+			"freezer":      primitive.M{"unit": "month", "value": int32(8)},
+			"refrigerator": primitive.M{"unit": "days", "value": int32(2)},
+		}}
+		cases := []struct {
+			storeIn string
+			want    time.Time
+			ok      bool
+		}{
+			{"refrigerator", time.Date(2021, time.November, 9, 20, 0, 0, 0, time.UTC), true},
+			{"freezer", time.Date(2022, time.July, 7, 20, 0, 0, 0, time.UTC), true},
+			{"pantry", time.Time{}, false},
+		}
+		for _, c := range cases {
+			got, ok := lifespanExpiration(ingredient, c.storeIn, from)
+			want := int64(0)
+			if c.ok {
+				want = int64(c.want.UnixNano()) / int64(time.Millisecond)
+			}
+			if got != want || ok != c.ok {
+				t.Errorf("lifespanExpiration(%s), got (%d, %t), want (%d, %t)", c.storeIn, got, ok, want, c.ok)
+			}
+		}
+	})
+
+	t.Run("frozenIngredients", func(t *testing.T) {
+		ingredients := []bson.M{
+			{"name": "Beef", "storeIn": "freezer"},
+			{"name": "Buns", "storeIn": "pantry"},
+			{"name": "Chicken", "storeIn": "freezer"},
+			{"name": "Chicken", "storeIn": "refrigerator"},
+			{"name": "Peas", "storeIn": "freezer"},
+		}
+		usedBy := map[string][]string{"Beef": {"Hamburgers", "Stew"}, "Buns": {"Hamburgers"}, "Chicken": {"Stew"}, "Peas": {"Stew"}}
+		want := "Move to the fridge tonight for tomorrow's meals: Beef (Hamburgers, Stew); Peas (Stew)"
+		if got := composeThawMessage(frozenIngredients(ingredients, usedBy)); got != want {
+			t.Errorf("composeThawMessage, got (%s), want (%s)", got, want)
+		}
+	})
+
+	t.Run("rolloverShoppingList", func(t *testing.T) {
+		mongo, trelloClient := configuration.Mongo, configuration.ShoppingList
+		defer func() { configuration.Mongo, configuration.ShoppingList = mongo, trelloClient }()
+
+		var history []interface{}
+		archived := false
+		configuration.Mongo = &mocks.MockMongo{OverrideInsertManyDocuments: func(ctx context.Context, collection string, docs []interface{}) error {
+			history = docs
+			return nil
+		}}
+		configuration.ShoppingList = &mocks.MockTrello{
+			OverrideGetShoppingListItems: OverrideGetShoppingListItemsRollover,
+			OverrideArchiveShoppingList:  func(list *config.ShoppingList) error { archived = true; return nil },
+		}
+
+		// Still due
+		now := time.Date(2021, time.November, 7, 12, 0, 0, 0, time.UTC)
+		due := now.Add(time.Hour)
+		card := config.ShoppingList{Name: "Shopping List", URL: "www.mock.url.com", Due: &due}
+		rolled, carried, err := rolloverShoppingList(context.Background(), &card, now)
+		if rolled || carried != nil || err != nil || archived || history != nil {
+			t.Errorf("rolloverShoppingList(due), got (%t, %v, %v)", rolled, carried, err)
+		}
+
+		// Overdue
+		rolled, carried, err = rolloverShoppingList(context.Background(), &card, due.Add(time.Minute))
+		want := "[map[_id:_ archived:1636290060000 due:1636290000000 items:[map[checked:false name:Milk (expiring)] map[checked:false name:Paper Towels section:Target - Household] map[checked:true name:Eggs (expired)]] name:Shopping List reason:overdue url:www.mock.url.com]]"
+		if len(history) == 1 {
+			history[0].(bson.M)["_id"] = "_"
+		}
+		if !rolled || err != nil || !archived || fmt.Sprint(history) != want {
+			t.Errorf("rolloverShoppingList(overdue), got (%t, %v, %v, %v), want history (%s)", rolled, carried, err, history, want)
+		}
+
+		got := carryOver([]config.ShoppingItem{{Name: "Milk (expired)", Section: "Dairy"}}, carried)
+		if fmt.Sprint(got) != "[{Milk (expired) false Dairy} {Paper Towels false Target - Household}]" {
+			t.Errorf("carryOver(%v), got (%v)", carried, got)
+		}
+	})
+
+	t.Run("groceryItem", func(t *testing.T) {
+		cases := []struct {
+			document bson.M
+			stage    string
+			want     config.ShoppingItem
+		}{
+			{bson.M{"name": "Milk"}, "expired", config.ShoppingItem{Name: "Milk (expired)", Section: "Groceries"}},
+			{bson.M{"name": "Milk", "store": "Costco", "aisle": "Dairy"}, "expiring", config.ShoppingItem{Name: "Milk (expiring)", Section: "Costco - Dairy"}},
+			{bson.M{"name": "Bread", "section": "Bakery"}, "expiring", config.ShoppingItem{Name: "Bread (expiring)", Section: "Bakery"}},
+			{bson.M{"name": "Yogurt", "store": "Aldi", "attributes": map[string]string{"brand": "Chobani", "flavor": "Peach"}}, "expired", config.ShoppingItem{Name: "Yogurt (Chobani, Peach, expired)", Section: "Aldi"}},
+		}
+
+		for _, c := range cases {
+			got := groceryItem(c.document, c.stage)
+			if got != c.want {
+				t.Errorf("groceryItem(%v, \"%s\"), got (%v), want (%v)", c.document, c.stage, got, c.want)
+			}
+		}
+	})
+
+	t.Run("validTrelloSignature", func(t *testing.T) {
+		secret, callback := configuration.TrelloSecret, configuration.TrelloCallback
+		defer func() { configuration.TrelloSecret, configuration.TrelloCallback = secret, callback }()
+		configuration.TrelloSecret = "secret"
+		configuration.TrelloCallback = "https://forage.example/webhooks/trello"
+
+		body := []byte("{\"action\":{}}")
+		cases := []struct {
+			signature string
+			want      bool
+		}{
+			{"6AJ2scsGjNxRzm4fuRy1X8ZON0w=", true},
+			{"", false},
+			{"bm9wZQ==", false},
+		}
+
+		for _, c := range cases {
+			if got := validTrelloSignature(body, c.signature); got != c.want {
+				t.Errorf("validTrelloSignature(\"%s\"), got (%t), want (%t)", c.signature, got, c.want)
+			}
+		}
+	})
+
+	t.Run("calendarFeed", func(t *testing.T) {
+		loc, _ := time.LoadLocation("America/New_York")
+		now := time.Date(2021, 11, 1, 12, 0, 0, 0, time.UTC)
+		ingredients := []bson.M{
+			{"_id": "milk", "name": "Milk", "expirationDate": int64(1636254000000), "storeIn": "refrigerator"},
+			{"_id": "salt", "name": "Salt"},
+			{"_id": "honey", "name": "Honey", "expirationDate": int64(0), "storeIn": "pantry"},
+		}
+		plans := []bson.M{
+			{"_id": "plan", "date": 1636329600000.0, "recipes": primitive.A{"Pancakes", primitive.M{"name": "Salad, Greek", "servings": 2}}},
+			{"_id": "empty", "date": 1636329600000.0, "recipes": primitive.A{}},
+		}
+
+		want := strings.Join([]string{
+			"BEGIN:VCALENDAR",
+			"VERSION:2.0",
+			"PRODID:-//Forage//Expirations//EN",
+			"CALSCALE:GREGORIAN",
+			"METHOD:PUBLISH",
+			"X-WR-CALNAME:Forage",
+			"BEGIN:VEVENT",
+			"UID:milk-expiration@forage",
+			"DTSTAMP:20211101T120000Z",
+			"DTSTART;VALUE=DATE:20211106",
+			"DTEND;VALUE=DATE:20211107",
+			"SUMMARY:Milk expires",
+			"DESCRIPTION:Stored in refrigerator",
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+			"BEGIN:VEVENT",
+			"UID:plan-mealplan@forage",
+			"DTSTAMP:20211101T120000Z",
+			"DTSTART;VALUE=DATE:20211107",
+			"DTEND;VALUE=DATE:20211108",
+			"SUMMARY:Planned: Pancakes\\, Salad\\, Greek",
+			"TRANSP:TRANSPARENT",
+			"END:VEVENT",
+			"END:VCALENDAR",
+			"",
+		}, "\r\n")
+
+		got := calendarFeed(ingredients, plans, loc, now)
+		if got != want {
+			t.Errorf("calendarFeed(), got (%q), want (%q)", got, want)
+		}
+	})
+
+	t.Run("cooklang", func(t *testing.T) {
+		source := ">> title: Eggs\n>> servings: 2\n\nCrack @eggs{3} into a #frying pan{} with @olive oil{1%tbsp}. -- keep the yolks whole\n\nFry for ~{4%minutes}, season with @salt and serve.\n"
+		exported := ">> title: Eggs\n>> servings: 2\n\nCrack @eggs{3} into a #frying pan{} with @olive oil{1%tablespoon}.\n\nFry for ~{4%minutes}, season with @salt{} and serve.\n"
+		names := map[string]string{"egg": "Egg", "salt": "Salt"}
+
+		recipe, unmatched := recipeFromCooklang(source, "", names)
+		steps := recipe["steps"].(primitive.A)
+		if len(unmatched) != 1 || unmatched[0] != "1 tbsp olive oil" || len(steps) != 2 || steps[1].(bson.M)["duration"] != 240 {
+			t.Errorf("recipeFromCooklang(\"%s\"), got (%v, %v)", source, recipe, unmatched)
+		}
+
+		got := recipeToCooklang(&recipe)
+		if got != exported {
+			t.Errorf("recipeToCooklang(%v), got (\"%s\"), want (\"%s\")", recipe, got, exported)
+		}
+
+		again, _ := recipeFromCooklang(got, "", names)
+		if fmt.Sprint(again) != fmt.Sprint(recipe) {
+			t.Errorf("recipeFromCooklang(\"%s\"), got (%v), want (%v)", got, again, recipe)
+		}
+	})
+
+	t.Run("grocy", func(t *testing.T) {
+		var export grocyExport
+		json.Unmarshal([]byte(grocyExportBasic), &export)
+
+		ingredients := ingredientsFromGrocy(export, time.UTC, 1337)
+		want := "[map[amount:map[unit:pound value:1.5] expirationDate:1636243200000 haveStocked:true name:Chicken stockedDate:1337 storeIn:freezer updated:1337] " +
+			"map[amount:map[unit:pound value:2] expirationDate:0 haveStocked:true name:Chicken stockedDate:1337 storeIn:refrigerator updated:1337] " +
+			"map[expirationDate:0 haveStocked:false name:Milk stockedDate:0 storeIn:refrigerator updated:1337]]"
+		if got := fmt.Sprint(ingredients); got != want {
+			t.Errorf("ingredientsFromGrocy(%v), got (%s), want (%s)", export, got, want)
+		}
+
+		exported, _ := json.Marshal(ingredientsToGrocy(ingredients, time.UTC))
+		want = "{\"locations\":[{\"id\":1,\"is_freezer\":0,\"name\":\"Pantry\"},{\"id\":2,\"is_freezer\":0,\"name\":\"Refrigerator\"},{\"id\":3,\"is_freezer\":1,\"name\":\"Freezer\"}]," +
+			"\"products\":[{\"id\":1,\"location_id\":3,\"name\":\"Chicken\",\"qu_id_stock\":1},{\"id\":2,\"location_id\":2,\"name\":\"Milk\",\"qu_id_stock\":2}]," +
+			"\"quantity_units\":[{\"id\":1,\"name\":\"pound\"},{\"id\":2,\"name\":\"count\"}]," +
+			"\"stock\":[{\"amount\":1.5,\"best_before_date\":\"2021-11-07\",\"location_id\":3,\"product_id\":1},{\"amount\":2,\"best_before_date\":\"2999-12-31\",\"location_id\":2,\"product_id\":1}]}"
+		if string(exported) != want {
+			t.Errorf("ingredientsToGrocy(%v), got (%s), want (%s)", ingredients, exported, want)
+		}
 	})
 
 	// Reverse logrus output change
@@ -38,12 +38,31 @@ func ListenAndServe(ctx context.Context, c *config.Configuration) {
 		log.WithError(err).Fatal("Failed to schedule expriation watch job")
 		return
 	} else {
-		configuration.Scheduler.StartAsync()
 		log.Info("Expiration watch job scheduled")
 	}
 
+	// Launch job to remind the evening before a meal to thaw its ingredients
+	_, err = configuration.Scheduler.Every(1).Day().At(configuration.ThawTime).Do(checkThawing)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to schedule thaw reminder job")
+		return
+	} else {
+		log.Info("Thaw reminder job scheduled")
+	}
+
+	// Launch job to restock what's been checked off the shopping list
+	_, err = configuration.Scheduler.Every(configuration.SyncInterval).Do(syncShoppingList)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to schedule shopping list sync job")
+		return
+	} else {
+		configuration.Scheduler.StartAsync()
+		log.Info("Shopping list sync job scheduled")
+	}
+
 	// Define route actions/methods
 	router := mux.NewRouter().StrictSlash(true)
+	router.HandleFunc("/calendar.ics", getCalendar).Methods("GET")
 	router.HandleFunc("/configure", getConfiguration).Methods("GET")
 	router.HandleFunc("/configure", putConfiguration).Methods("PUT")
 	router.HandleFunc("/cookable", getCookable).Methods("GET")
@@ -55,6 +74,39 @@ func ListenAndServe(ctx context.Context, c *config.Configuration) {
 	router.HandleFunc("/documents/{collection}", deleteManyDocuments).Methods("DELETE")
 	router.HandleFunc("/expiring", getExpiring).Methods("GET")
 	router.HandleFunc("/expired", getExpired).Methods("GET")
+	router.HandleFunc("/ingredients/export/grocy", getGrocyExport).Methods("GET")
+	router.HandleFunc("/ingredients/import/grocy", postGrocyImport).Methods("POST")
+	router.HandleFunc("/ingredients/{id}/move", postIngredientMove).Methods("POST")
+	router.HandleFunc("/locations/{id}/contents", getLocationContents).Methods("GET")
+	router.HandleFunc("/prices", getPrices).Methods("GET")
+	router.HandleFunc("/prices", postPrices).Methods("POST")
+	router.HandleFunc("/recipes/import", postRecipeImport).Methods("POST")
+	router.HandleFunc("/recipes/import/cooklang", postCooklangImport).Methods("POST")
+	router.HandleFunc("/recipes/import/mealie", postMealieImport).Methods("POST")
+	router.HandleFunc("/recipes/import/paprika", postPaprikaImport).Methods("POST")
+	router.HandleFunc("/recipes/import/tandoor", postTandoorImport).Methods("POST")
+	router.HandleFunc("/recipes/{id}/cook", postCook).Methods("POST")
+	router.HandleFunc("/recipes/{id}/cooklang", getCooklangExport).Methods("GET")
+	router.HandleFunc("/recipes/{id}/history", postCookLog).Methods("POST")
+	router.HandleFunc("/recipes/{id}/sessions", postSession).Methods("POST")
+	router.HandleFunc("/reports/nutrition", getNutritionReport).Methods("GET")
+	router.HandleFunc("/reports/waste", getWasteReport).Methods("GET")
+	router.HandleFunc("/sessions/{id}", getSession).Methods("GET")
+	router.HandleFunc("/sessions/{id}", deleteSession).Methods("DELETE")
+	router.HandleFunc("/sessions/{id}/advance", postSessionAdvance).Methods("POST")
+	router.HandleFunc("/sessions/{id}/events", getSessionEvents).Methods("GET")
+	router.HandleFunc("/sessions/{id}/timers", postSessionTimer).Methods("POST")
+	router.HandleFunc("/shopping-list", getShoppingList).Methods("GET")
+	router.HandleFunc("/shopping-list", postShoppingList).Methods("POST")
+	router.HandleFunc("/shopping-list", patchShoppingList).Methods("PATCH")
+	router.HandleFunc("/shopping-trips", postShoppingTrip).Methods("POST")
+	router.HandleFunc("/suggestions", getSuggestions).Methods("GET")
+	if configuration.TrelloSecret != "" {
+		router.HandleFunc("/webhooks/trello", headTrelloWebhook).Methods("HEAD")
+		router.HandleFunc("/webhooks/trello", postTrelloWebhook).Methods("POST")
+	} else {
+		log.Info("Trello webhook disabled without an API secret")
+	}
 
 	// Specify common fields
 	log = log.WithFields(logrus.Fields{"socket": configuration.ListenSocket})
@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"time"
 
-	"github.com/adlio/trello"
 	"github.com/tyler-cromwell/forage/config"
 	"github.com/tyler-cromwell/forage/utils"
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,8 +18,45 @@ import (
 const bodyCookable = "{\"ingredients\":[\"hello\"],\"isCookable\":true}"
 const bodyCookables = "[{\"ingredients\":[\"hello\"],\"isCookable\":true}]"
 const bodyEmpty = "[]"
+const bodyCookableServings2 = "{\"ingredients\":[{\"amount\":{\"unit\":\"pound\",\"value\":1},\"name\":\"hello\"}],\"isCookable\":true,\"servings\":2}"
+const bodyCookableServings4 = "{\"ingredients\":[{\"amount\":{\"unit\":\"pound\",\"value\":2},\"name\":\"hello\"}],\"isCookable\":false,\"servings\":4}"
+const bodyCookablesServings2 = "[" + bodyCookableServings2 + "]"
+const bodyNutritionRecipe = "{\"ingredients\":[{\"amount\":{\"unit\":\"kilogram\",\"value\":1},\"name\":\"hello\"}],\"isCookable\":false,\"name\":\"hello\",\"nutrition\":{\"missing\":[],\"perServing\":{\"calories\":250,\"protein\":10},\"total\":{\"calories\":500,\"protein\":20}},\"servings\":2}"
+const bodyNutritionReport = "{\"days\":[{\"date\":1636243200000,\"missing\":[],\"recipes\":[\"hello\"],\"total\":{\"calories\":250,\"protein\":10}}],\"inventory\":{\"missing\":[],\"total\":{\"calories\":100,\"protein\":4}}}"
+const bodyNutritionReportMissing = "{\"days\":[{\"date\":1636243200000,\"missing\":[\"hello\"],\"recipes\":[],\"total\":{}}],\"inventory\":{\"missing\":[],\"total\":{\"calories\":100,\"protein\":4}}}"
+const bodyCostRecipe = "{\"cost\":{\"missing\":[],\"perServing\":1.5,\"total\":3},\"ingredients\":[{\"amount\":{\"unit\":\"kilogram\",\"value\":1},\"name\":\"hello\"}],\"isCookable\":false,\"name\":\"hello\",\"servings\":2}"
+const bodyPrices = "[{\"amount\":{\"unit\":\"kilogram\",\"value\":2},\"date\":1636243200000,\"name\":\"hello\",\"price\":6,\"source\":\"receipt\",\"store\":\"Aldi\"},{\"amount\":{\"unit\":\"kilogram\",\"value\":2},\"date\":1633651200000,\"name\":\"hello\",\"price\":8,\"source\":\"trip\"}]"
+const bodyUnpricedRecipe = "{\"ingredients\":[{\"amount\":{\"unit\":\"kilogram\",\"value\":1},\"name\":\"hello\"}],\"isCookable\":false,\"name\":\"hello\",\"servings\":2}"
+const bodyCostRecipes = "[{\"cost\":{\"missing\":[],\"perServing\":1.5,\"total\":3},\"ingredients\":[{\"amount\":{\"unit\":\"kilogram\",\"value\":1},\"name\":\"hello\"}],\"isCookable\":false,\"name\":\"hello\",\"servings\":2},{\"cost\":{\"missing\":[\"caviar\"],\"perServing\":0,\"total\":0},\"ingredients\":[\"caviar\"],\"isCookable\":true,\"name\":\"caviar\"}]"
+const bodyUnpricedRecipes = "[{\"ingredients\":[{\"amount\":{\"unit\":\"kilogram\",\"value\":1},\"name\":\"hello\"}],\"isCookable\":false,\"name\":\"hello\",\"servings\":2},{\"ingredients\":[\"caviar\"],\"isCookable\":true,\"name\":\"caviar\"}]"
+const bodyReceipt = "[{\"amount\":{\"unit\":\"gallon\",\"value\":1},\"date\":1636243200000,\"name\":\"Milk\",\"price\":3.49,\"source\":\"receipt\",\"store\":\"Aldi\"},{\"date\":1636243200000,\"name\":\"Flour\",\"price\":2.5,\"source\":\"receipt\",\"store\":\"Costco\"}]"
+const bodyWasteReport = "{\"from\":10,\"items\":[{\"_id\":1337,\"expirationDate\":15,\"name\":\"hello\",\"value\":1.5},{\"_id\":1338,\"expirationDate\":18,\"name\":\"caviar\"}],\"to\":20,\"total\":1.5,\"unpriced\":[\"caviar\"]}"
+const bodyTaggedPancakes = "{\"allergens\":[\"dairy\",\"gluten\"],\"diets\":[\"vegetarian\"],\"ingredients\":[\"Flour\",\"Milk\"],\"isCookable\":true,\"name\":\"Pancakes\"}"
+const bodyTaggedSalad = "{\"diets\":[\"vegan\",\"vegetarian\"],\"ingredients\":[\"Lettuce\"],\"isCookable\":true,\"name\":\"Salad\"}"
+const bodyImportPaella = "[{\"ingredients\":[{\"amount\":{\"unit\":\"pinch\",\"value\":1},\"name\":\"saffron\"},{\"amount\":{\"unit\":\"count\",\"value\":1},\"name\":\"Egg\"}],\"isCookable\":false,\"name\":\"Paella\",\"steps\":[{\"text\":\"Cook.\"}]}],\"rejected\":[],\"unmatched\":[{\"line\":\"1 pinch saffron\",\"recipe\":\"Paella\"}]}"
+const bodyImportPancakes = "{\"inserted\":true,\"recipes\":[{\"cookTime\":20,\"ingredients\":[{\"amount\":{\"unit\":\"cup\",\"value\":2},\"name\":\"Flour\"},{\"amount\":{\"unit\":\"count\",\"value\":2},\"name\":\"Egg\"}],\"isCookable\":false,\"name\":\"Pancakes\",\"prepTime\":10,\"servings\":4,\"steps\":[{\"text\":\"Mix.\"},{\"text\":\"Fry.\"}]}],\"rejected\":[],\"unmatched\":[]}"
+const bodyImportOmelette = "{\"inserted\":true,\"recipes\":[{\"cookware\":[\"pan\"],\"ingredients\":[{\"amount\":{\"unit\":\"count\",\"value\":2},\"name\":\"Egg\"},{\"amount\":{\"unit\":\"cup\",\"value\":1},\"name\":\"Flour\"}],\"isCookable\":false,\"name\":\"Omelette\",\"steps\":[{\"text\":\"Crack eggs into a pan.\"},{\"duration\":120,\"text\":\"Stir in all-purpose flour for 2 minutes.\"}]}],\"rejected\":[],\"unmatched\":[]}"
+const bodyImportOmeletteSaffron = "{\"inserted\":false,\"recipes\":[{\"cookware\":[\"pan\"],\"ingredients\":[{\"amount\":{\"unit\":\"count\",\"value\":2},\"name\":\"Egg\"},{\"amount\":{\"unit\":\"cup\",\"value\":1},\"name\":\"Flour\"},\"saffron\"],\"isCookable\":false,\"name\":\"Omelette\",\"steps\":[{\"text\":\"Crack eggs into a pan.\"},{\"duration\":120,\"text\":\"Stir in all-purpose flour for 2 minutes. Add saffron.\"}]}],\"rejected\":[],\"unmatched\":[{\"line\":\"saffron\",\"recipe\":\"Omelette\"}]}"
+const bodyImportMealie = "[{\"cookTime\":60,\"ingredients\":[{\"amount\":{\"unit\":\"cup\",\"value\":2},\"name\":\"Flour\"},{\"amount\":{\"unit\":\"count\",\"value\":2},\"name\":\"Egg\"}],\"isCookable\":false,\"name\":\"Pancakes\",\"prepTime\":10,\"servings\":4,\"steps\":[{\"text\":\"Mix.\"},{\"text\":\"Fry.\"}],\"url\":\"https://example.com/pancakes\"}],\"rejected\":[],\"unmatched\":[]}"
+const bodyImportPaprika = "[{\"cookTime\":65,\"ingredients\":[{\"amount\":{\"unit\":\"cup\",\"value\":2},\"name\":\"Flour\"},{\"amount\":{\"unit\":\"count\",\"value\":2},\"name\":\"Egg\"},{\"amount\":{\"unit\":\"pinch\",\"value\":1},\"name\":\"saffron\"}],\"isCookable\":false,\"name\":\"Pancakes\",\"prepTime\":10,\"servings\":4,\"steps\":[{\"text\":\"Mix.\"},{\"text\":\"Fry.\"}]}],\"rejected\":[],\"unmatched\":[{\"line\":\"1 pinch saffron\",\"recipe\":\"Pancakes\"}]}"
+const bodyImportTandoor = "[{\"ingredients\":[{\"amount\":{\"unit\":\"gram\",\"value\":250},\"name\":\"Flour\"},{\"amount\":{\"unit\":\"count\",\"value\":2},\"name\":\"Egg\"},\"butter\"],\"isCookable\":false,\"name\":\"Pancakes\",\"prepTime\":10,\"servings\":4,\"steps\":[{\"text\":\"Mix.\"},{\"duration\":300,\"text\":\"Fry.\"}]}],\"rejected\":[],\"unmatched\":[{\"line\":\"Butter\",\"recipe\":\"Pancakes\"}]}"
+const bodyGrocyExport = "{\"locations\":[{\"id\":1,\"is_freezer\":0,\"name\":\"Pantry\"},{\"id\":2,\"is_freezer\":0,\"name\":\"Refrigerator\"},{\"id\":3,\"is_freezer\":1,\"name\":\"Freezer\"}],\"products\":[{\"id\":1,\"location_id\":3,\"name\":\"Chicken\",\"qu_id_stock\":1},{\"id\":2,\"location_id\":2,\"name\":\"Milk\",\"qu_id_stock\":2}],\"quantity_units\":[{\"id\":1,\"name\":\"pound\"},{\"id\":2,\"name\":\"count\"}],\"stock\":[{\"amount\":1.5,\"best_before_date\":\"2021-11-07\",\"location_id\":3,\"product_id\":1}]}"
+const bodyQuickOmelette = "[{\"cookTime\":5,\"ingredients\":[\"Egg\"],\"isCookable\":true,\"name\":\"Omelette\",\"prepTime\":5}]"
+const bodyLocationContents = "{\"contents\":[{\"name\":\"Ice Cream\",\"shelf\":\"top\"},{\"name\":\"Beef\",\"shelf\":\"bottom\"},{\"name\":\"Peas\",\"shelf\":\"bottom\"},{\"name\":\"Bread\"}],\"location\":{\"capacity\":20,\"name\":\"Chest Freezer\",\"shelves\":[\"top\",\"bottom\"],\"type\":\"freezer\"},\"quantity\":4,\"remaining\":16}"
+const bodyEquipmentBread = "[{\"ingredients\":[\"Flour\"],\"isCookable\":true,\"name\":\"Bread\",\"requires\":[\"Oven\",\"Stand Mixer\"]}]"
 const bodyExpiring = "[{\"_id\":1337,\"expirationDate\":25,\"haveStocked\":\"false\",\"name\":\"hello\",\"type\":\"thing\"}]"
 
+const jsonLDRecipe = "{\"@context\":\"https://schema.org\",\"@graph\":[{\"@type\":\"WebPage\"},{\"@type\":\"Recipe\",\"name\":\"Pancakes\",\"recipeYield\":\"4 servings\",\"prepTime\":\"PT10M\",\"cookTime\":\"PT20M\",\"recipeIngredient\":[\"2 cups all-purpose flour\",\"2 large eggs\"],\"recipeInstructions\":[{\"@type\":\"HowToStep\",\"text\":\"Mix.\"},{\"@type\":\"HowToStep\",\"text\":\"Fry.\"}]}]}"
+const jsonLDRecipeUnmatched = "[{\"@type\":[\"Recipe\"],\"name\":\"Paella\",\"recipeIngredient\":[\"1 pinch saffron\",\"1 egg\"],\"recipeInstructions\":\"Cook.\"}]"
+
+const cooklangRecipe = "Crack @eggs{2} into a #pan{}.\n\nStir in @all-purpose flour{1%cup} for ~{2%minutes}."
+
+const mealieRecipe = "{\"name\":\"Pancakes\",\"recipeYield\":\"4 servings\",\"prepTime\":\"10 minutes\",\"performTime\":\"1 hour\",\"orgURL\":\"https://example.com/pancakes\",\"recipeIngredient\":[{\"quantity\":2,\"unit\":{\"name\":\"cup\"},\"food\":{\"name\":\"all-purpose flour\"}},{\"quantity\":2,\"unit\":null,\"food\":{\"name\":\"egg\"}}],\"recipeInstructions\":[{\"text\":\"Mix.\"},{\"text\":\"Fry.\"}]}"
+const paprikaRecipe = "{\"name\":\"Pancakes\",\"ingredients\":\"2 cups flour\\n2 eggs\\n1 pinch saffron\",\"directions\":\"Mix.\\nFry.\",\"servings\":\"4\",\"prep_time\":\"10 mins\",\"cook_time\":\"1 hr 5 mins\",\"source_url\":\"\"}"
+const tandoorRecipe = "{\"name\":\"Pancakes\",\"servings\":4,\"working_time\":10,\"waiting_time\":0,\"steps\":[{\"instruction\":\"Mix.\",\"time\":0,\"ingredients\":[{\"food\":{\"name\":\"Flour\"},\"unit\":{\"name\":\"g\"},\"amount\":250},{\"is_header\":true,\"note\":\"Wet\"},{\"food\":{\"name\":\"Egg\"},\"unit\":null,\"amount\":2}]},{\"instruction\":\"Fry.\",\"time\":5,\"ingredients\":[{\"food\":{\"name\":\"Butter\"},\"no_amount\":true}]}]}"
+
+const grocyExportBasic = "{\"locations\":[{\"id\":\"1\",\"name\":\"Fridge\",\"is_freezer\":\"0\"},{\"id\":\"2\",\"name\":\"Chest\",\"is_freezer\":\"1\"}],\"quantity_units\":[{\"id\":\"3\",\"name\":\"Pounds\"}],\"products\":[{\"id\":\"4\",\"name\":\"Chicken\",\"location_id\":\"2\",\"qu_id_stock\":\"3\"},{\"id\":\"5\",\"name\":\"Milk\",\"location_id\":\"1\"}],\"stock\":[{\"product_id\":\"4\",\"amount\":\"1.5\",\"best_before_date\":\"2021-11-07\"},{\"product_id\":\"4\",\"amount\":2,\"best_before_date\":\"2999-12-31\",\"location_id\":\"1\"}]}"
+
 const collectionIdInvalid = "dfhsrgaweg"
 
 const documentId = "6187e576abc057dac3e7d5dc"
@@ -41,6 +77,7 @@ const errorIoReadAll = "ioutil.ReadAll error"
 const errorJsonEnd = "unexpected end of JSON input"
 const errorJsonUndecodable = "invalid character ':' looking for beginning of object key string"
 const errorNoDocuments = "no documents found"
+const errorServingsZero = "servings must be positive: 0"
 const errorStrconvLol = "strconv.ParseBool: parsing \"lol\": invalid syntax"
 const errorStrconvX = "strconv.ParseInt: parsing \"x\": invalid syntax"
 const errorStrconvY = "strconv.ParseInt: parsing \"y\": invalid syntax"
@@ -58,6 +95,9 @@ var routeVarsIngredients = map[string]string{"collection": config.MongoCollectio
 var routeVarsIngredientsDoc = map[string]string{"collection": config.MongoCollectionIngredients, "id": documentId}
 var routeVarsIngredientsDocInvalid = map[string]string{"collection": config.MongoCollectionIngredients, "id": documentIdInvalid}
 var routeVarsIngredientsDocEncodeFail = map[string]string{"collection": config.MongoCollectionIngredients, "id": documentIdEncodeFail}
+var routeVarsRecipe = map[string]string{"id": documentId}
+var routeVarsRecipeInvalid = map[string]string{"id": documentIdInvalid}
+var routeVarsRecipeEncodeFail = map[string]string{"id": documentIdEncodeFail}
 var routeVarsRecipes = map[string]string{"collection": config.MongoCollectionRecipes}
 var routeVarsRecipesDoc = map[string]string{"collection": config.MongoCollectionRecipes, "id": documentId}
 
@@ -77,6 +117,55 @@ func OverrideFindOneDocumentRecipe(ctx context.Context, collection string, filte
 	return &doc, nil
 }
 
+func OverrideFindOneDocumentRecipeServings(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+	var doc bson.M = bson.M{
+		"ingredients": primitive.A{
+			bson.M{"name": "hello", "amount": bson.M{"value": int32(1), "unit": "pound"}},
+		},
+		"isCookable": false,
+		"servings":   int32(2),
+	}
+	return &doc, nil
+}
+
+func OverrideFindOneDocumentRecipeSteps(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+	var doc bson.M = bson.M{
+		"ingredients": primitive.A{
+			bson.M{"name": "hello", "amount": bson.M{"value": int32(1), "unit": "pound"}},
+		},
+		"isCookable": false,
+		"name":       "Eggs",
+		"servings":   int32(2),
+		"steps": primitive.A{
+			bson.M{"text": "Boil the water.", "duration": int32(60)},
+			"Crack the eggs.",
+		},
+	}
+	return &doc, nil
+}
+
+func OverrideFindOneDocumentRecipeNutrition(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+	var doc bson.M = bson.M{
+		"ingredients": primitive.A{
+			bson.M{"name": "hello", "amount": bson.M{"value": int32(1), "unit": "kilogram"}},
+		},
+		"isCookable": false,
+		"name":       "hello",
+		"servings":   int32(2),
+	}
+	return &doc, nil
+}
+
+func OverrideFindOneDocumentLocation(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+	var doc bson.M = bson.M{"capacity": int32(20), "name": "Chest Freezer", "shelves": primitive.A{"top", "bottom"}, "type": "freezer"}
+	return &doc, nil
+}
+
+func OverrideFindOneDocumentIngredientShelved(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+	var doc bson.M = bson.M{"location": documentId, "name": "Chicken", "shelf": "top", "storeIn": "freezer"}
+	return &doc, nil
+}
+
 func OverrideFindOneDocumentErrorBasic(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
 	return nil, fmt.Errorf(errorBasic)
 }
@@ -123,6 +212,314 @@ func OverrideFindManyDocumentsRecipe(ctx context.Context, collection string, fil
 	return []bson.M{map[string]interface{}{"isCookable": false, "ingredients": primitive.A{"hello"}}}, nil
 }
 
+func OverrideFindManyDocumentsIngredientAmount(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	current := int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond)
+	return []bson.M{map[string]interface{}{"_id": 1337, "amount": bson.M{"value": 24.0, "unit": "ounces"}, "expirationDate": current, "haveStocked": true, "name": "hello"}}, nil
+}
+
+func OverrideFindManyDocumentsServings(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		recipe, _ := OverrideFindOneDocumentRecipeServings(ctx, collection, nil)
+		return []bson.M{*recipe}, nil
+	} else {
+		return OverrideFindManyDocumentsIngredientAmount(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsNutrition(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionMealPlans {
+		return []bson.M{{"date": int64(1636243200000), "recipes": primitive.A{"hello"}}}, nil
+	} else {
+		return []bson.M{{
+			"amount":      bson.M{"value": int32(200), "unit": "grams"},
+			"haveStocked": true,
+			"name":        "hello",
+			"nutrition": bson.M{
+				"per":      bson.M{"value": int32(100), "unit": "gram"},
+				"calories": int32(50),
+				"protein":  2.0,
+			},
+		}}, nil
+	}
+}
+
+func OverrideFindManyDocumentsNutritionTwice(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionMealPlans {
+		return []bson.M{{"date": int64(1636243200000), "recipes": primitive.A{"hello", "hello"}}}, nil
+	} else {
+		return OverrideFindManyDocumentsNutrition(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsNutritionErrorBasic(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if _, ok := filter["$and"].([]bson.M)[1]["nutrition"]; ok {
+		return nil, fmt.Errorf(errorBasic)
+	} else {
+		return []bson.M{}, nil
+	}
+}
+
+func OverrideFindManyDocumentsMealPlansOrErrorBasic(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionMealPlans {
+		return OverrideFindManyDocumentsNutrition(ctx, collection, filter, opts)
+	} else {
+		return OverrideFindManyDocumentsErrorBasic(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsTagged(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		return []bson.M{
+			{"name": "Pancakes", "ingredients": primitive.A{"Flour", "Milk"}, "isCookable": true},
+			{"name": "Salad", "ingredients": primitive.A{"Lettuce"}, "isCookable": true},
+		}, nil
+	} else {
+		return []bson.M{
+			{"name": "Flour", "allergens": primitive.A{"Gluten"}, "diets": primitive.A{"vegetarian", "vegan"}},
+			{"name": "Lettuce", "allergens": primitive.A{}, "diets": primitive.A{"vegetarian", "vegan"}},
+			{"name": "Milk", "allergens": primitive.A{"dairy"}, "diets": primitive.A{"vegetarian"}},
+		}, nil
+	}
+}
+
+func OverrideFindManyDocumentsTaggedUnknown(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		recipes, _ := OverrideFindManyDocumentsTagged(ctx, collection, filter, opts)
+		return append(recipes, bson.M{"name": "Sandwich", "ingredients": primitive.A{"Bread", "Peanut Butter"}, "isCookable": true}), nil
+	} else {
+		ingredients, _ := OverrideFindManyDocumentsTagged(ctx, collection, filter, opts)
+		return append(ingredients, bson.M{"name": "Bread", "allergens": primitive.A{"gluten"}}), nil
+	}
+}
+
+func OverrideFindManyDocumentsHousehold(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionMembers {
+		members := []bson.M{
+			{"name": "Alice", "favorites": primitive.A{"Salad"}},
+			{"name": "Bob", "allergens": primitive.A{"Gluten"}, "dislikes": primitive.A{"Lettuce"}},
+		}
+		names := filter["name"].(bson.M)["$in"].([]string)
+		found := []bson.M{}
+		for _, member := range members {
+			if utils.Contains(names, member["name"].(string)) {
+				found = append(found, member)
+			}
+		}
+		return found, nil
+	} else {
+		return OverrideFindManyDocumentsTagged(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsHistory(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionHistory {
+		return []bson.M{{"date": int64(100), "rating": 4.0}, {"date": int64(200), "rating": 5.0}}, nil
+	} else if collection == config.MongoCollectionRecipes {
+		return []bson.M{
+			{"name": "Pancakes", "ingredients": primitive.A{"Flour", "Milk"}, "isCookable": true, "rating": 5.0, "lastCooked": int64(4102444800000)},
+			{"name": "Salad", "ingredients": primitive.A{"Lettuce"}, "isCookable": true, "rating": 2.5, "lastCooked": int64(0)},
+		}, nil
+	} else {
+		return OverrideFindManyDocumentsIngredientAmount(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsEquipment(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionEquipment {
+		return []bson.M{
+			{"name": "Oven", "available": true},
+			{"name": "Instant Pot", "available": false, "status": "at the cabin"},
+			{"name": "Stand Mixer"},
+		}, nil
+	} else if collection == config.MongoCollectionRecipes {
+		return []bson.M{
+			{"name": "Bread", "ingredients": primitive.A{"Flour"}, "isCookable": true, "requires": primitive.A{"Oven", "Stand Mixer"}},
+			{"name": "Chili", "ingredients": primitive.A{"Beans"}, "isCookable": true, "requires": primitive.A{"Instant Pot"}},
+			{"name": "Waffles", "ingredients": primitive.A{"Flour"}, "isCookable": true, "requires": primitive.A{"Waffle Iron"}},
+		}, nil
+	} else {
+		return []bson.M{{"name": "Flour", "haveStocked": true}, {"name": "Beans", "haveStocked": true}}, nil
+	}
+}
+
+func OverrideFindManyDocumentsEquipmentRestored(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		// Stored as not cookable while the oven was broken
+		return []bson.M{{"name": "Pizza", "ingredients": primitive.A{"Flour"}, "isCookable": false, "requires": primitive.A{"Oven"}}}, nil
+	} else {
+		return OverrideFindManyDocumentsEquipment(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsEquipmentError(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionEquipment {
+		return nil, fmt.Errorf(errorBasic)
+	} else {
+		return OverrideFindManyDocumentsEquipment(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsQuick(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		return []bson.M{
+			{"name": "Omelette", "ingredients": primitive.A{"Egg"}, "isCookable": true, "prepTime": int32(5), "cookTime": int32(5)},
+			{"name": "Stew", "ingredients": primitive.A{"Beef"}, "isCookable": true, "prepTime": int32(20), "cookTime": int32(120)},
+			{"name": "Tacos", "ingredients": primitive.A{"Beef"}, "isCookable": true, "cookTime": int32(15)},
+			{"name": "Toast", "ingredients": primitive.A{"Bread"}, "isCookable": true},
+		}, nil
+	} else if conditions, ok := filter["$and"].([]bson.M); ok && len(conditions) > 3 {
+		// Frozen items excluded
+		return []bson.M{{"name": "Egg", "haveStocked": true, "storeIn": "refrigerator"}}, nil
+	} else {
+		return []bson.M{{"name": "Egg", "haveStocked": true, "storeIn": "refrigerator"}, {"name": "Beef", "haveStocked": true, "storeIn": "freezer"}}, nil
+	}
+}
+
+func OverrideFindManyDocumentsQuickError(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		return OverrideFindManyDocumentsQuick(ctx, collection, filter, opts)
+	} else {
+		return nil, fmt.Errorf(errorBasic)
+	}
+}
+
+func OverrideFindManyDocumentsThaw(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionMealPlans {
+		return []bson.M{{"date": int64(1636329600000), "recipes": primitive.A{"Hamburgers", bson.M{"name": "Stew", "servings": int32(4)}}}}, nil
+	} else if collection == config.MongoCollectionRecipes {
+		return []bson.M{
+			{"name": "Hamburgers", "ingredients": primitive.A{"Beef", "Buns"}},
+			{"name": "Stew", "ingredients": primitive.A{"Beef", bson.M{"name": "Chicken", "amount": bson.M{"value": 1, "unit": "pound"}}}},
+		}, nil
+	} else {
+		return []bson.M{
+			{"name": "Beef", "haveStocked": true, "storeIn": "freezer"},
+			{"name": "Buns", "haveStocked": true, "storeIn": "pantry"},
+			{"name": "Chicken", "haveStocked": true, "storeIn": "refrigerator"},
+		}, nil
+	}
+}
+
+func OverrideFindManyDocumentsThawRecipesError(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionMealPlans {
+		return OverrideFindManyDocumentsThaw(ctx, collection, filter, opts)
+	} else {
+		return nil, fmt.Errorf(errorBasic)
+	}
+}
+
+func OverrideFindManyDocumentsThawIngredientsError(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionIngredients {
+		return nil, fmt.Errorf(errorBasic)
+	} else {
+		return OverrideFindManyDocumentsThaw(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsLocation(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	return []bson.M{
+		{"name": "Peas", "shelf": "bottom"},
+		{"name": "Bread"},
+		{"name": "Ice Cream", "shelf": "top"},
+		{"name": "Beef", "shelf": "bottom"},
+	}, nil
+}
+
+func OverrideFindManyDocumentsTrip(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		return []bson.M{{"_id": "pancakes", "name": "Pancakes", "ingredients": primitive.A{"Flour", "Milk"}, "isCookable": false}}, nil
+	} else if len(filter) > 0 {
+		// Stocked after the trip
+		return []bson.M{{"name": "Flour", "haveStocked": true}, {"name": "Milk", "haveStocked": true}}, nil
+	} else {
+		return []bson.M{
+			{"_id": "milk", "name": "Milk", "aliases": primitive.A{"Whole Milk"}, "amount": bson.M{"value": 0.5, "unit": "gallon"}, "haveStocked": true, "storeIn": "refrigerator"},
+			{"_id": "flour", "name": "Flour", "barcode": "0002", "haveStocked": false, "lifespan": bson.M{"pantry": bson.M{"value": 6, "unit": "month"}}, "storeIn": "pantry"},
+		}, nil
+	}
+}
+
+func OverrideFindManyDocumentsTripRecipesError(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		return nil, fmt.Errorf(errorBasic)
+	} else {
+		return OverrideFindManyDocumentsTrip(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsPrices(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionPrices {
+		return []bson.M{
+			{"amount": bson.M{"value": int32(2), "unit": "kilogram"}, "date": int64(1636243200000), "name": "hello", "price": 6.0, "source": "receipt", "store": "Aldi"},
+			{"amount": bson.M{"value": int32(2), "unit": "kilogram"}, "date": int64(1633651200000), "name": "hello", "price": 8.0, "source": "trip"},
+		}, nil
+	} else {
+		// Expired while stocked
+		return []bson.M{
+			{"_id": 1337, "amount": bson.M{"value": int32(500), "unit": "grams"}, "expirationDate": int64(15), "haveStocked": true, "name": "hello"},
+			{"_id": 1338, "expirationDate": int64(18), "haveStocked": true, "name": "caviar"},
+		}, nil
+	}
+}
+
+func OverrideFindManyDocumentsPricesErrorBasic(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionPrices {
+		return nil, fmt.Errorf(errorBasic)
+	} else {
+		return OverrideFindManyDocumentsPrices(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsPricesBatched(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionRecipes {
+		return []bson.M{
+			{"name": "hello", "ingredients": primitive.A{bson.M{"name": "hello", "amount": bson.M{"value": 1, "unit": "kilogram"}}}, "isCookable": false, "servings": 2},
+			{"name": "caviar", "ingredients": primitive.A{"caviar"}, "isCookable": false},
+		}, nil
+	} else if collection == config.MongoCollectionPrices {
+		// Prices for every recipe come from one lookup
+		if names, _ := filter["name"].(bson.M)["$in"].([]string); len(names) != 2 {
+			return nil, fmt.Errorf(errorBasic)
+		}
+	}
+	return OverrideFindManyDocumentsPrices(ctx, collection, filter, opts)
+}
+
+func OverrideFindManyDocumentsPricesBatchedErrorBasic(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if collection == config.MongoCollectionPrices {
+		return nil, fmt.Errorf(errorBasic)
+	} else {
+		return OverrideFindManyDocumentsPricesBatched(ctx, collection, filter, opts)
+	}
+}
+
+func OverrideFindManyDocumentsPricesMilk(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	return []bson.M{{"amount": bson.M{"value": 1, "unit": "gallon"}, "name": "Milk", "price": 3.49}}, nil
+}
+
+func OverrideFindManyDocumentsImport(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if len(filter) == 0 {
+		return []bson.M{{"name": "Flour", "aliases": primitive.A{"All-Purpose Flour"}}, {"name": "Egg"}}, nil
+	} else {
+		return []bson.M{{"name": "Flour", "haveStocked": true}}, nil
+	}
+}
+
+func OverrideFindManyDocumentsImportCookableError(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	if len(filter) == 0 {
+		return OverrideFindManyDocumentsImport(ctx, collection, filter, opts)
+	} else {
+		return nil, fmt.Errorf(errorBasic)
+	}
+}
+
+func OverrideFindManyDocumentsGrocy(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+	return []bson.M{
+		{"name": "Chicken", "amount": primitive.M{"value": 1.5, "unit": "pounds"}, "expirationDate": int64(1636243200000), "haveStocked": true, "storeIn": "freezer"},
+		{"name": "Milk", "expirationDate": int64(0), "haveStocked": false, "storeIn": "refrigerator"},
+	}, nil
+}
+
 func OverrideFindManyDocumentsSuper(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
 	if collection == config.MongoCollectionRecipes {
 		return OverrideFindManyDocumentsRecipe(ctx, collection, filter, opts)
@@ -186,6 +583,13 @@ func OverrideUpdateOneDocumentZero(ctx context.Context, collection string, filte
 	return 0, 0, nil
 }
 
+func OverrideUpdateOneDocumentTripError(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+	if filter[0].Value == "flour" {
+		return 0, 0, fmt.Errorf(errorBasic)
+	}
+	return 1, 1, nil
+}
+
 func OverrideUpdateOneDocumentErrorBasic(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
 	return 0, 0, fmt.Errorf(errorBasic)
 }
@@ -206,23 +610,44 @@ func OverrideDeleteManyDocumentsErrorBasic(ctx context.Context, collection strin
 	return 0, fmt.Errorf(errorBasic)
 }
 
-func OverrideGetShoppingListNil() (*trello.Card, error) {
+func OverrideGetShoppingListNil() (*config.ShoppingList, error) {
 	return nil, nil
 }
 
-func OverrideGetShoppingListErrorBasic() (*trello.Card, error) {
+func OverrideGetShoppingListErrorBasic() (*config.ShoppingList, error) {
 	return nil, fmt.Errorf(errorBasic)
 }
 
-func OverrideCreateShoppingListErrorBasic(dueDate *time.Time, applyLabels []string, listItems []string) (string, error) {
+func OverrideCreateShoppingListErrorBasic(dueDate *time.Time, listItems []config.ShoppingItem) (string, error) {
 	return "", fmt.Errorf(errorBasic)
 }
 
-func OverrideAddToShoppingListErroBasic(itemNames []string) (string, error) {
+func OverrideAddToShoppingListErroBasic(listItems []config.ShoppingItem) (string, error) {
 	return "", fmt.Errorf(errorBasic)
 }
 
-func OverrideComposeMessageEmpty(quantity, quantityExpired int, url string) string {
+func OverrideGetShoppingListOverdue() (*config.ShoppingList, error) {
+	due := time.Now().Add(-time.Hour)
+	return &config.ShoppingList{Name: "Shopping List", Due: &due}, nil
+}
+
+func OverrideGetShoppingListItemsRollover(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+	return []config.ShoppingItem{
+		{Name: "Milk (expiring)"},
+		{Name: "Paper Towels", Section: "Target - Household"},
+		{Name: "Eggs (expired)", Checked: true},
+	}, nil
+}
+
+func OverrideGetShoppingListItemsComplete(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+	return []config.ShoppingItem{{Name: "Eggs (expired)", Checked: true}}, nil
+}
+
+func OverrideArchiveShoppingListErrorBasic(list *config.ShoppingList) error {
+	return fmt.Errorf(errorBasic)
+}
+
+func OverrideComposeMessageEmpty(quantity, quantityExpired int, url string, items []config.ShoppingItem) string {
 	return ""
 }
 
@@ -0,0 +1,612 @@
+package clients
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+)
+
+const caldavQuery = `<?xml version="1.0" encoding="utf-8" ?>
+<C:calendar-query xmlns:D="DAV:" xmlns:C="urn:ietf:params:xml:ns:caldav">
+  <D:prop><D:getetag/><C:calendar-data/></D:prop>
+  <C:filter><C:comp-filter name="VCALENDAR"><C:comp-filter name="VTODO"/></C:comp-filter></C:filter>
+</C:calendar-query>`
+
+// A failed CalDAV request
+type CalDAVError struct {
+	Status int
+	Body   string
+}
+
+func (e *CalDAVError) Error() string {
+	return fmt.Sprintf("caldav request failed: %d: %s", e.Status, e.Body)
+}
+
+// The parts of a WebDAV multistatus response holding each task's data
+type caldavMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				CalendarData string `xml:"calendar-data"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// A VTODO as stored on the server, with the properties the shopping list reads pulled out
+type caldavTodo struct {
+	Href       string
+	Data       string
+	UID        string
+	Summary    string
+	Status     string
+	Due        string
+	RelatedTo  string
+	Categories []string
+}
+
+func (todo caldavTodo) completed() bool {
+	return todo.Status == "COMPLETED"
+}
+
+// The shopping list is a "Shopping List" VTODO in a CalDAV task list (e.g. Nextcloud Tasks) with a
+// sub-task per item, related to it by RELATED-TO. Every task carries the list's due date and the
+// configured labels as categories, and each item its section as another category.
+type CalDAV struct {
+	URL       string
+	Username  string
+	Password  string
+	LabelsStr string
+	Client    *http.Client
+}
+
+func NewCalDAVClientWrapper(collectionURL, username, password, labels string) *CalDAV {
+	if !strings.HasSuffix(collectionURL, "/") {
+		collectionURL += "/"
+	}
+	client := CalDAV{
+		URL:       collectionURL,
+		Username:  username,
+		Password:  password,
+		LabelsStr: labels,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	return &client
+}
+
+func (cc *CalDAV) do(method, href string, headers map[string]string, body string) ([]byte, error) {
+	base, err := url.Parse(cc.URL)
+	if err != nil {
+		return nil, err
+	}
+	target, err := base.Parse(href)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(method, target.String(), bytes.NewReader([]byte(body)))
+	if err != nil {
+		return nil, err
+	}
+	request.SetBasicAuth(cc.Username, cc.Password)
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+
+	response, err := cc.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	contents, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	} else if response.StatusCode >= http.StatusMultipleChoices {
+		return nil, &CalDAVError{Status: response.StatusCode, Body: strings.TrimSpace(string(contents))}
+	}
+	return contents, nil
+}
+
+// Every VTODO in the task list
+func (cc *CalDAV) todos() ([]caldavTodo, error) {
+	headers := map[string]string{"Content-Type": "application/xml; charset=utf-8", "Depth": "1"}
+	contents, err := cc.do("REPORT", cc.URL, headers, caldavQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var multistatus caldavMultistatus
+	err = xml.Unmarshal(contents, &multistatus)
+	if err != nil {
+		return nil, err
+	}
+
+	todos := []caldavTodo{}
+	for _, response := range multistatus.Responses {
+		for _, propstat := range response.Propstat {
+			data := propstat.Prop.CalendarData
+			if data == "" {
+				continue
+			}
+
+			todo := caldavTodo{Href: response.Href, Data: data}
+			inTodo := false
+			for _, line := range utils.ICalUnfold(data) {
+				name, params, value := caldavProperty(line)
+				if name == "BEGIN" && value == "VTODO" {
+					inTodo = true
+				} else if name == "END" && value == "VTODO" {
+					inTodo = false
+				} else if !inTodo {
+					continue
+				}
+
+				switch name {
+				case "UID":
+					todo.UID = value
+				case "SUMMARY":
+					todo.Summary = utils.ICalUnescape(value)
+				case "STATUS":
+					todo.Status = value
+				case "DUE":
+					// Kept as written after the name, so new items can reuse it as is
+					if params != "" {
+						params = ";" + params
+					}
+					todo.Due = params + ":" + value
+				case "CATEGORIES":
+					for _, category := range caldavSplitCategories(value) {
+						todo.Categories = append(todo.Categories, utils.ICalUnescape(category))
+					}
+				case "RELATED-TO":
+					if !strings.Contains(params, "RELTYPE=") || strings.Contains(params, "RELTYPE=PARENT") {
+						todo.RelatedTo = value
+					}
+				}
+			}
+			if todo.UID != "" {
+				todos = append(todos, todo)
+			}
+		}
+	}
+	return todos, nil
+}
+
+// Split a CATEGORIES value on its unescaped commas
+func caldavSplitCategories(value string) []string {
+	categories := []string{}
+	var category strings.Builder
+	escaped := false
+	for _, r := range value {
+		if r == ',' && !escaped {
+			categories = append(categories, category.String())
+			category.Reset()
+			continue
+		}
+		escaped = r == '\\' && !escaped
+		category.WriteRune(r)
+	}
+	return append(categories, category.String())
+}
+
+// Split a content line into its name, parameters and value
+func caldavProperty(line string) (string, string, string) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.ToUpper(line), "", ""
+	}
+
+	name, params := line[:colon], ""
+	if semicolon := strings.Index(name, ";"); semicolon >= 0 {
+		name, params = name[:semicolon], name[semicolon+1:]
+	}
+	return strings.ToUpper(name), params, line[colon+1:]
+}
+
+// Replace (or add) properties of the VTODO in an iCalendar object, leaving everything else alone
+func caldavSet(data string, properties map[string]string) string {
+	lines := []string{}
+	inTodo := false
+	for _, line := range utils.ICalUnfold(data) {
+		name, _, value := caldavProperty(line)
+		if name == "BEGIN" && value == "VTODO" {
+			inTodo = true
+		} else if name == "END" && value == "VTODO" {
+			for _, key := range []string{"SUMMARY", "STATUS", "CATEGORIES", "COMPLETED", "PERCENT-COMPLETE", "LAST-MODIFIED"} {
+				if v, ok := properties[key]; ok {
+					lines = append(lines, key+":"+v)
+				}
+			}
+			inTodo = false
+		} else if _, replaced := properties[name]; inTodo && replaced {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	folded := []string{}
+	for _, line := range lines {
+		folded = append(folded, utils.ICalFold(line))
+	}
+	return strings.Join(folded, "\r\n") + "\r\n"
+}
+
+func caldavTimestamp(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func (cc *CalDAV) labels() []string {
+	labels := []string{}
+	for _, label := range strings.Split(cc.LabelsStr, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// The configured labels, followed by the section when given
+func (cc *CalDAV) categories(section string) string {
+	categories := []string{}
+	for _, label := range cc.labels() {
+		categories = append(categories, utils.ICalEscape(label))
+	}
+	if section != "" {
+		categories = append(categories, utils.ICalEscape(section))
+	}
+	return strings.Join(categories, ",")
+}
+
+// The section a task was categorized under, skipping the labels every task gets
+func (cc *CalDAV) section(todo caldavTodo) string {
+	for _, category := range todo.Categories {
+		if !utils.Contains(cc.labels(), category) {
+			return category
+		}
+	}
+	return config.ShoppingSectionDefault
+}
+
+// Write a new VTODO, returning its UID
+func (cc *CalDAV) create(summary, due, parent, section string) (string, error) {
+	random := make([]byte, 16)
+	_, err := rand.Read(random)
+	if err != nil {
+		return "", err
+	}
+	uid := "forage-" + hex.EncodeToString(random)
+	now := caldavTimestamp(time.Now())
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Forage//Shopping List//EN",
+		"BEGIN:VTODO",
+		"UID:" + uid,
+		"DTSTAMP:" + now,
+		"CREATED:" + now,
+		"SUMMARY:" + utils.ICalEscape(summary),
+		"STATUS:NEEDS-ACTION",
+	}
+	if due != "" {
+		lines = append(lines, "DUE"+due)
+	}
+	if categories := cc.categories(section); categories != "" {
+		lines = append(lines, "CATEGORIES:"+categories)
+	}
+	if parent != "" {
+		lines = append(lines, "RELATED-TO;RELTYPE=PARENT:"+parent)
+	}
+	lines = append(lines, "END:VTODO", "END:VCALENDAR")
+
+	folded := []string{}
+	for _, line := range lines {
+		folded = append(folded, utils.ICalFold(line))
+	}
+
+	headers := map[string]string{"Content-Type": "text/calendar; charset=utf-8", "If-None-Match": "*"}
+	_, err = cc.do("PUT", uid+".ics", headers, strings.Join(folded, "\r\n")+"\r\n")
+	if err != nil {
+		return "", err
+	}
+	return uid, nil
+}
+
+func (cc *CalDAV) update(todo caldavTodo, properties map[string]string) error {
+	properties["LAST-MODIFIED"] = caldavTimestamp(time.Now())
+	headers := map[string]string{"Content-Type": "text/calendar; charset=utf-8"}
+	_, err := cc.do("PUT", todo.Href, headers, caldavSet(todo.Data, properties))
+	return err
+}
+
+func (cc *CalDAV) complete(todo caldavTodo) error {
+	return cc.update(todo, map[string]string{
+		"COMPLETED":        caldavTimestamp(time.Now()),
+		"PERCENT-COMPLETE": "100",
+		"STATUS":           "COMPLETED",
+	})
+}
+
+// The open "Shopping List" task and every task under it, or nil when there isn't one
+func (cc *CalDAV) current() (*caldavTodo, []caldavTodo, error) {
+	todos, err := cc.todos()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var list *caldavTodo
+	for i, todo := range todos {
+		if todo.RelatedTo == "" && todo.Summary == "Shopping List" && todo.Status != "COMPLETED" && todo.Status != "CANCELLED" {
+			list = &todos[i]
+			break
+		}
+	}
+	if list == nil {
+		return nil, nil, nil
+	}
+	return list, caldavItems(todos, list.UID), nil
+}
+
+func caldavItems(todos []caldavTodo, uid string) []caldavTodo {
+	items := []caldavTodo{}
+	for _, todo := range todos {
+		if todo.RelatedTo == uid && todo.Status != "CANCELLED" {
+			items = append(items, todo)
+		}
+	}
+	return items
+}
+
+// A DUE value, in UTC, as a date-time or an all-day date (which is taken to be midnight locally)
+func caldavDue(due string) (*time.Time, bool) {
+	params, value, _ := strings.Cut(due, ":")
+	var t time.Time
+	var err error
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+	} else if strings.Contains(value, "T") {
+		location := time.Local
+		for _, param := range strings.Split(params, ";") {
+			if tzid, ok := strings.CutPrefix(param, "TZID="); ok {
+				if loc, err := time.LoadLocation(tzid); err == nil {
+					location = loc
+				}
+			}
+		}
+		t, err = time.ParseInLocation("20060102T150405", value, location)
+	} else {
+		t, err = time.ParseInLocation("20060102", value, time.Local)
+	}
+	if err != nil {
+		return nil, false
+	}
+	return &t, true
+}
+
+func (cc *CalDAV) GetShoppingList() (*config.ShoppingList, error) {
+	list, _, err := cc.current()
+	if err != nil || list == nil {
+		return nil, err
+	}
+
+	shoppingList := config.ShoppingList{ID: list.UID, Name: list.Summary, URL: cc.URL}
+	if due, ok := caldavDue(list.Due); ok {
+		shoppingList.Due = due
+	}
+	return &shoppingList, nil
+}
+
+func (cc *CalDAV) CreateShoppingList(dueDate *time.Time, listItems []config.ShoppingItem) (string, error) {
+	var due string
+	if dueDate != nil {
+		due = ":" + caldavTimestamp(*dueDate)
+	}
+
+	uid, err := cc.create("Shopping List", due, "", "")
+	if err != nil {
+		return "", err
+	}
+
+	// Add a task per item, grouped by section
+	sections, grouped := utils.GroupShoppingItems(listItems)
+	present := map[string]bool{}
+	for _, section := range sections {
+		for _, item := range grouped[section] {
+			key := strings.ToLower(utils.ShoppingItemName(item.Name))
+			if present[key] {
+				continue
+			}
+
+			_, err := cc.create(item.Name, due, uid, section)
+			if err != nil {
+				return "", err
+			}
+			present[key] = true
+		}
+	}
+
+	return cc.URL, nil
+}
+
+// Same rules as the Trello card: tasks already on the list have their stage and section updated in
+// place, ones the expiration job added that are no longer needed are deleted, and anything added by
+// hand or completed is left alone.
+func (cc *CalDAV) AddToShoppingList(listItems []config.ShoppingItem) (string, error) {
+	list, items, err := cc.current()
+	if err != nil {
+		return "", err
+	} else if list == nil {
+		return "", ErrorShoppingListNotFound
+	}
+
+	wanted := map[string]config.ShoppingItem{}
+	for _, item := range listItems {
+		wanted[strings.ToLower(utils.ShoppingItemName(item.Name))] = item
+	}
+
+	present := map[string]bool{}
+	for _, item := range items {
+		key := strings.ToLower(utils.ShoppingItemName(item.Summary))
+		want, needed := wanted[key]
+		if item.completed() {
+			// Waiting to be synced back into inventory
+			present[key] = present[key] || needed
+			continue
+		} else if !needed && utils.ShoppingItemStage(item.Summary) == "" {
+			// Added by hand
+			continue
+		}
+
+		if !needed || present[key] {
+			// Restocked or a duplicate
+			_, err := cc.do("DELETE", item.Href, nil, "")
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		present[key] = true
+		if section := shoppingSection(want); item.Summary != want.Name || cc.section(item) != section {
+			err := cc.update(item, map[string]string{"SUMMARY": utils.ICalEscape(want.Name), "CATEGORIES": cc.categories(section)})
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, item := range listItems {
+		key := strings.ToLower(utils.ShoppingItemName(item.Name))
+		if present[key] {
+			continue
+		}
+
+		_, err := cc.create(item.Name, list.Due, list.UID, shoppingSection(item))
+		if err != nil {
+			return "", err
+		}
+		present[key] = true
+	}
+
+	return cc.URL, nil
+}
+
+// Complete the tasks for the given ingredients, returning the text of each one checked off
+func (cc *CalDAV) CheckOffShoppingList(names []string) ([]string, error) {
+	checked := []string{}
+	list, items, err := cc.current()
+	if err != nil {
+		return checked, err
+	} else if list == nil {
+		// No shopping list, nothing to check off
+		return checked, nil
+	}
+
+	for _, item := range items {
+		if item.completed() || !shoppingItemMatches(item.Summary, names) {
+			continue
+		}
+
+		err := cc.complete(item)
+		if err != nil {
+			return checked, err
+		}
+		checked = append(checked, item.Summary)
+	}
+
+	return checked, nil
+}
+
+// The text of each completed task
+func (cc *CalDAV) GetCompletedShoppingItems() ([]string, error) {
+	completed := []string{}
+	list, items, err := cc.current()
+	if err != nil {
+		return completed, err
+	} else if list == nil {
+		return completed, nil
+	}
+
+	for _, item := range items {
+		if item.completed() {
+			completed = append(completed, item.Summary)
+		}
+	}
+	return completed, nil
+}
+
+// Delete the tasks with the given text
+func (cc *CalDAV) RemoveFromShoppingList(itemText []string) error {
+	list, items, err := cc.current()
+	if err != nil || list == nil {
+		return err
+	}
+
+	for _, item := range items {
+		if !utils.Contains(itemText, item.Summary) {
+			continue
+		}
+
+		_, err := cc.do("DELETE", item.Href, nil, "")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (cc *CalDAV) GetShoppingListItems(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+	todos, err := cc.todos()
+	if err != nil {
+		return nil, err
+	}
+
+	items := []config.ShoppingItem{}
+	for _, todo := range caldavItems(todos, list.ID) {
+		items = append(items, config.ShoppingItem{Name: todo.Summary, Checked: todo.completed(), Section: cc.section(todo)})
+	}
+	return items, nil
+}
+
+// Complete the shopping list task so the next list starts on a fresh one, cancelling what's left on
+// it (those items are carried over to the next list)
+func (cc *CalDAV) ArchiveShoppingList(list *config.ShoppingList) error {
+	todos, err := cc.todos()
+	if err != nil {
+		return err
+	}
+
+	var parent *caldavTodo
+	for i, todo := range todos {
+		if todo.UID == list.ID {
+			parent = &todos[i]
+			break
+		}
+	}
+	if parent == nil {
+		return fmt.Errorf("%w: %s", ErrorShoppingListNotFound, list.ID)
+	}
+
+	for _, item := range caldavItems(todos, list.ID) {
+		if item.completed() {
+			continue
+		}
+		err := cc.update(item, map[string]string{"STATUS": "CANCELLED"})
+		if err != nil {
+			return err
+		}
+	}
+	return cc.complete(*parent)
+}
@@ -0,0 +1,306 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrorShoppingListNotFound = errors.New("shopping list not found")
+var ErrorShoppingListChanged = errors.New("shopping list kept changing")
+
+// How many times to reread a list that changed while its items were being rewritten
+const nativeUpdateAttempts = 3
+
+// Shopping lists kept by Forage itself in the shoppinglists collection, for households without Trello.
+// Lists are { name, due, created, updated, archived, items: [{ name, checked, section }] } and at most one isn't
+// archived. Items are kept grouped by section.
+type Native struct {
+	URL   string
+	Mongo config.MongoHandle
+}
+
+func NewNativeClientWrapper(mongo config.MongoHandle, url string) *Native {
+	client := Native{
+		URL:   url,
+		Mongo: mongo,
+	}
+	return &client
+}
+
+// The list that isn't archived yet, or nil when there isn't one
+func (nc *Native) current(ctx context.Context) (bson.M, error) {
+	filter := bson.M{"archived": bson.M{"$ne": true}}
+	documents, err := nc.Mongo.FindDocuments(ctx, config.MongoCollectionShoppingLists, filter, nil)
+	if err != nil || len(documents) == 0 {
+		return nil, err
+	}
+	return documents[0], nil
+}
+
+// Apply an update to the list matching the filter, stamping when it changed. Returns whether a list matched
+func (nc *Native) update(ctx context.Context, filter bson.D, update bson.M) (bool, error) {
+	fields, _ := update["$set"].(bson.M)
+	if fields == nil {
+		fields = bson.M{}
+		update["$set"] = fields
+	}
+	fields["updated"] = int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond)
+	matched, _, err := nc.Mongo.UpdateOneDocument(ctx, config.MongoCollectionShoppingLists, filter, update)
+	return matched > 0, err
+}
+
+// The document behind a shopping list returned earlier
+func (nc *Native) find(ctx context.Context, list *config.ShoppingList) (bson.M, error) {
+	var id interface{} = list.ID
+	if oid, err := primitive.ObjectIDFromHex(list.ID); err == nil {
+		id = oid
+	}
+
+	document, err := nc.Mongo.FindOneDocument(ctx, config.MongoCollectionShoppingLists, bson.D{{"_id", id}})
+	if err != nil && err.Error() == utils.ErrorMongoNoDocuments {
+		return nil, fmt.Errorf("%w: %s", ErrorShoppingListNotFound, list.ID)
+	} else if err != nil {
+		return nil, err
+	}
+	return *document, nil
+}
+
+func nativeID(document bson.M) string {
+	if oid, ok := document["_id"].(primitive.ObjectID); ok {
+		return oid.Hex()
+	}
+	return fmt.Sprint(document["_id"])
+}
+
+func nativeItems(document bson.M) []config.ShoppingItem {
+	var entries []interface{}
+	switch values := document["items"].(type) {
+	case primitive.A:
+		entries = values
+	case []interface{}:
+		entries = values
+	case []bson.M:
+		for _, value := range values {
+			entries = append(entries, value)
+		}
+	}
+
+	items := []config.ShoppingItem{}
+	for _, entry := range entries {
+		var fields map[string]interface{}
+		switch e := entry.(type) {
+		case bson.M:
+			fields = e
+		case map[string]interface{}:
+			fields = e
+		default:
+			continue
+		}
+
+		item := config.ShoppingItem{}
+		item.Name, _ = fields["name"].(string)
+		item.Checked, _ = fields["checked"].(bool)
+		item.Section, _ = fields["section"].(string)
+		item.Section = shoppingSection(item)
+		items = append(items, item)
+	}
+	return items
+}
+
+// The item documents, grouped by section
+func nativeItemDocuments(items []config.ShoppingItem) []bson.M {
+	documents := []bson.M{}
+	sections, grouped := utils.GroupShoppingItems(items)
+	for _, section := range sections {
+		for _, item := range grouped[section] {
+			documents = append(documents, bson.M{"name": item.Name, "checked": item.Checked, "section": section})
+		}
+	}
+	return documents
+}
+
+func (nc *Native) GetShoppingList() (*config.ShoppingList, error) {
+	document, err := nc.current(context.Background())
+	if err != nil || document == nil {
+		return nil, err
+	}
+
+	list := config.ShoppingList{ID: nativeID(document), URL: nc.URL}
+	list.Name, _ = document["name"].(string)
+	if due, ok := utils.Float64FromNumber(document["due"]); ok {
+		dueDate := time.UnixMilli(int64(due))
+		list.Due = &dueDate
+	}
+	return &list, nil
+}
+
+func (nc *Native) CreateShoppingList(dueDate *time.Time, listItems []config.ShoppingItem) (string, error) {
+	now := int64(time.Now().UTC().UnixNano()) / int64(time.Millisecond)
+	items := []config.ShoppingItem{}
+	present := map[string]bool{}
+	for _, item := range listItems {
+		key := strings.ToLower(utils.ShoppingItemName(item.Name))
+		if !present[key] {
+			present[key] = true
+			items = append(items, config.ShoppingItem{Name: item.Name, Section: item.Section})
+		}
+	}
+
+	document := bson.M{
+		"archived": false,
+		"created":  now,
+		"items":    nativeItemDocuments(items),
+		"name":     "Shopping List",
+		"updated":  now,
+	}
+	if dueDate != nil {
+		document["due"] = int64(dueDate.UTC().UnixNano()) / int64(time.Millisecond)
+	}
+
+	err := nc.Mongo.InsertManyDocuments(context.Background(), config.MongoCollectionShoppingLists, []interface{}{document})
+	if err != nil {
+		return "", err
+	}
+	return nc.URL, nil
+}
+
+// Same rules as the Trello card: items already on the list have their stage and section updated in
+// place, items the expiration job added that are no longer needed are dropped, and anything added by
+// hand or checked off is kept.
+func (nc *Native) AddToShoppingList(listItems []config.ShoppingItem) (string, error) {
+	ctx := context.Background()
+	for attempt := 0; attempt < nativeUpdateAttempts; attempt++ {
+		document, err := nc.current(ctx)
+		if err != nil {
+			return "", err
+		} else if document == nil {
+			return "", ErrorShoppingListNotFound
+		}
+
+		// Only write the items back if nobody changed the list since it was read, otherwise start over
+		filter := bson.D{{"_id", document["_id"]}, {"updated", document["updated"]}}
+		items := reconcileNativeItems(nativeItems(document), listItems)
+		updated, err := nc.update(ctx, filter, bson.M{"$set": bson.M{"items": nativeItemDocuments(items)}})
+		if err != nil {
+			return "", err
+		} else if updated {
+			return nc.URL, nil
+		}
+	}
+	return "", ErrorShoppingListChanged
+}
+
+// The items a list should have once the given items are added to what's on it
+func reconcileNativeItems(existing []config.ShoppingItem, listItems []config.ShoppingItem) []config.ShoppingItem {
+	wanted := map[string]config.ShoppingItem{}
+	for _, item := range listItems {
+		wanted[strings.ToLower(utils.ShoppingItemName(item.Name))] = item
+	}
+
+	present := map[string]bool{}
+	items := []config.ShoppingItem{}
+	for _, item := range existing {
+		key := strings.ToLower(utils.ShoppingItemName(item.Name))
+		want, needed := wanted[key]
+		if item.Checked {
+			present[key] = present[key] || needed
+			items = append(items, item)
+			continue
+		} else if !needed && utils.ShoppingItemStage(item.Name) == "" {
+			items = append(items, item)
+			continue
+		} else if !needed || present[key] {
+			continue
+		}
+
+		present[key] = true
+		items = append(items, config.ShoppingItem{Name: want.Name, Section: want.Section})
+	}
+
+	for _, item := range listItems {
+		key := strings.ToLower(utils.ShoppingItemName(item.Name))
+		if !present[key] {
+			present[key] = true
+			items = append(items, config.ShoppingItem{Name: item.Name, Section: item.Section})
+		}
+	}
+	return items
+}
+
+func (nc *Native) CheckOffShoppingList(names []string) ([]string, error) {
+	ctx := context.Background()
+	checked := []string{}
+	document, err := nc.current(ctx)
+	if err != nil || document == nil {
+		return checked, err
+	}
+
+	// Check items off one at a time, in place, so changes made to the rest of the list meanwhile are kept
+	for _, item := range nativeItems(document) {
+		if item.Checked || !shoppingItemMatches(item.Name, names) {
+			continue
+		}
+
+		filter := bson.D{{"_id", document["_id"]}, {"items", bson.M{"$elemMatch": bson.M{"name": item.Name, "checked": false}}}}
+		updated, err := nc.update(ctx, filter, bson.M{"$set": bson.M{"items.$.checked": true}})
+		if err != nil {
+			return []string{}, err
+		} else if updated {
+			checked = append(checked, item.Name)
+		}
+	}
+	return checked, nil
+}
+
+func (nc *Native) GetCompletedShoppingItems() ([]string, error) {
+	completed := []string{}
+	document, err := nc.current(context.Background())
+	if err != nil || document == nil {
+		return completed, err
+	}
+
+	for _, item := range nativeItems(document) {
+		if item.Checked {
+			completed = append(completed, item.Name)
+		}
+	}
+	return completed, nil
+}
+
+func (nc *Native) RemoveFromShoppingList(itemText []string) error {
+	ctx := context.Background()
+	document, err := nc.current(ctx)
+	if err != nil || document == nil {
+		return err
+	}
+
+	update := bson.M{"$pull": bson.M{"items": bson.M{"name": bson.M{"$in": itemText}}}}
+	_, err = nc.update(ctx, bson.D{{"_id", document["_id"]}}, update)
+	return err
+}
+
+func (nc *Native) GetShoppingListItems(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+	document, err := nc.find(context.Background(), list)
+	if err != nil {
+		return nil, err
+	}
+	return nativeItems(document), nil
+}
+
+func (nc *Native) ArchiveShoppingList(list *config.ShoppingList) error {
+	ctx := context.Background()
+	document, err := nc.find(ctx, list)
+	if err != nil {
+		return err
+	}
+	_, err = nc.update(ctx, bson.D{{"_id", document["_id"]}}, bson.M{"$set": bson.M{"archived": true}})
+	return err
+}
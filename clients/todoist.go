@@ -0,0 +1,610 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+)
+
+var ErrorTodoistProjectNotFound = errors.New("project not found")
+
+// A failed Todoist API call, kept so not-found responses can be told apart
+type TodoistError struct {
+	Status int
+	Body   string
+}
+
+func (e *TodoistError) Error() string {
+	return fmt.Sprintf("todoist request failed: %d: %s", e.Status, e.Body)
+}
+
+func isTodoistNotFound(err error) bool {
+	var todoistErr *TodoistError
+	return errors.As(err, &todoistErr) && todoistErr.Status == http.StatusNotFound
+}
+
+type todoistProject struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type todoistDue struct {
+	Date     string `json:"date"`
+	Datetime string `json:"datetime,omitempty"`
+}
+
+type todoistTask struct {
+	ID          string      `json:"id"`
+	ProjectID   string      `json:"project_id"`
+	ParentID    *string     `json:"parent_id"`
+	Content     string      `json:"content"`
+	Labels      []string    `json:"labels"`
+	Due         *todoistDue `json:"due"`
+	URL         string      `json:"url"`
+	IsCompleted bool        `json:"is_completed"`
+	CreatedAt   string      `json:"created_at"`
+}
+
+// What the Sync API reports for a completed task, with the task itself when asked to annotate it
+type todoistCompletedTask struct {
+	TaskID     string       `json:"task_id"`
+	Content    string       `json:"content"`
+	ItemObject *todoistTask `json:"item_object"`
+}
+
+// The shopping list is a "Shopping List" task in the configured project, with a sub-task per item
+// labelled with its section. The REST API only lists active tasks, so checked off items come from the
+// Sync API instead.
+type Todoist struct {
+	Token       string
+	ProjectName string
+	LabelsStr   string
+	BaseURL     string
+	SyncURL     string
+	Client      *http.Client
+
+	// Resolved from the names above, looked up once and reused until Todoist reports them missing
+	mu        sync.Mutex
+	projectID string
+	task      *todoistTask
+}
+
+func NewTodoistClientWrapper(apiToken, projectName, labels string) *Todoist {
+	client := Todoist{
+		Token:       apiToken,
+		ProjectName: projectName,
+		LabelsStr:   labels,
+		BaseURL:     "https://api.todoist.com/rest/v2",
+		SyncURL:     "https://api.todoist.com/sync/v9",
+		Client:      &http.Client{Timeout: 30 * time.Second},
+	}
+	return &client
+}
+
+// Call the Todoist API, decoding the JSON response into out when given
+func (tc *Todoist) do(method, endpoint string, query url.Values, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		marshalled, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(marshalled)
+	}
+
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	request, err := http.NewRequest(method, endpoint, reader)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+tc.Token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := tc.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	contents, err := io.ReadAll(response.Body)
+	if err != nil {
+		return err
+	} else if response.StatusCode >= http.StatusMultipleChoices {
+		return &TodoistError{Status: response.StatusCode, Body: strings.TrimSpace(string(contents))}
+	} else if out == nil || len(contents) == 0 {
+		return nil
+	}
+	return json.Unmarshal(contents, out)
+}
+
+// Forget every cached ID so the next call looks them up by name again
+func (tc *Todoist) invalidate() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.projectID = ""
+	tc.task = nil
+}
+
+// The ID of the configured project, looked up by name only when it isn't cached
+func (tc *Todoist) getProject() (string, error) {
+	tc.mu.Lock()
+	projectID := tc.projectID
+	tc.mu.Unlock()
+	if projectID != "" {
+		return projectID, nil
+	}
+
+	var projects []todoistProject
+	err := tc.do("GET", tc.BaseURL+"/projects", nil, nil, &projects)
+	if err != nil {
+		return "", err
+	}
+	for _, p := range projects {
+		if p.Name == tc.ProjectName {
+			projectID = p.ID
+			break
+		}
+	}
+	if projectID == "" {
+		return "", fmt.Errorf("%w: %s", ErrorTodoistProjectNotFound, tc.ProjectName)
+	}
+
+	tc.mu.Lock()
+	tc.projectID = projectID
+	tc.mu.Unlock()
+	return projectID, nil
+}
+
+// Run fn against the project, looking it up again once if the cached ID has gone stale
+func (tc *Todoist) withProject(fn func(string) error) error {
+	tc.mu.Lock()
+	cached := tc.projectID != ""
+	tc.mu.Unlock()
+
+	projectID, err := tc.getProject()
+	if err != nil {
+		return err
+	}
+
+	err = fn(projectID)
+	if cached && isTodoistNotFound(err) {
+		tc.invalidate()
+		projectID, err = tc.getProject()
+		if err != nil {
+			return err
+		}
+		err = fn(projectID)
+	}
+	return err
+}
+
+func (tc *Todoist) labels() []string {
+	labels := []string{}
+	for _, label := range strings.Split(tc.LabelsStr, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// The active "Shopping List" task, or nil when there isn't one
+func (tc *Todoist) getTask() (*todoistTask, error) {
+	tc.mu.Lock()
+	cached := tc.task
+	tc.mu.Unlock()
+
+	// Get the task found last time, unless it's since been completed or deleted
+	if cached != nil {
+		var task todoistTask
+		err := tc.do("GET", tc.BaseURL+"/tasks/"+cached.ID, nil, nil, &task)
+		if err != nil && !isTodoistNotFound(err) {
+			return nil, err
+		} else if err == nil && !task.IsCompleted {
+			tc.mu.Lock()
+			tc.task = &task
+			tc.mu.Unlock()
+			return &task, nil
+		}
+
+		tc.mu.Lock()
+		tc.task = nil
+		tc.mu.Unlock()
+	}
+
+	// Get top-level task with content "Shopping List"
+	var task *todoistTask
+	err := tc.withProject(func(projectID string) error {
+		var tasks []todoistTask
+		err := tc.do("GET", tc.BaseURL+"/tasks", url.Values{"project_id": {projectID}}, nil, &tasks)
+		if err != nil {
+			return err
+		}
+		for i, t := range tasks {
+			if t.ParentID == nil && t.Content == "Shopping List" {
+				task = &tasks[i]
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if task != nil {
+		tc.mu.Lock()
+		tc.task = task
+		tc.mu.Unlock()
+	}
+	return task, nil
+}
+
+// The task behind a shopping list returned earlier
+func (tc *Todoist) taskFor(list *config.ShoppingList) (*todoistTask, error) {
+	tc.mu.Lock()
+	cached := tc.task
+	tc.mu.Unlock()
+
+	if cached != nil && cached.ID == list.ID {
+		return cached, nil
+	}
+
+	var task todoistTask
+	err := tc.do("GET", tc.BaseURL+"/tasks/"+list.ID, nil, nil, &task)
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// The task's unchecked sub-tasks
+func (tc *Todoist) activeItems(task *todoistTask) ([]todoistTask, error) {
+	var tasks []todoistTask
+	err := tc.do("GET", tc.BaseURL+"/tasks", url.Values{"project_id": {task.ProjectID}}, nil, &tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []todoistTask{}
+	for _, t := range tasks {
+		if t.ParentID != nil && *t.ParentID == task.ID {
+			items = append(items, t)
+		}
+	}
+	return items, nil
+}
+
+// The task's checked off sub-tasks, going by the parent of each completed task's annotated item so
+// other tasks completed in the project since are left out.
+func (tc *Todoist) completedItems(task *todoistTask) ([]todoistCompletedTask, error) {
+	query := url.Values{"annotate_items": {"true"}, "project_id": {task.ProjectID}}
+	if created, err := time.Parse(time.RFC3339, task.CreatedAt); err == nil {
+		query.Set("since", created.UTC().Format("2006-01-02T15:04:05"))
+	}
+
+	var completed struct {
+		Items []todoistCompletedTask `json:"items"`
+	}
+	err := tc.do("GET", tc.SyncURL+"/completed/get_all", query, nil, &completed)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []todoistCompletedTask{}
+	for _, item := range completed.Items {
+		if item.ItemObject != nil && item.ItemObject.ParentID != nil && *item.ItemObject.ParentID == task.ID {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
+
+func (tc *Todoist) addItem(task *todoistTask, item config.ShoppingItem) error {
+	body := map[string]interface{}{
+		"content":    item.Name,
+		"labels":     []string{shoppingSection(item)},
+		"parent_id":  task.ID,
+		"project_id": task.ProjectID,
+	}
+	return tc.do("POST", tc.BaseURL+"/tasks", nil, body, &todoistTask{})
+}
+
+// The section a sub-task was labelled with, skipping the labels every task gets
+func (tc *Todoist) section(labels []string) string {
+	for _, label := range labels {
+		if !utils.Contains(tc.labels(), label) {
+			return label
+		}
+	}
+	return config.ShoppingSectionDefault
+}
+
+func (tc *Todoist) GetShoppingList() (*config.ShoppingList, error) {
+	task, err := tc.getTask()
+	if err != nil || task == nil {
+		return nil, err
+	}
+
+	list := config.ShoppingList{ID: task.ID, Name: task.Content, URL: task.URL}
+	if task.Due != nil {
+		var due time.Time
+		if task.Due.Datetime != "" {
+			due, err = time.Parse(time.RFC3339, task.Due.Datetime)
+		} else {
+			due, err = time.Parse("2006-01-02", task.Due.Date)
+		}
+		if err == nil {
+			list.Due = &due
+		}
+	}
+	return &list, nil
+}
+
+func (tc *Todoist) CreateShoppingList(dueDate *time.Time, listItems []config.ShoppingItem) (string, error) {
+	var task todoistTask
+	err := tc.withProject(func(projectID string) error {
+		body := map[string]interface{}{
+			"content":     "Shopping List",
+			"description": "A list of items that must be bought in the near future.",
+			"labels":      tc.labels(),
+			"project_id":  projectID,
+		}
+		if dueDate != nil {
+			body["due_datetime"] = dueDate.UTC().Format(time.RFC3339)
+		}
+		return tc.do("POST", tc.BaseURL+"/tasks", nil, body, &task)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	tc.mu.Lock()
+	tc.task = &task
+	tc.mu.Unlock()
+
+	// Add a sub-task per item, grouped by section
+	sections, grouped := utils.GroupShoppingItems(listItems)
+	present := map[string]bool{}
+	for _, section := range sections {
+		for _, item := range grouped[section] {
+			key := strings.ToLower(utils.ShoppingItemName(item.Name))
+			if present[key] {
+				continue
+			}
+
+			err := tc.addItem(&task, item)
+			if err != nil {
+				return "", err
+			}
+			present[key] = true
+		}
+	}
+
+	return task.URL, nil
+}
+
+// Same rules as the Trello card: sub-tasks already on the list have their stage and section updated in
+// place, ones the expiration job added that are no longer needed are deleted, and anything added by hand
+// or checked off is left alone.
+func (tc *Todoist) AddToShoppingList(listItems []config.ShoppingItem) (string, error) {
+	task, err := tc.getTask()
+	if err != nil {
+		return "", err
+	} else if task == nil {
+		return "", ErrorShoppingListNotFound
+	}
+
+	active, err := tc.activeItems(task)
+	if err != nil {
+		return "", err
+	}
+	completed, err := tc.completedItems(task)
+	if err != nil {
+		return "", err
+	}
+
+	wanted := map[string]config.ShoppingItem{}
+	for _, item := range listItems {
+		wanted[strings.ToLower(utils.ShoppingItemName(item.Name))] = item
+	}
+
+	present := map[string]bool{}
+	for _, item := range completed {
+		// Waiting to be synced back into inventory
+		key := strings.ToLower(utils.ShoppingItemName(item.Content))
+		_, needed := wanted[key]
+		present[key] = present[key] || needed
+	}
+
+	for _, item := range active {
+		key := strings.ToLower(utils.ShoppingItemName(item.Content))
+		want, needed := wanted[key]
+		if !needed && utils.ShoppingItemStage(item.Content) == "" {
+			// Added by hand
+			continue
+		}
+
+		if !needed || present[key] {
+			// Restocked or a duplicate
+			err := tc.do("DELETE", tc.BaseURL+"/tasks/"+item.ID, nil, nil, nil)
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+
+		present[key] = true
+		if section := shoppingSection(want); item.Content != want.Name || tc.section(item.Labels) != section {
+			body := map[string]interface{}{"content": want.Name, "labels": []string{section}}
+			err := tc.do("POST", tc.BaseURL+"/tasks/"+item.ID, nil, body, &todoistTask{})
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	for _, item := range listItems {
+		key := strings.ToLower(utils.ShoppingItemName(item.Name))
+		if present[key] {
+			continue
+		}
+
+		err := tc.addItem(task, item)
+		if err != nil {
+			return "", err
+		}
+		present[key] = true
+	}
+
+	return task.URL, nil
+}
+
+// Complete the sub-tasks for the given ingredients, returning the text of each one checked off
+func (tc *Todoist) CheckOffShoppingList(names []string) ([]string, error) {
+	checked := []string{}
+	task, err := tc.getTask()
+	if err != nil {
+		return checked, err
+	} else if task == nil {
+		// No shopping list, nothing to check off
+		return checked, nil
+	}
+
+	active, err := tc.activeItems(task)
+	if err != nil {
+		return checked, err
+	}
+
+	for _, item := range active {
+		if !shoppingItemMatches(item.Content, names) {
+			continue
+		}
+
+		err := tc.do("POST", tc.BaseURL+"/tasks/"+item.ID+"/close", nil, nil, nil)
+		if err != nil {
+			return checked, err
+		}
+		checked = append(checked, item.Content)
+	}
+
+	return checked, nil
+}
+
+// The text of each sub-task checked off
+func (tc *Todoist) GetCompletedShoppingItems() ([]string, error) {
+	completed := []string{}
+	task, err := tc.getTask()
+	if err != nil {
+		return completed, err
+	} else if task == nil {
+		return completed, nil
+	}
+
+	items, err := tc.completedItems(task)
+	if err != nil {
+		return completed, err
+	}
+	for _, item := range items {
+		completed = append(completed, item.Content)
+	}
+	return completed, nil
+}
+
+// Delete the sub-tasks with the given text, checked off or not
+func (tc *Todoist) RemoveFromShoppingList(itemText []string) error {
+	task, err := tc.getTask()
+	if err != nil {
+		return err
+	} else if task == nil {
+		return nil
+	}
+
+	active, err := tc.activeItems(task)
+	if err != nil {
+		return err
+	}
+	completed, err := tc.completedItems(task)
+	if err != nil {
+		return err
+	}
+
+	ids := []string{}
+	for _, item := range active {
+		if utils.Contains(itemText, item.Content) {
+			ids = append(ids, item.ID)
+		}
+	}
+	for _, item := range completed {
+		if utils.Contains(itemText, item.Content) {
+			ids = append(ids, item.TaskID)
+		}
+	}
+
+	for _, id := range ids {
+		err := tc.do("DELETE", tc.BaseURL+"/tasks/"+id, nil, nil, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Every sub-task of the shopping list, unchecked ones first
+func (tc *Todoist) GetShoppingListItems(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+	task, err := tc.taskFor(list)
+	if err != nil {
+		return nil, err
+	}
+
+	active, err := tc.activeItems(task)
+	if err != nil {
+		return nil, err
+	}
+	completed, err := tc.completedItems(task)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []config.ShoppingItem{}
+	for _, item := range active {
+		items = append(items, config.ShoppingItem{Name: item.Content, Section: tc.section(item.Labels)})
+	}
+	for _, item := range completed {
+		section := config.ShoppingSectionDefault
+		if item.ItemObject != nil {
+			section = tc.section(item.ItemObject.Labels)
+		}
+		items = append(items, config.ShoppingItem{Name: item.Content, Checked: true, Section: section})
+	}
+	return items, nil
+}
+
+// Complete the shopping list task so the next list starts on a fresh one
+func (tc *Todoist) ArchiveShoppingList(list *config.ShoppingList) error {
+	err := tc.do("POST", tc.BaseURL+"/tasks/"+list.ID+"/close", nil, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	tc.mu.Lock()
+	if tc.task != nil && tc.task.ID == list.ID {
+		tc.task = nil
+	}
+	tc.mu.Unlock()
+	return nil
+}
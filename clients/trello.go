@@ -1,10 +1,21 @@
 package clients
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/adlio/trello"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
+)
+
+var (
+	ErrorTrelloBoardNotFound     = errors.New("board not found")
+	ErrorTrelloListNotFound      = errors.New("list not found")
+	ErrorTrelloChecklistNotFound = errors.New("checklist not found")
 )
 
 type Trello struct {
@@ -15,6 +26,13 @@ type Trello struct {
 	ListName  string
 	LabelsStr string
 	Client    *trello.Client
+
+	// IDs resolved from the names above, looked up once and reused until Trello reports them missing
+	mu       sync.Mutex
+	boardID  string
+	listID   string
+	card     *trello.Card
+	labelIDs map[string]string
 }
 
 func NewTrelloClientWrapper(apiKey, apiToken, memberID, boardName, listName, labels string) *Trello {
@@ -30,182 +48,541 @@ func NewTrelloClientWrapper(apiKey, apiToken, memberID, boardName, listName, lab
 	return &client
 }
 
-func (tc *Trello) GetShoppingList() (*trello.Card, error) {
-	var board *trello.Board
-	var list *trello.List
-	var card *trello.Card
+// Forget every cached ID so the next call looks them up by name again
+func (tc *Trello) invalidate() {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.boardID = ""
+	tc.listID = ""
+	tc.card = nil
+	tc.labelIDs = nil
+}
 
-	// Get Trello member
-	member, err := tc.Client.GetMember(tc.MemberID, trello.Defaults())
-	if err != nil {
-		return nil, err
+// The configured board and list, walking member -> boards -> lists only when their IDs aren't cached
+func (tc *Trello) getList() (*trello.Board, *trello.List, error) {
+	tc.mu.Lock()
+	boardID, listID := tc.boardID, tc.listID
+	tc.mu.Unlock()
+
+	if boardID == "" || listID == "" {
+		var board *trello.Board
+		var list *trello.List
+
+		// Get Trello member
+		member, err := tc.Client.GetMember(tc.MemberID, trello.Defaults())
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Get Board with given name
+		boards, err := member.GetBoards(trello.Defaults())
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, b := range boards {
+			if b.Name == tc.BoardName {
+				board = b
+				break
+			}
+		}
+		if board == nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrorTrelloBoardNotFound, tc.BoardName)
+		}
+
+		// Get List with given name
+		lists, err := board.GetLists(trello.Defaults())
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, l := range lists {
+			if l.Name == tc.ListName {
+				list = l
+				break
+			}
+		}
+		if list == nil {
+			return nil, nil, fmt.Errorf("%w: %s", ErrorTrelloListNotFound, tc.ListName)
+		}
+
+		tc.mu.Lock()
+		tc.boardID, tc.listID = board.ID, list.ID
+		tc.mu.Unlock()
+		return board, list, nil
 	}
 
-	// Get all boards
-	boards, err := member.GetBoards(trello.Defaults())
+	board := &trello.Board{ID: boardID}
+	board.SetClient(tc.Client)
+	list := &trello.List{ID: listID, IDBoard: boardID}
+	list.SetClient(tc.Client)
+	return board, list, nil
+}
+
+// Run fn against the board and list, looking them up again once if the cached IDs have gone stale
+func (tc *Trello) withList(fn func(*trello.Board, *trello.List) error) error {
+	tc.mu.Lock()
+	cached := tc.listID != ""
+	tc.mu.Unlock()
+
+	board, list, err := tc.getList()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	for _, b := range boards {
-		if b.Name == tc.BoardName {
-			board = b
-			break
+
+	err = fn(board, list)
+	if cached && trello.IsNotFound(err) {
+		tc.invalidate()
+		board, list, err = tc.getList()
+		if err != nil {
+			return err
 		}
+		err = fn(board, list)
 	}
+	return err
+}
 
-	// Get List with given name
-	lists, err := board.GetLists(trello.Defaults())
-	if err != nil {
+// The IDs of the named labels on the board, fetching the board's labels only once
+func (tc *Trello) getLabelIDs(board *trello.Board, names []string) ([]string, error) {
+	tc.mu.Lock()
+	labelIDs := tc.labelIDs
+	tc.mu.Unlock()
+
+	if labelIDs == nil {
+		labels, err := board.GetLabels(trello.Defaults())
+		if err != nil {
+			return nil, err
+		}
+
+		labelIDs = map[string]string{}
+		for _, l := range labels {
+			labelIDs[l.Name] = l.ID
+		}
+
+		tc.mu.Lock()
+		tc.labelIDs = labelIDs
+		tc.mu.Unlock()
+	}
+
+	ids := []string{}
+	for _, name := range names {
+		if id, ok := labelIDs[name]; ok {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+func (tc *Trello) GetShoppingList() (*config.ShoppingList, error) {
+	card, err := tc.getCard()
+	if err != nil || card == nil {
 		return nil, err
 	}
-	for _, l := range lists {
-		if l.Name == tc.ListName {
-			list = l
-			break
+	return &config.ShoppingList{ID: card.ID, Name: card.Name, URL: card.URL, Due: card.Due}, nil
+}
+
+// The "Shopping List" card, or nil when there isn't one
+func (tc *Trello) getCard() (*trello.Card, error) {
+	tc.mu.Lock()
+	cached := tc.card
+	tc.mu.Unlock()
+
+	// Get the card found last time, unless it's since been archived or deleted
+	if cached != nil {
+		card, err := tc.Client.GetCard(cached.ID, trello.Defaults())
+		if err != nil && !trello.IsNotFound(err) {
+			return nil, err
+		} else if err == nil && !card.Closed {
+			tc.mu.Lock()
+			tc.card = card
+			tc.mu.Unlock()
+			return card, nil
 		}
+
+		tc.mu.Lock()
+		tc.card = nil
+		tc.mu.Unlock()
 	}
 
 	// Get Card with name "Shopping List"
-	cards, err := list.GetCards()
+	var card *trello.Card
+	err := tc.withList(func(board *trello.Board, list *trello.List) error {
+		cards, err := list.GetCards()
+		if err != nil {
+			return err
+		}
+		for _, c := range cards {
+			if c.Name == "Shopping List" {
+				card = c
+				break
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	for _, c := range cards {
-		if c.Name == "Shopping List" {
-			card = c
-			break
-		}
-	}
 
+	if card != nil {
+		tc.mu.Lock()
+		tc.card = card
+		tc.mu.Unlock()
+	}
 	return card, nil
 }
 
-func (tc *Trello) CreateShoppingList(dueDate *time.Time, applyLabels []string, listItems []string) (string, error) {
-	var board *trello.Board
-	var list *trello.List
+// The card behind a shopping list returned earlier
+func (tc *Trello) cardFor(list *config.ShoppingList) (*trello.Card, error) {
+	tc.mu.Lock()
+	cached := tc.card
+	tc.mu.Unlock()
+
+	if cached != nil && cached.ID == list.ID {
+		return cached, nil
+	}
+	return tc.Client.GetCard(list.ID, trello.Defaults())
+}
+
+func (tc *Trello) CreateShoppingList(dueDate *time.Time, listItems []config.ShoppingItem) (string, error) {
+	var card *trello.Card
 	var labelIDs []string
+	applyLabels := strings.Split(tc.LabelsStr, ",")
+
+	err := tc.withList(func(board *trello.Board, list *trello.List) error {
+		// Get labels
+		var err error
+		labelIDs, err = tc.getLabelIDs(board, applyLabels)
+		if err != nil {
+			return err
+		}
 
-	// Get Trello member
-	member, err := tc.Client.GetMember(tc.MemberID, trello.Defaults())
+		// Construct card
+		card = &trello.Card{
+			Name: "Shopping List",
+			Desc: "A list of items that must be bought in the near future.",
+			Due:  dueDate,
+		}
+
+		// Add shopping list card
+		return list.AddCard(card, trello.Defaults())
+	})
 	if err != nil {
 		return "", err
 	}
 
-	// Get all boards
-	boards, err := member.GetBoards(trello.Defaults())
+	tc.mu.Lock()
+	tc.card = card
+	tc.mu.Unlock()
+
+	// Set the card's position in the list
+	err = card.SetPos(1.0)
 	if err != nil {
 		return "", err
 	}
-	for _, b := range boards {
-		if b.Name == tc.BoardName {
-			board = b
-			break
+
+	// Add a checklist per section, or an empty Groceries checklist to start with
+	sections, grouped := utils.GroupShoppingItems(listItems)
+	if len(sections) == 0 {
+		sections = []string{config.ShoppingSectionDefault}
+	}
+	present := map[string]bool{}
+	for _, section := range sections {
+		checklist, err := tc.Client.CreateChecklist(card, section, trello.Defaults())
+		if err != nil {
+			return "", err
+		}
+
+		// Add items to the checklist
+		for _, item := range grouped[section] {
+			key := strings.ToLower(utils.ShoppingItemName(item.Name))
+			if present[key] {
+				continue
+			}
+
+			_, err := checklist.CreateCheckItem(item.Name)
+			if err != nil {
+				return "", err
+			}
+			present[key] = true
 		}
 	}
 
-	// Get List with given name
-	lists, err := board.GetLists(trello.Defaults())
+	// Add labels to the card
+	for _, labelID := range labelIDs {
+		err = card.AddIDLabel(labelID)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return card.URL, nil
+}
+
+// Bring the card's checklists in line with the given items, one checklist per section. Items already on
+// the list have their stage updated in place (and are moved when their section changed) rather than being
+// added again, and items this job added earlier that are no longer needed (restocked since) are removed.
+// Items added by hand and checked off items are left alone.
+func (tc *Trello) AddToShoppingList(listItems []config.ShoppingItem) (string, error) {
+	card, err := tc.getCard()
+	if err != nil {
+		return "", err
+	}
+
+	checklists, err := tc.getChecklists(card)
 	if err != nil {
 		return "", err
 	}
-	for _, l := range lists {
-		if l.Name == tc.ListName {
-			list = l
-			break
+
+	// The checklist for a section, adding it to the card when it's the section's first item
+	sectionChecklists := map[string]*trello.Checklist{}
+	for _, checklist := range checklists {
+		if _, ok := sectionChecklists[checklist.Name]; !ok {
+			sectionChecklists[checklist.Name] = checklist
+		}
+	}
+	checklistFor := func(section string) (*trello.Checklist, error) {
+		if checklist, ok := sectionChecklists[section]; ok {
+			return checklist, nil
+		}
+		checklist, err := tc.Client.CreateChecklist(card, section, trello.Defaults())
+		if err != nil {
+			return nil, err
 		}
+		sectionChecklists[section] = checklist
+		return checklist, nil
 	}
 
-	// Get labels
-	labels, err := board.GetLabels(trello.Defaults())
-	if err != nil {
-		return "", err
+	wanted := map[string]config.ShoppingItem{}
+	for _, item := range listItems {
+		wanted[strings.ToLower(utils.ShoppingItemName(item.Name))] = item
 	}
-	for _, l := range labels {
-		for _, al := range applyLabels {
-			if l.Name == al {
-				labelIDs = append(labelIDs, l.ID)
+
+	present := map[string]bool{}
+	for _, checklist := range checklists {
+		for _, item := range checklist.CheckItems {
+			key := strings.ToLower(utils.ShoppingItemName(item.Name))
+			want, needed := wanted[key]
+			if item.State == "complete" {
+				// Waiting to be synced back into inventory
+				present[key] = present[key] || needed
+				continue
+			} else if !needed && utils.ShoppingItemStage(item.Name) == "" {
+				// Added by hand
+				continue
+			}
+
+			if !needed || present[key] {
+				// Restocked or a duplicate
+				path := fmt.Sprintf("checklists/%s/checkItems/%s", checklist.ID, item.ID)
+				err := tc.Client.Delete(path, trello.Defaults(), &trello.CheckItem{})
+				if err != nil {
+					return "", err
+				}
+				continue
+			}
+
+			present[key] = true
+			args := trello.Arguments{}
+			if item.Name != want.Name {
+				args["name"] = want.Name
+			}
+			if section := shoppingSection(want); checklist.Name != section {
+				target, err := checklistFor(section)
+				if err != nil {
+					return "", err
+				}
+				args["idChecklist"] = target.ID
+			}
+			if len(args) > 0 {
+				path := fmt.Sprintf("cards/%s/checkItem/%s", card.ID, item.ID)
+				err := tc.Client.Put(path, args, &trello.CheckItem{})
+				if err != nil {
+					return "", err
+				}
 			}
 		}
 	}
 
-	// Construct card
-	cardName := "Shopping List"
-	card := &trello.Card{
-		Name: cardName,
-		Desc: "A list of items that must be bought in the near future.",
-		Due:  dueDate,
+	for _, item := range listItems {
+		key := strings.ToLower(utils.ShoppingItemName(item.Name))
+		if present[key] {
+			continue
+		}
+
+		checklist, err := checklistFor(shoppingSection(item))
+		if err != nil {
+			return "", err
+		}
+		_, err = checklist.CreateCheckItem(item.Name)
+		if err != nil {
+			return "", err
+		}
+		present[key] = true
 	}
 
-	// Add shopping list card
-	err = list.AddCard(card, trello.Defaults())
+	return card.URL, nil
+}
+
+// Mark the items for the given ingredients complete, returning the text of each item checked off
+func (tc *Trello) CheckOffShoppingList(names []string) ([]string, error) {
+	checked := []string{}
+	card, err := tc.getCard()
 	if err != nil {
-		return "", err
+		return checked, err
+	} else if card == nil {
+		// No shopping list, nothing to check off
+		return checked, nil
 	}
 
-	// Set the card's position in the list
-	err = card.SetPos(1.0)
+	checklists, err := tc.getChecklists(card)
 	if err != nil {
-		return "", err
+		return checked, err
 	}
 
-	// Add checklist to card
-	checklist, err := tc.Client.CreateChecklist(card, "Groceries", trello.Defaults())
-	if err != nil {
-		return "", err
-	} else {
-		// Add items to the checklist
-		for _, item := range listItems {
-			_, err := checklist.CreateCheckItem(item)
+	for _, checklist := range checklists {
+		for _, item := range checklist.CheckItems {
+			if item.State == "complete" || !shoppingItemMatches(item.Name, names) {
+				continue
+			}
+
+			path := fmt.Sprintf("cards/%s/checkItem/%s", card.ID, item.ID)
+			err := tc.Client.Put(path, trello.Arguments{"state": "complete"}, &trello.CheckItem{})
 			if err != nil {
-				return "", err
+				return checked, err
 			}
+			checked = append(checked, item.Name)
 		}
 	}
 
-	// Add labels to the card
-	for _, labelID := range labelIDs {
-		err = card.AddIDLabel(labelID)
-		if err != nil {
-			return "", err
-		}
+	return checked, nil
+}
+
+// Every item on the card, in the section named by its checklist
+func (tc *Trello) GetShoppingListItems(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+	card, err := tc.cardFor(list)
+	if err != nil {
+		return nil, err
 	}
 
-	return card.URL, nil
+	checklists, err := tc.getChecklists(card)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []config.ShoppingItem{}
+	for _, checklist := range checklists {
+		for _, item := range checklist.CheckItems {
+			items = append(items, config.ShoppingItem{Name: item.Name, Checked: item.State == "complete", Section: checklist.Name})
+		}
+	}
+	return items, nil
 }
 
-func (tc *Trello) AddToShoppingList(itemText []string) (string, error) {
-	card, err := tc.GetShoppingList()
+// Archive the card so the next shopping list starts on a fresh one
+func (tc *Trello) ArchiveShoppingList(list *config.ShoppingList) error {
+	card, err := tc.cardFor(list)
 	if err != nil {
-		return "", err
+		return err
 	}
 
+	err = card.Archive()
+	if err != nil {
+		return err
+	}
+
+	tc.mu.Lock()
+	if tc.card != nil && tc.card.ID == card.ID {
+		tc.card = nil
+	}
+	tc.mu.Unlock()
+	return nil
+}
+
+// The card's checklists, one per section of the shopping list
+func (tc *Trello) getChecklists(card *trello.Card) ([]*trello.Checklist, error) {
 	checklistIDs := card.IDCheckLists
 	if len(checklistIDs) == 0 {
-		return "", fmt.Errorf("no checklists attached to card")
+		return nil, fmt.Errorf("%w: no checklists attached to card", ErrorTrelloChecklistNotFound)
 	}
 
-	var checklist *trello.Checklist
+	checklists := []*trello.Checklist{}
 	for _, cid := range checklistIDs {
 		c, err := tc.Client.GetChecklist(cid, trello.Defaults())
 		if err != nil {
-			return "", err
-		} else if c.Name == "Groceries" {
-			checklist = c
-			break
+			return nil, err
+		}
+		checklists = append(checklists, c)
+	}
+	return checklists, nil
+}
+
+// The text of each item checked off the card
+func (tc *Trello) GetCompletedShoppingItems() ([]string, error) {
+	completed := []string{}
+	card, err := tc.getCard()
+	if err != nil {
+		return completed, err
+	} else if card == nil {
+		return completed, nil
+	}
+
+	checklists, err := tc.getChecklists(card)
+	if err != nil {
+		return completed, err
+	}
+
+	for _, checklist := range checklists {
+		for _, item := range checklist.CheckItems {
+			if item.State == "complete" {
+				completed = append(completed, item.Name)
+			}
 		}
 	}
+	return completed, nil
+}
+
+// Delete the checklist items with the given text, whichever section they're in
+func (tc *Trello) RemoveFromShoppingList(itemText []string) error {
+	card, err := tc.getCard()
+	if err != nil {
+		return err
+	} else if card == nil {
+		return nil
+	}
 
-	if checklist == nil {
-		return "", fmt.Errorf("%s checklist not found", "Groceries")
-	} else {
-		checklist.CheckItems = []trello.CheckItem{}
-		for _, text := range itemText {
-			_, err := checklist.CreateCheckItem(text)
+	checklists, err := tc.getChecklists(card)
+	if err != nil {
+		return err
+	}
+
+	for _, checklist := range checklists {
+		for _, item := range checklist.CheckItems {
+			if !utils.Contains(itemText, item.Name) {
+				continue
+			}
+
+			path := fmt.Sprintf("checklists/%s/checkItems/%s", checklist.ID, item.ID)
+			err := tc.Client.Delete(path, trello.Defaults(), &trello.CheckItem{})
 			if err != nil {
-				return "", err
+				return err
 			}
 		}
 	}
+	return nil
+}
 
-	return card.URL, nil
+func shoppingItemMatches(text string, names []string) bool {
+	itemName := utils.ShoppingItemName(text)
+	for _, name := range names {
+		if strings.EqualFold(itemName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// The section an item is listed under, the default one when it doesn't say
+func shoppingSection(item config.ShoppingItem) string {
+	if item.Section == "" {
+		return config.ShoppingSectionDefault
+	}
+	return item.Section
 }
@@ -2,9 +2,12 @@ package clients
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/twilio/twilio-go"
 	openapi "github.com/twilio/twilio-go/rest/api/v2010"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/utils"
 )
 
 type TwilioInterface interface {
@@ -32,7 +35,8 @@ func NewTwilioClientWrapper(accountSid, authToken, phoneFrom, phoneTo string) *T
 	return &client
 }
 
-func (tc *Twilio) ComposeMessage(quantity, quantityExpired int, url string) string {
+// A summary of what's expiring, followed by what to buy grouped by section (one line per store/aisle)
+func (tc *Twilio) ComposeMessage(quantity, quantityExpired int, url string, items []config.ShoppingItem) string {
 	var message string
 	if quantity == 1 && quantityExpired >= 1 {
 		message = fmt.Sprintf("%d item expiring and %d already expired! View shopping list: %s", quantity, quantityExpired, url)
@@ -47,7 +51,21 @@ func (tc *Twilio) ComposeMessage(quantity, quantityExpired int, url string) stri
 	} else if quantity > 1 && quantityExpired <= 0 {
 		message = fmt.Sprintf("%d items expiring! View shopping list: %s", quantity, url)
 	}
-	return message
+
+	if message == "" || len(items) == 0 {
+		return message
+	}
+
+	sections, grouped := utils.GroupShoppingItems(items)
+	lines := []string{message, ""}
+	for _, section := range sections {
+		names := []string{}
+		for _, item := range grouped[section] {
+			names = append(names, utils.ShoppingItemName(item.Name))
+		}
+		lines = append(lines, fmt.Sprintf("%s: %s", section, strings.Join(names, ", ")))
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (tc *Twilio) SendMessage(phoneFrom, phoneTo, message string) (string, error) {
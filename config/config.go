@@ -4,15 +4,23 @@ import (
 	"context"
 	"time"
 
-	"github.com/adlio/trello"
 	"github.com/go-co-op/gocron"
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+const MongoCollectionEquipment = "equipment"
+const MongoCollectionHistory = "history"
 const MongoCollectionIngredients = "ingredients"
+const MongoCollectionLocations = "locations"
+const MongoCollectionMealPlans = "mealplans"
+const MongoCollectionMembers = "members"
+const MongoCollectionPrices = "prices"
 const MongoCollectionRecipes = "recipes"
+const MongoCollectionShoppingHistory = "shoppinghistory"
+const MongoCollectionShoppingLists = "shoppinglists"
+const MongoCollectionShoppingRestocked = "shoppingrestocked"
 
 type MongoHandle interface {
 	Collections(context.Context) ([]string, error)
@@ -25,14 +33,38 @@ type MongoHandle interface {
 	DeleteManyDocuments(context.Context, string, bson.M) (int64, error)
 }
 
-type TrelloHandle interface {
-	GetShoppingList() (*trello.Card, error)
-	CreateShoppingList(*time.Time, []string, []string) (string, error)
-	AddToShoppingList([]string) (string, error)
+// A shopping list as every provider sees it
+type ShoppingList struct {
+	ID   string
+	Name string
+	URL  string
+	Due  *time.Time
+}
+
+// Items are grouped into sections by where they're bought, e.g. "Costco - Produce"
+type ShoppingItem struct {
+	Name    string
+	Checked bool
+	Section string
+}
+
+// The section for items without a store or aisle
+const ShoppingSectionDefault = "Groceries"
+
+// Where the shopping list is kept: Trello, Forage's own shoppinglists collection, ...
+type ShoppingListHandle interface {
+	GetShoppingList() (*ShoppingList, error)
+	CreateShoppingList(*time.Time, []ShoppingItem) (string, error)
+	AddToShoppingList([]ShoppingItem) (string, error)
+	CheckOffShoppingList([]string) ([]string, error)
+	GetCompletedShoppingItems() ([]string, error)
+	RemoveFromShoppingList([]string) error
+	GetShoppingListItems(*ShoppingList) ([]ShoppingItem, error)
+	ArchiveShoppingList(*ShoppingList) error
 }
 
 type TwilioHandle interface {
-	ComposeMessage(int, int, string) string
+	ComposeMessage(int, int, string, []ShoppingItem) string
 	SendMessage(string, string, string) (string, error)
 }
 
@@ -42,11 +74,16 @@ type Configuration struct {
 	Interval       int
 	Silence        bool
 	Time           string
+	ThawTime       string
+	SyncInterval   time.Duration
 	Timezone       string
 	LogrusLevel    logrus.Level
 	ListenSocket   string
 	Scheduler      *gocron.Scheduler
-	Mongo          MongoHandle  //*clients.Mongo
-	Trello         TrelloHandle //*clients.Trello
-	Twilio         TwilioHandle //*clients.Twilio
+	TrelloSecret   string
+	TrelloCallback string
+	CalendarToken  string
+	Mongo          MongoHandle        //*clients.Mongo
+	ShoppingList   ShoppingListHandle //*clients.Trello, *clients.Todoist, *clients.CalDAV, *clients.Native
+	Twilio         TwilioHandle       //*clients.Twilio
 }
@@ -76,6 +76,37 @@ func main() {
 		log.WithFields(logrus.Fields{"time": forageTime}).Debug("Using specified expiration check time")
 	}
 
+	forageThawTime := os.Getenv("FORAGE_THAW_TIME")
+	if forageThawTime == "" {
+		// Default case
+		forageThawTime = "20:00"
+		log.WithFields(logrus.Fields{"time": forageThawTime}).Info("Using default thaw reminder time")
+	} else {
+		log.WithFields(logrus.Fields{"time": forageThawTime}).Debug("Using specified thaw reminder time")
+	}
+
+	syncIntervalStr := os.Getenv("FORAGE_SYNC_INTERVAL")
+	if syncIntervalStr == "" {
+		// Default case
+		syncIntervalStr = "15m"
+		log.WithFields(logrus.Fields{"interval": syncIntervalStr}).Info("Using default shopping list sync interval")
+	} else {
+		log.WithFields(logrus.Fields{"interval": syncIntervalStr}).Debug("Using specified shopping list sync interval")
+	}
+
+	shoppingListProvider := os.Getenv("FORAGE_SHOPPING_LIST")
+	if shoppingListProvider == "" && os.Getenv("TRELLO_API_KEY") == "" {
+		// Default case, without Trello
+		shoppingListProvider = "native"
+		log.WithFields(logrus.Fields{"provider": shoppingListProvider}).Info("Using default shopping list provider")
+	} else if shoppingListProvider == "" {
+		// Default case
+		shoppingListProvider = "trello"
+		log.WithFields(logrus.Fields{"provider": shoppingListProvider}).Info("Using default shopping list provider")
+	} else {
+		log.WithFields(logrus.Fields{"provider": shoppingListProvider}).Debug("Using specified shopping list provider")
+	}
+
 	forageTimezone := os.Getenv("FORAGE_TIMEZONE")
 	if forageTimezone == "" {
 		// Default case
@@ -106,6 +137,13 @@ func main() {
 		log.WithFields(logrus.Fields{"interval": forageInterval}).Debug("Parsed expiration interval")
 	}
 
+	forageSyncInterval, err := time.ParseDuration(syncIntervalStr)
+	if err != nil {
+		log.WithFields(logrus.Fields{"interval": syncIntervalStr}).WithError(err).Fatal("Failed to parse shopping list sync interval")
+	} else {
+		log.WithFields(logrus.Fields{"interval": forageSyncInterval}).Debug("Parsed shopping list sync interval")
+	}
+
 	mongoUri := os.Getenv("MONGO_URI")
 	listenSocket := os.Getenv("LISTEN_SOCKET")
 	trelloMemberID := os.Getenv("TRELLO_MEMBER")
@@ -114,11 +152,28 @@ func main() {
 	trelloLabels := os.Getenv("TRELLO_LABELS")
 	trelloApiKey := os.Getenv("TRELLO_API_KEY")
 	trelloApiToken := os.Getenv("TRELLO_API_TOKEN")
+	trelloApiSecret := os.Getenv("TRELLO_API_SECRET")
+	trelloWebhookUrl := os.Getenv("TRELLO_WEBHOOK_URL")
+	calendarToken := os.Getenv("FORAGE_CALENDAR_TOKEN")
+	shoppingListUrl := os.Getenv("FORAGE_SHOPPING_LIST_URL")
+	todoistApiToken := os.Getenv("TODOIST_API_TOKEN")
+	todoistProject := os.Getenv("TODOIST_PROJECT")
+	todoistLabels := os.Getenv("TODOIST_LABELS")
+	caldavUrl := os.Getenv("CALDAV_URL")
+	caldavUsername := os.Getenv("CALDAV_USERNAME")
+	caldavPassword := os.Getenv("CALDAV_PASSWORD")
+	caldavLabels := os.Getenv("CALDAV_LABELS")
 	twilioAccountSid := os.Getenv("TWILIO_ACCOUNT_SID")
 	twilioAuthToken := os.Getenv("TWILIO_AUTH_TOKEN")
 	twilioPhoneFrom := os.Getenv("TWILIO_PHONE_FROM")
 	twilioPhoneTo := os.Getenv("TWILIO_PHONE_TO")
 
+	// Only a shopping list on Trello can be restocked through its webhook
+	if trelloApiSecret != "" && shoppingListProvider != "trello" {
+		log.WithFields(logrus.Fields{"provider": shoppingListProvider}).Warn("Ignoring Trello API secret, the shopping list isn't on Trello")
+		trelloApiSecret = ""
+	}
+
 	// Initialize context/timeout
 	ctx, cancel := context.WithTimeout(context.Background(), forageContextTimeout)
 	log.WithFields(logrus.Fields{"timeout": forageContextTimeout}).Info("Initialized context")
@@ -131,7 +186,20 @@ func main() {
 	} else {
 		defer mongoClient.Client.Disconnect(ctx)
 	}
-	trelloClient := clients.NewTrelloClientWrapper(trelloApiKey, trelloApiToken, trelloMemberID, trelloBoardName, trelloListName, trelloLabels)
+
+	var shoppingList config.ShoppingListHandle
+	switch shoppingListProvider {
+	case "native":
+		shoppingList = clients.NewNativeClientWrapper(mongoClient, shoppingListUrl)
+	case "trello":
+		shoppingList = clients.NewTrelloClientWrapper(trelloApiKey, trelloApiToken, trelloMemberID, trelloBoardName, trelloListName, trelloLabels)
+	case "todoist":
+		shoppingList = clients.NewTodoistClientWrapper(todoistApiToken, todoistProject, todoistLabels)
+	case "caldav":
+		shoppingList = clients.NewCalDAVClientWrapper(caldavUrl, caldavUsername, caldavPassword, caldavLabels)
+	default:
+		log.WithFields(logrus.Fields{"provider": shoppingListProvider}).Fatal("Unknown shopping list provider")
+	}
 	twilioClient := clients.NewTwilioClientWrapper(twilioAccountSid, twilioAuthToken, twilioPhoneFrom, twilioPhoneTo)
 
 	config := config.Configuration{
@@ -139,11 +207,16 @@ func main() {
 		Lookahead:      forageLookahead,
 		Interval:       forageInterval,
 		Time:           forageTime,
+		ThawTime:       forageThawTime,
+		SyncInterval:   forageSyncInterval,
 		Timezone:       forageTimezone,
 		LogrusLevel:    level,
 		ListenSocket:   listenSocket,
+		TrelloSecret:   trelloApiSecret,
+		TrelloCallback: trelloWebhookUrl,
+		CalendarToken:  calendarToken,
 		Mongo:          mongoClient,
-		Trello:         trelloClient,
+		ShoppingList:   shoppingList,
 		Twilio:         twilioClient,
 	}
 
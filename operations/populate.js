@@ -5,6 +5,24 @@ let resultIngredientsDrop = database.ingredients.drop()
 print('Ingredients Dropped:', resultIngredientsDrop)
 let resultRecipesDrop = database.recipes.drop()
 print('Recipes Dropped:', resultRecipesDrop)
+let resultMealPlansDrop = database.mealplans.drop()
+print('Meal Plans Dropped:', resultMealPlansDrop)
+let resultMembersDrop = database.members.drop()
+print('Members Dropped:', resultMembersDrop)
+let resultHistoryDrop = database.history.drop()
+print('History Dropped:', resultHistoryDrop)
+let resultEquipmentDrop = database.equipment.drop()
+print('Equipment Dropped:', resultEquipmentDrop)
+let resultLocationsDrop = database.locations.drop()
+print('Locations Dropped:', resultLocationsDrop)
+let resultShoppingHistoryDrop = database.shoppinghistory.drop()
+print('Shopping History Dropped:', resultShoppingHistoryDrop)
+let resultShoppingListsDrop = database.shoppinglists.drop()
+print('Shopping Lists Dropped:', resultShoppingListsDrop)
+let resultShoppingRestockedDrop = database.shoppingrestocked.drop()
+print('Shopping Restocked Dropped:', resultShoppingRestockedDrop)
+let resultPricesDrop = database.prices.drop()
+print('Prices Dropped:', resultPricesDrop)
 
 // Production will include expiration date
 let dateUpdated = new Date()
@@ -46,6 +64,7 @@ let ingredients = [
         name: 'Aioli'
     },
     {
+        aisle: 'Produce',
         amount: {
             value: 5,
             unit: 'count'
@@ -56,7 +75,8 @@ let ingredients = [
                 unit: 'week'
             }
         },
-        name: 'Apples'
+        name: 'Apples',
+        store: 'Aldi'
     },
     {
         amount: {
@@ -116,6 +136,7 @@ let ingredients = [
         name: 'Bagel'
     },
     {
+        aisle: 'Produce',
         amount: {
             value: 4,
             unit: 'count'
@@ -126,7 +147,8 @@ let ingredients = [
                 unit: 'day'
             }
         },
-        name: 'Bananas'
+        name: 'Bananas',
+        store: 'Aldi'
     },
     {
         amount: {
@@ -1734,6 +1756,8 @@ let recipes = [
 //    { name: 'Bacon, Egg, and Cheese' },
     {
         name: 'Chicken & Vegetable Quinoa',
+        prepTime: 15, // minutes
+        cookTime: 30, // minutes
         ingredients: [
             "Bell Peppers",
             "Chicken",
@@ -1753,6 +1777,8 @@ let recipes = [
     },
     {
         name: 'Chicken Fried Rice',
+        prepTime: 10, // minutes
+        cookTime: 15, // minutes
         ingredients: [
             "Carrots",
             "Chicken",
@@ -1767,6 +1793,8 @@ let recipes = [
 //    { name: 'Gyoza' },
     {
         name: 'Hamburgers',
+        prepTime: 10, // minutes
+        cookTime: 15, // minutes
         ingredients: [
             "Beef",
             "Cheese", // American
@@ -1804,6 +1832,35 @@ if (recipes.length != recipeIDs.length) {
     // error
 }
 
+// Meal plans are added later via the API: { date: <epoch ms>, recipes: [<name> | { name, servings }] }
+database.createCollection('mealplans')
+
+// Household members are added later via the API: { name, allergens, diets, dislikes, favorites }
+database.createCollection('members')
+
+// Cook history is recorded via the API: { recipe, name, date, source, servings, rating, notes }
+database.createCollection('history')
+
+// Kitchen equipment is added later via the API: { name, available, status }
+// Recipes list what they need by name in "requires"; unlisted equipment counts as unavailable
+database.createCollection('equipment')
+
+// Storage locations are added later via the API: { name, type: 'pantry' | 'refrigerator' | 'freezer', shelves, capacity }
+// Ingredients reference one by id in "location" (and optionally a "shelf"), "storeIn" follows the location's type
+database.createCollection('locations')
+
+// Past shopping lists are recorded when a Trello card rolls over: { name, url, due, archived, reason: 'completed' | 'overdue', items: [{ name, checked, section }] }
+database.createCollection('shoppinghistory')
+
+// The native shopping list provider keeps its lists here, at most one not archived: { name, due, created, updated, archived, items: [{ name, checked, section }] }
+database.createCollection('shoppinglists')
+
+// Items restocked and taken off the open Trello card, counted when it rolls over then removed: { listId, restocked: [name] }
+database.createCollection('shoppingrestocked')
+
+// Prices are recorded from shopping trips and receipts: { name, price, amount: { value, unit }, store, date, source: 'trip' | 'receipt' }
+database.createCollection('prices')
+
 /*
 let resultFind = database.ingredients.find()
 print('Find:', resultFind)
@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-cromwell/forage/clients"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/tests/mocks"
+)
+
+func TestCalDAVClient(t *testing.T) {
+	logrus.SetOutput(os.Stdout)
+
+	due := time.Date(2022, 1, 8, 0, 0, 0, 0, time.UTC)
+	newCalDAV := func() (*mocks.MockCalDAV, *httptest.Server, *clients.CalDAV) {
+		caldav := &mocks.MockCalDAV{Username: "forage", Password: "secret"}
+		server := httptest.NewServer(caldav)
+		client := mocks.NewCalDAVClientWrapper(server, "forage", "secret", "Forage,Food")
+		return caldav, server, client
+	}
+	// The summaries of every task under the shopping list, completed ones marked with a "✓"
+	itemSummaries := func(caldav *mocks.MockCalDAV) []string {
+		summaries := []string{}
+		for _, data := range caldav.Resources() {
+			if !strings.Contains(data, "RELATED-TO") || strings.Contains(data, "STATUS:CANCELLED") {
+				continue
+			}
+			summary := strings.SplitN(strings.SplitN(data, "SUMMARY:", 2)[1], "\r\n", 2)[0]
+			if strings.Contains(data, "STATUS:COMPLETED") {
+				summary += " ✓"
+			}
+			summaries = append(summaries, summary)
+		}
+		return summaries
+	}
+
+	t.Run("NewCalDAVClientWrapper", func(t *testing.T) {
+		client := clients.NewCalDAVClientWrapper("https://cloud.example.com/remote.php/dav/calendars/forage/shopping", "", "", "")
+		require.NotNil(t, client)
+		require.Equal(t, "https://cloud.example.com/remote.php/dav/calendars/forage/shopping/", client.URL)
+	})
+
+	t.Run("GetShoppingList", func(t *testing.T) {
+		t.Run("None", func(t *testing.T) {
+			_, server, client := newCalDAV()
+			defer server.Close()
+
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("ErrorUnauthorized", func(t *testing.T) {
+			_, server, _ := newCalDAV()
+			defer server.Close()
+			client := mocks.NewCalDAVClientWrapper(server, "forage", "wrong", "")
+
+			list, err := client.GetShoppingList()
+			require.Error(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("FromAnotherClient", func(t *testing.T) {
+			caldav, server, client := newCalDAV()
+			defer server.Close()
+			caldav.Put("/calendars/forage/shopping/list.ics", "BEGIN:VCALENDAR\r\nBEGIN:VTODO\r\nUID:list\r\nSUMMARY:Shopping List\r\nDUE;TZID=America/New_York:20220107T190000\r\nEND:VTODO\r\nEND:VCALENDAR\r\n")
+
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Equal(t, "list", list.ID)
+			require.True(t, time.Date(2022, 1, 8, 0, 0, 0, 0, time.UTC).Equal(*list.Due))
+		})
+	})
+
+	t.Run("CreateShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			caldav, server, client := newCalDAV()
+			defer server.Close()
+
+			url, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "milk (expired)"}, {Name: "Eggs (expired)"}})
+			require.NoError(t, err)
+			require.Equal(t, client.URL, url)
+			require.Equal(t, []string{"Milk (expiring)", "Eggs (expired)"}, itemSummaries(caldav))
+			for _, data := range caldav.Resources() {
+				require.Contains(t, data, "DUE:20220108T000000Z\r\n")
+				require.Contains(t, data, "CATEGORIES:Forage,Food")
+			}
+
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Equal(t, "Shopping List", list.Name)
+			require.True(t, due.Equal(*list.Due))
+		})
+
+		t.Run("Error", func(t *testing.T) {
+			caldav, server, client := newCalDAV()
+			defer server.Close()
+			caldav.FailWith = http.StatusInsufficientStorage
+
+			url, err := client.CreateShoppingList(&due, []config.ShoppingItem{})
+			require.Error(t, err)
+			require.Empty(t, url)
+		})
+	})
+
+	t.Run("AddToShoppingList", func(t *testing.T) {
+		t.Run("Reconcile", func(t *testing.T) {
+			caldav, server, client := newCalDAV()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "Bread (expiring)"}, {Name: "Paper Towels"}, {Name: "Eggs (expired)"}})
+			require.NoError(t, err)
+			_, err = client.CheckOffShoppingList([]string{"Eggs"})
+			require.NoError(t, err)
+
+			_, err = client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expired)"}, {Name: "Eggs (expired)"}, {Name: "Cheese (expiring)"}})
+			require.NoError(t, err)
+			require.Equal(t, []string{"Milk (expired)", "Paper Towels", "Eggs (expired) ✓", "Cheese (expiring)"}, itemSummaries(caldav))
+		})
+
+		t.Run("Sections", func(t *testing.T) {
+			_, server, client := newCalDAV()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}})
+			require.NoError(t, err)
+
+			_, err = client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Bread (expiring)", Section: "Bakery"}})
+			require.NoError(t, err)
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			items, err := client.GetShoppingListItems(list)
+			require.NoError(t, err)
+			require.Equal(t, []config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Bread (expiring)", Section: "Bakery"}}, items)
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, server, client := newCalDAV()
+			defer server.Close()
+
+			_, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk"}})
+			require.ErrorIs(t, err, clients.ErrorShoppingListNotFound)
+		})
+	})
+
+	t.Run("CheckOffShoppingList", func(t *testing.T) {
+		caldav, server, client := newCalDAV()
+		defer server.Close()
+		_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "Eggs (expired)"}})
+		require.NoError(t, err)
+
+		checked, err := client.CheckOffShoppingList([]string{"milk"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Milk (expiring)"}, checked)
+		require.Contains(t, caldav.Resources()[1], "PERCENT-COMPLETE:100\r\n")
+
+		completed, err := client.GetCompletedShoppingItems()
+		require.NoError(t, err)
+		require.Equal(t, []string{"Milk (expiring)"}, completed)
+	})
+
+	t.Run("RemoveFromShoppingList", func(t *testing.T) {
+		caldav, server, client := newCalDAV()
+		defer server.Close()
+		_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "Eggs (expired)"}, {Name: "Bread"}})
+		require.NoError(t, err)
+
+		err = client.RemoveFromShoppingList([]string{"Eggs (expired)", "Bread"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Milk (expiring)"}, itemSummaries(caldav))
+	})
+
+	t.Run("GetShoppingListItems", func(t *testing.T) {
+		_, server, client := newCalDAV()
+		defer server.Close()
+		_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Eggs (expired)"}})
+		require.NoError(t, err)
+		_, err = client.CheckOffShoppingList([]string{"Eggs"})
+		require.NoError(t, err)
+		list, err := client.GetShoppingList()
+		require.NoError(t, err)
+
+		items, err := client.GetShoppingListItems(list)
+		require.NoError(t, err)
+		require.Equal(t, []config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Eggs (expired)", Checked: true, Section: "Groceries"}}, items)
+	})
+
+	t.Run("ArchiveShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			caldav, server, client := newCalDAV()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "Eggs (expired)"}})
+			require.NoError(t, err)
+			_, err = client.CheckOffShoppingList([]string{"Eggs"})
+			require.NoError(t, err)
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+
+			err = client.ArchiveShoppingList(list)
+			require.NoError(t, err)
+			require.Equal(t, []string{"Eggs (expired) ✓"}, itemSummaries(caldav))
+
+			list, err = client.GetShoppingList()
+			require.NoError(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, server, client := newCalDAV()
+			defer server.Close()
+
+			err := client.ArchiveShoppingList(&config.ShoppingList{ID: "list"})
+			require.ErrorIs(t, err, clients.ErrorShoppingListNotFound)
+		})
+	})
+}
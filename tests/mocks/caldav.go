@@ -0,0 +1,124 @@
+package mocks
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/tyler-cromwell/forage/clients"
+)
+
+// An in-memory stand-in for a CalDAV task list, e.g. one in Nextcloud Tasks
+type MockCalDAV struct {
+	Username string
+	Password string
+	Requests []string
+
+	// Respond to every request with this status instead, when set
+	FailWith int
+
+	mu        sync.Mutex
+	resources map[string]string
+	order     []string
+}
+
+// The iCalendar data stored for each task, in the order they were created
+func (mc *MockCalDAV) Resources() []string {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	resources := []string{}
+	for _, href := range mc.order {
+		resources = append(resources, mc.resources[href])
+	}
+	return resources
+}
+
+// Store a task as if it was created by another client
+func (mc *MockCalDAV) Put(href, data string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	if mc.resources == nil {
+		mc.resources = map[string]string{}
+	}
+	if _, ok := mc.resources[href]; !ok {
+		mc.order = append(mc.order, href)
+	}
+	mc.resources[href] = data
+}
+
+func (mc *MockCalDAV) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	mc.mu.Lock()
+	mc.Requests = append(mc.Requests, request.Method+" "+request.URL.Path)
+	failWith := mc.FailWith
+	mc.mu.Unlock()
+
+	username, password, _ := request.BasicAuth()
+	if failWith != 0 {
+		response.WriteHeader(failWith)
+		return
+	} else if username != mc.Username || password != mc.Password {
+		response.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	href := request.URL.Path
+	switch request.Method {
+	case "REPORT":
+		mc.mu.Lock()
+		var buffer bytes.Buffer
+		buffer.WriteString(`<?xml version="1.0" encoding="utf-8"?><d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">`)
+		for _, h := range mc.order {
+			buffer.WriteString("<d:response><d:href>" + h + "</d:href><d:propstat><d:prop><cal:calendar-data>")
+			xml.EscapeText(&buffer, []byte(mc.resources[h]))
+			buffer.WriteString("</cal:calendar-data></d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat></d:response>")
+		}
+		buffer.WriteString("</d:multistatus>")
+		mc.mu.Unlock()
+		response.WriteHeader(http.StatusMultiStatus)
+		response.Write(buffer.Bytes())
+	case "PUT":
+		mc.mu.Lock()
+		_, exists := mc.resources[href]
+		mc.mu.Unlock()
+		if exists && request.Header.Get("If-None-Match") == "*" {
+			response.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := io.ReadAll(request.Body)
+		if !strings.Contains(string(body), "BEGIN:VTODO") {
+			response.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mc.Put(href, string(body))
+		if exists {
+			response.WriteHeader(http.StatusNoContent)
+		} else {
+			response.WriteHeader(http.StatusCreated)
+		}
+	case "DELETE":
+		mc.mu.Lock()
+		defer mc.mu.Unlock()
+		if _, ok := mc.resources[href]; !ok {
+			response.WriteHeader(http.StatusNotFound)
+			return
+		}
+		delete(mc.resources, href)
+		for i, h := range mc.order {
+			if h == href {
+				mc.order = append(mc.order[:i], mc.order[i+1:]...)
+				break
+			}
+		}
+		response.WriteHeader(http.StatusNoContent)
+	default:
+		response.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func NewCalDAVClientWrapper(mockServer *httptest.Server, username, password, labels string) *clients.CalDAV {
+	return clients.NewCalDAVClientWrapper(mockServer.URL+"/calendars/forage/shopping/", username, password, labels)
+}
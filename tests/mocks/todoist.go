@@ -0,0 +1,176 @@
+package mocks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/gorilla/mux"
+	"github.com/tyler-cromwell/forage/clients"
+)
+
+// An in-memory stand-in for the parts of the Todoist REST and Sync APIs the client uses
+type MockTodoist struct {
+	Projects []map[string]interface{}
+	Tasks    []map[string]interface{}
+	Requests []string
+
+	// Respond to every request with this status instead, when set
+	FailWith int
+
+	mu     sync.Mutex
+	nextID int
+}
+
+func (mt *MockTodoist) find(id string) (int, map[string]interface{}) {
+	for i, task := range mt.Tasks {
+		if task["id"] == id {
+			return i, task
+		}
+	}
+	return -1, nil
+}
+
+func (mt *MockTodoist) hasProject(id string) bool {
+	for _, project := range mt.Projects {
+		if project["id"] == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (mt *MockTodoist) respond(response http.ResponseWriter, status int, body interface{}) {
+	response.WriteHeader(status)
+	if body != nil {
+		b, _ := json.Marshal(body)
+		response.Write(b)
+	}
+}
+
+func (mt *MockTodoist) Router() *mux.Router {
+	router := mux.NewRouter().StrictSlash(true)
+	router.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+			mt.mu.Lock()
+			defer mt.mu.Unlock()
+			mt.Requests = append(mt.Requests, request.Method+" "+request.URL.Path)
+			if mt.FailWith != 0 {
+				response.WriteHeader(mt.FailWith)
+				return
+			}
+			next.ServeHTTP(response, request)
+		})
+	})
+
+	router.HandleFunc("/rest/v2/projects", func(response http.ResponseWriter, request *http.Request) {
+		mt.respond(response, http.StatusOK, mt.Projects)
+	}).Methods("GET")
+
+	router.HandleFunc("/rest/v2/tasks", func(response http.ResponseWriter, request *http.Request) {
+		projectID := request.URL.Query().Get("project_id")
+		if !mt.hasProject(projectID) {
+			mt.respond(response, http.StatusNotFound, nil)
+			return
+		}
+		tasks := []map[string]interface{}{}
+		for _, task := range mt.Tasks {
+			if task["project_id"] == projectID && task["is_completed"] != true {
+				tasks = append(tasks, task)
+			}
+		}
+		mt.respond(response, http.StatusOK, tasks)
+	}).Methods("GET")
+
+	router.HandleFunc("/rest/v2/tasks", func(response http.ResponseWriter, request *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(request.Body).Decode(&body)
+
+		mt.nextID++
+		id := fmt.Sprintf("task%d", mt.nextID)
+		task := map[string]interface{}{
+			"id":           id,
+			"project_id":   body["project_id"],
+			"parent_id":    body["parent_id"],
+			"content":      body["content"],
+			"labels":       body["labels"],
+			"url":          "https://todoist.com/showTask?id=" + id,
+			"is_completed": false,
+			"created_at":   "2022-01-01T00:00:00Z",
+		}
+		if due, ok := body["due_datetime"]; ok {
+			task["due"] = map[string]interface{}{"date": "2022-01-08", "datetime": due}
+		}
+		mt.Tasks = append(mt.Tasks, task)
+		mt.respond(response, http.StatusOK, task)
+	}).Methods("POST")
+
+	router.HandleFunc("/rest/v2/tasks/{id}", func(response http.ResponseWriter, request *http.Request) {
+		_, task := mt.find(mux.Vars(request)["id"])
+		if task == nil {
+			mt.respond(response, http.StatusNotFound, nil)
+			return
+		}
+		mt.respond(response, http.StatusOK, task)
+	}).Methods("GET")
+
+	router.HandleFunc("/rest/v2/tasks/{id}", func(response http.ResponseWriter, request *http.Request) {
+		_, task := mt.find(mux.Vars(request)["id"])
+		if task == nil {
+			mt.respond(response, http.StatusNotFound, nil)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(request.Body).Decode(&body)
+		for k, v := range body {
+			task[k] = v
+		}
+		mt.respond(response, http.StatusOK, task)
+	}).Methods("POST")
+
+	router.HandleFunc("/rest/v2/tasks/{id}", func(response http.ResponseWriter, request *http.Request) {
+		i, task := mt.find(mux.Vars(request)["id"])
+		if task == nil {
+			mt.respond(response, http.StatusNotFound, nil)
+			return
+		}
+		mt.Tasks = append(mt.Tasks[:i], mt.Tasks[i+1:]...)
+		response.WriteHeader(http.StatusNoContent)
+	}).Methods("DELETE")
+
+	router.HandleFunc("/rest/v2/tasks/{id}/close", func(response http.ResponseWriter, request *http.Request) {
+		_, task := mt.find(mux.Vars(request)["id"])
+		if task == nil {
+			mt.respond(response, http.StatusNotFound, nil)
+			return
+		}
+		task["is_completed"] = true
+		response.WriteHeader(http.StatusNoContent)
+	}).Methods("POST")
+
+	router.HandleFunc("/sync/v9/completed/get_all", func(response http.ResponseWriter, request *http.Request) {
+		projectID := request.URL.Query().Get("project_id")
+		items := []map[string]interface{}{}
+		for _, task := range mt.Tasks {
+			if task["project_id"] == projectID && task["is_completed"] == true {
+				item := map[string]interface{}{"task_id": task["id"], "content": task["content"]}
+				if request.URL.Query().Get("annotate_items") == "true" {
+					item["item_object"] = task
+				}
+				items = append(items, item)
+			}
+		}
+		mt.respond(response, http.StatusOK, map[string]interface{}{"items": items})
+	}).Methods("GET")
+
+	return router
+}
+
+func NewTodoistClientWrapper(mockServer *httptest.Server, apiToken, projectName, labels string) *clients.Todoist {
+	client := clients.NewTodoistClientWrapper(apiToken, projectName, labels)
+	client.BaseURL = mockServer.URL + "/rest/v2"
+	client.SyncURL = mockServer.URL + "/sync/v9"
+	return client
+}
@@ -4,50 +4,97 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"time"
 
 	"github.com/adlio/trello"
 	"github.com/gorilla/mux"
 	"github.com/tyler-cromwell/forage/clients"
+	"github.com/tyler-cromwell/forage/config"
 )
 
 type MockTrello struct {
-	Key                        string
-	Token                      string
-	MemberID                   string
-	BoardName                  string
-	ListName                   string
-	LabelsStr                  string
-	OverrideGetShoppingList    func() (*trello.Card, error)
-	OverrideCreateShoppingList func(*time.Time, []string, []string) (string, error)
-	OverrideAddToShoppingList  func([]string) (string, error)
-}
-
-func (mmc *MockTrello) GetShoppingList() (*trello.Card, error) {
+	Key                               string
+	Token                             string
+	MemberID                          string
+	BoardName                         string
+	ListName                          string
+	LabelsStr                         string
+	OverrideGetShoppingList           func() (*config.ShoppingList, error)
+	OverrideCreateShoppingList        func(*time.Time, []config.ShoppingItem) (string, error)
+	OverrideAddToShoppingList         func([]config.ShoppingItem) (string, error)
+	OverrideCheckOffShoppingList      func([]string) ([]string, error)
+	OverrideGetCompletedShoppingItems func() ([]string, error)
+	OverrideRemoveFromShoppingList    func([]string) error
+	OverrideGetShoppingListItems      func(*config.ShoppingList) ([]config.ShoppingItem, error)
+	OverrideArchiveShoppingList       func(*config.ShoppingList) error
+}
+
+func (mmc *MockTrello) GetShoppingList() (*config.ShoppingList, error) {
 	if mmc.OverrideGetShoppingList != nil {
 		return mmc.OverrideGetShoppingList()
 	} else {
-		var card trello.Card
-		return &card, nil
+		var list config.ShoppingList
+		return &list, nil
 	}
 }
 
-func (mmc *MockTrello) CreateShoppingList(dueDate *time.Time, applyLabels []string, listItems []string) (string, error) {
+func (mmc *MockTrello) CreateShoppingList(dueDate *time.Time, listItems []config.ShoppingItem) (string, error) {
 	if mmc.OverrideCreateShoppingList != nil {
-		return mmc.OverrideCreateShoppingList(dueDate, applyLabels, listItems)
+		return mmc.OverrideCreateShoppingList(dueDate, listItems)
 	} else {
 		return "", nil
 	}
 }
 
-func (mmc *MockTrello) AddToShoppingList(itemNames []string) (string, error) {
+func (mmc *MockTrello) AddToShoppingList(listItems []config.ShoppingItem) (string, error) {
 	if mmc.OverrideAddToShoppingList != nil {
-		return mmc.OverrideAddToShoppingList(itemNames)
+		return mmc.OverrideAddToShoppingList(listItems)
 	} else {
 		return "", nil
 	}
 }
 
+func (mmc *MockTrello) CheckOffShoppingList(names []string) ([]string, error) {
+	if mmc.OverrideCheckOffShoppingList != nil {
+		return mmc.OverrideCheckOffShoppingList(names)
+	} else {
+		return []string{}, nil
+	}
+}
+
+func (mmc *MockTrello) GetCompletedShoppingItems() ([]string, error) {
+	if mmc.OverrideGetCompletedShoppingItems != nil {
+		return mmc.OverrideGetCompletedShoppingItems()
+	} else {
+		return []string{}, nil
+	}
+}
+
+func (mmc *MockTrello) RemoveFromShoppingList(itemText []string) error {
+	if mmc.OverrideRemoveFromShoppingList != nil {
+		return mmc.OverrideRemoveFromShoppingList(itemText)
+	} else {
+		return nil
+	}
+}
+
+func (mmc *MockTrello) GetShoppingListItems(list *config.ShoppingList) ([]config.ShoppingItem, error) {
+	if mmc.OverrideGetShoppingListItems != nil {
+		return mmc.OverrideGetShoppingListItems(list)
+	} else {
+		return []config.ShoppingItem{}, nil
+	}
+}
+
+func (mmc *MockTrello) ArchiveShoppingList(list *config.ShoppingList) error {
+	if mmc.OverrideArchiveShoppingList != nil {
+		return mmc.OverrideArchiveShoppingList(list)
+	} else {
+		return nil
+	}
+}
+
 func NewTrelloClientWrapper(mockServer *httptest.Server, apiKey, apiToken, memberID, boardName, listName, labels string) *clients.Trello {
 	client := clients.Trello{
 		Key:       apiKey,
@@ -181,6 +228,28 @@ func MockListGetCardsWithCheckLists(router *mux.Router) *mux.Router {
 	return router
 }
 
+func MockGetCard(router *mux.Router) *mux.Router {
+	router.HandleFunc("/cards/{cid}", func(response http.ResponseWriter, request *http.Request) {
+		card := trello.Card{
+			ID:           mux.Vars(request)["cid"],
+			Name:         "Shopping List",
+			URL:          "www.mock.url.com",
+			IDCheckLists: []string{"groceries"},
+		}
+		c, _ := json.Marshal(card)
+		response.WriteHeader(http.StatusOK)
+		response.Write(c)
+	}).Methods("GET")
+	return router
+}
+
+func MockGetCardNotFound(router *mux.Router) *mux.Router {
+	router.HandleFunc("/cards/{cid}", func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusNotFound)
+	}).Methods("GET")
+	return router
+}
+
 func MockListAddCards(router *mux.Router) *mux.Router {
 	router.HandleFunc("/lists/{lid}/cards", func(response http.ResponseWriter, request *http.Request) {
 		card := trello.Card{
@@ -223,17 +292,13 @@ func MockCardsParamError(router *mux.Router) *mux.Router {
 
 func MockCreateChecklist(router *mux.Router) *mux.Router {
 	router.HandleFunc("/cards/{cid}/checklists", func(response http.ResponseWriter, request *http.Request) {
-		card := trello.Card{
-			ID:   "shopping_list",
-			Name: "Shopping List",
-			Checklists: []*trello.Checklist{
-				{
-					ID:   "groceries",
-					Name: "Groceries",
-				},
-			},
+		name := request.URL.Query().Get("name")
+		checklist := trello.Checklist{
+			ID:     strings.ToLower(strings.ReplaceAll(name, " ", "_")),
+			Name:   name,
+			IDCard: mux.Vars(request)["cid"],
 		}
-		c, _ := json.Marshal(card)
+		c, _ := json.Marshal(checklist)
 		response.WriteHeader(http.StatusOK)
 		response.Write(c)
 	})
@@ -300,6 +365,79 @@ func MockGetChecklistError(router *mux.Router) *mux.Router {
 	return router
 }
 
+func MockGetChecklistStale(router *mux.Router) *mux.Router {
+	router.HandleFunc("/checklists/{cid}", func(response http.ResponseWriter, request *http.Request) {
+		checklist := trello.Checklist{
+			ID:   "groceries",
+			Name: "Groceries",
+			CheckItems: []trello.CheckItem{
+				{ID: "milk", Name: "Milk (expiring)", State: "incomplete"},
+				{ID: "milk2", Name: "Milk (expiring)", State: "incomplete"},
+				{ID: "eggs", Name: "Eggs (expired)", State: "incomplete"},
+				{ID: "towels", Name: "Paper Towels", State: "incomplete"},
+				{ID: "rice", Name: "Rice (expiring)", State: "complete"},
+			},
+		}
+		c, _ := json.Marshal(checklist)
+		response.WriteHeader(http.StatusOK)
+		response.Write(c)
+	})
+	return router
+}
+
+func MockGetChecklistProgress(router *mux.Router) *mux.Router {
+	router.HandleFunc("/checklists/{cid}", func(response http.ResponseWriter, request *http.Request) {
+		checklist := trello.Checklist{
+			ID:   "groceries",
+			Name: "Groceries",
+			CheckItems: []trello.CheckItem{
+				{ID: "gyoza", Name: "Gyoza (expiring)", State: "incomplete"},
+				{ID: "milk", Name: "Milk (Horizon, Whole, expired)", State: "incomplete"},
+				{ID: "rice", Name: "Rice", State: "complete"},
+			},
+		}
+		c, _ := json.Marshal(checklist)
+		response.WriteHeader(http.StatusOK)
+		response.Write(c)
+	})
+	return router
+}
+
+func MockUpdateCheckItem(router *mux.Router) *mux.Router {
+	router.HandleFunc("/cards/{cid}/checkItem/{iid}", func(response http.ResponseWriter, request *http.Request) {
+		checkItem := trello.CheckItem{
+			ID:    mux.Vars(request)["iid"],
+			State: request.URL.Query().Get("state"),
+		}
+		c, _ := json.Marshal(checkItem)
+		response.WriteHeader(http.StatusOK)
+		response.Write(c)
+	})
+	return router
+}
+
+func MockUpdateCheckItemError(router *mux.Router) *mux.Router {
+	router.HandleFunc("/cards/{cid}/checkItem/{iid}", func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusInternalServerError)
+	})
+	return router
+}
+
+func MockDeleteCheckItem(router *mux.Router) *mux.Router {
+	router.HandleFunc("/checklists/{cid}/checkItems/{iid}", func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusOK)
+		response.Write([]byte("{}"))
+	}).Methods("DELETE")
+	return router
+}
+
+func MockDeleteCheckItemError(router *mux.Router) *mux.Router {
+	router.HandleFunc("/checklists/{cid}/checkItems/{iid}", func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusInternalServerError)
+	}).Methods("DELETE")
+	return router
+}
+
 func MockCreateCheckItem(router *mux.Router) *mux.Router {
 	router.HandleFunc("/checklists/{cid}/checkItems", func(response http.ResponseWriter, request *http.Request) {
 		checkItem := trello.CheckItem{}
@@ -317,6 +455,14 @@ func MockCreateCheckItemError(router *mux.Router) *mux.Router {
 	return router
 }
 
+func MockAddIDLabel(router *mux.Router) *mux.Router {
+	router.HandleFunc("/cards/{cid}/idLabels", func(response http.ResponseWriter, request *http.Request) {
+		response.WriteHeader(http.StatusOK)
+		response.Write([]byte("[]"))
+	})
+	return router
+}
+
 func MockAddIDLabelError(router *mux.Router) *mux.Router {
 	router.HandleFunc("/cards/{cid}/idLabels", func(response http.ResponseWriter, request *http.Request) {
 		response.WriteHeader(http.StatusInternalServerError)
@@ -6,20 +6,21 @@ import (
 	"github.com/twilio/twilio-go"
 	openapi "github.com/twilio/twilio-go/rest/api/v2010"
 	"github.com/tyler-cromwell/forage/clients"
+	"github.com/tyler-cromwell/forage/config"
 )
 
 type MockTwilio struct {
 	From                   string
 	To                     string
-	OverrideComposeMessage func(int, int, string) string
+	OverrideComposeMessage func(int, int, string, []config.ShoppingItem) string
 	OverrideSendMessage    func(string, string, string) (string, error)
 }
 
 type MockTwilioStruct struct{}
 
-func (mtc *MockTwilio) ComposeMessage(quantity, quantityExpired int, url string) string {
+func (mtc *MockTwilio) ComposeMessage(quantity, quantityExpired int, url string, items []config.ShoppingItem) string {
 	if mtc.OverrideComposeMessage != nil {
-		return mtc.OverrideComposeMessage(quantity, quantityExpired, url)
+		return mtc.OverrideComposeMessage(quantity, quantityExpired, url, items)
 	} else {
 		return ""
 	}
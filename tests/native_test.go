@@ -0,0 +1,311 @@
+package tests
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-cromwell/forage/clients"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/tests/mocks"
+	"github.com/tyler-cromwell/forage/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestNativeClient(t *testing.T) {
+	logrus.SetOutput(os.Stdout)
+
+	id := primitive.NewObjectID()
+	due := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	current := func() bson.M {
+		return bson.M{
+			"_id":     id,
+			"name":    "Shopping List",
+			"due":     due.UnixMilli(),
+			"updated": int64(1640995200000),
+			"items": primitive.A{
+				bson.M{"name": "Milk (expiring)", "checked": false},
+				bson.M{"name": "Eggs (expired)", "checked": true},
+				bson.M{"name": "Paper Towels", "checked": false},
+				bson.M{"name": "Bread (expiring)", "checked": false},
+			},
+		}
+	}
+	findCurrent := func(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+		return []bson.M{current()}, nil
+	}
+	findNone := func(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+		return []bson.M{}, nil
+	}
+	findError := func(ctx context.Context, collection string, filter bson.M, opts *options.FindOptions) ([]bson.M, error) {
+		return nil, fmt.Errorf("failed")
+	}
+
+	// Captures the filters and updates sent to the shopping lists
+	var filters []bson.D
+	var updates []bson.M
+	recordUpdate := func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+		filters = append(filters, filter)
+		updates = append(updates, update.(bson.M))
+		return 1, 1, nil
+	}
+	resetUpdates := func() {
+		filters, updates = nil, nil
+	}
+	updatedItems := func() []bson.M {
+		return updates[len(updates)-1]["$set"].(bson.M)["items"].([]bson.M)
+	}
+
+	t.Run("NewNativeClientWrapper", func(t *testing.T) {
+		client := clients.NewNativeClientWrapper(&mocks.MockMongo{}, "")
+		require.NotNil(t, client)
+	})
+
+	t.Run("GetShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{OverrideFindManyDocuments: findCurrent}, "www.mock.url.com")
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Equal(t, id.Hex(), list.ID)
+			require.Equal(t, "Shopping List", list.Name)
+			require.Equal(t, "www.mock.url.com", list.URL)
+			require.True(t, due.Equal(*list.Due))
+		})
+
+		t.Run("None", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{OverrideFindManyDocuments: findNone}, "")
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("Error", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{OverrideFindManyDocuments: findError}, "")
+			list, err := client.GetShoppingList()
+			require.Error(t, err)
+			require.Nil(t, list)
+		})
+	})
+
+	t.Run("CreateShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			var inserted bson.M
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideInsertManyDocuments: func(ctx context.Context, collection string, docs []interface{}) error {
+					require.Equal(t, config.MongoCollectionShoppingLists, collection)
+					inserted = docs[0].(bson.M)
+					return nil
+				},
+			}, "www.mock.url.com")
+
+			url, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Eggs"}, {Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "milk (expired)"}, {Name: "Cheese", Section: "Costco - Dairy"}})
+			require.NoError(t, err)
+			require.Equal(t, "www.mock.url.com", url)
+			require.Equal(t, due.UnixMilli(), inserted["due"])
+			require.Equal(t, false, inserted["archived"])
+			require.Equal(t, []bson.M{
+				{"name": "Eggs", "checked": false, "section": "Groceries"},
+				{"name": "Milk (expiring)", "checked": false, "section": "Costco - Dairy"},
+				{"name": "Cheese", "checked": false, "section": "Costco - Dairy"},
+			}, inserted["items"])
+		})
+
+		t.Run("Error", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideInsertManyDocuments: func(ctx context.Context, collection string, docs []interface{}) error {
+					return fmt.Errorf("failed")
+				},
+			}, "")
+
+			url, err := client.CreateShoppingList(&due, []config.ShoppingItem{})
+			require.Error(t, err)
+			require.Empty(t, url)
+		})
+	})
+
+	t.Run("AddToShoppingList", func(t *testing.T) {
+		t.Run("Reconcile", func(t *testing.T) {
+			resetUpdates()
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindManyDocuments: findCurrent,
+				OverrideUpdateOneDocument: recordUpdate,
+			}, "www.mock.url.com")
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expired)", Section: "Costco - Dairy"}, {Name: "Eggs (expired)"}, {Name: "Cheese (expiring)", Section: "Costco - Dairy"}})
+			require.NoError(t, err)
+			require.Equal(t, "www.mock.url.com", url)
+			require.Equal(t, []bson.M{
+				{"name": "Milk (expired)", "checked": false, "section": "Costco - Dairy"},
+				{"name": "Cheese (expiring)", "checked": false, "section": "Costco - Dairy"},
+				{"name": "Eggs (expired)", "checked": true, "section": "Groceries"},
+				{"name": "Paper Towels", "checked": false, "section": "Groceries"},
+			}, updatedItems())
+
+			// The items are only written back to the list as it was read
+			require.Equal(t, bson.D{{"_id", id}, {"updated", int64(1640995200000)}}, filters[0])
+		})
+
+		t.Run("Changed", func(t *testing.T) {
+			// The list changes between reading and writing it once, so it's read again
+			resetUpdates()
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindManyDocuments: findCurrent,
+				OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+					recordUpdate(ctx, collection, filter, update)
+					if len(updates) == 1 {
+						return 0, 0, nil
+					}
+					return 1, 1, nil
+				},
+			}, "www.mock.url.com")
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk"}})
+			require.NoError(t, err)
+			require.Equal(t, "www.mock.url.com", url)
+			require.Len(t, updates, 2)
+		})
+
+		t.Run("KeepsChanging", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindManyDocuments: findCurrent,
+				OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+					return 0, 0, nil
+				},
+			}, "")
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk"}})
+			require.ErrorIs(t, err, clients.ErrorShoppingListChanged)
+			require.Empty(t, url)
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{OverrideFindManyDocuments: findNone}, "")
+			_, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk"}})
+			require.ErrorIs(t, err, clients.ErrorShoppingListNotFound)
+		})
+
+		t.Run("ErrorUpdate", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindManyDocuments: findCurrent,
+				OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+					return 0, 0, fmt.Errorf("failed")
+				},
+			}, "")
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk"}})
+			require.Error(t, err)
+			require.Empty(t, url)
+		})
+	})
+
+	t.Run("CheckOffShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			resetUpdates()
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindManyDocuments: findCurrent,
+				OverrideUpdateOneDocument: recordUpdate,
+			}, "")
+
+			checked, err := client.CheckOffShoppingList([]string{"milk", "eggs"})
+			require.NoError(t, err)
+			require.Equal(t, []string{"Milk (expiring)"}, checked)
+
+			// Only the matching item is checked off, in place
+			require.Len(t, updates, 1)
+			require.Equal(t, bson.D{{"_id", id}, {"items", bson.M{"$elemMatch": bson.M{"name": "Milk (expiring)", "checked": false}}}}, filters[0])
+			require.Equal(t, true, updates[0]["$set"].(bson.M)["items.$.checked"])
+		})
+
+		t.Run("Changed", func(t *testing.T) {
+			// Someone else checked the item off first
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindManyDocuments: findCurrent,
+				OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+					return 0, 0, nil
+				},
+			}, "")
+
+			checked, err := client.CheckOffShoppingList([]string{"milk"})
+			require.NoError(t, err)
+			require.Empty(t, checked)
+		})
+
+		t.Run("Nothing", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{OverrideFindManyDocuments: findNone}, "")
+			checked, err := client.CheckOffShoppingList([]string{"milk"})
+			require.NoError(t, err)
+			require.Empty(t, checked)
+		})
+	})
+
+	t.Run("GetCompletedShoppingItems", func(t *testing.T) {
+		client := clients.NewNativeClientWrapper(&mocks.MockMongo{OverrideFindManyDocuments: findCurrent}, "")
+		completed, err := client.GetCompletedShoppingItems()
+		require.NoError(t, err)
+		require.Equal(t, []string{"Eggs (expired)"}, completed)
+	})
+
+	t.Run("RemoveFromShoppingList", func(t *testing.T) {
+		client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+			OverrideFindManyDocuments: findCurrent,
+			OverrideUpdateOneDocument: recordUpdate,
+		}, "")
+
+		resetUpdates()
+		err := client.RemoveFromShoppingList([]string{"Eggs (expired)"})
+		require.NoError(t, err)
+		require.Equal(t, bson.M{"items": bson.M{"name": bson.M{"$in": []string{"Eggs (expired)"}}}}, updates[0]["$pull"])
+	})
+
+	t.Run("GetShoppingListItems", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindOneDocument: func(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+					require.Equal(t, id, filter[0].Value)
+					document := current()
+					return &document, nil
+				},
+			}, "")
+
+			items, err := client.GetShoppingListItems(&config.ShoppingList{ID: id.Hex()})
+			require.NoError(t, err)
+			require.Len(t, items, 4)
+			require.True(t, items[1].Checked)
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+				OverrideFindOneDocument: func(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+					return nil, fmt.Errorf(utils.ErrorMongoNoDocuments)
+				},
+			}, "")
+
+			items, err := client.GetShoppingListItems(&config.ShoppingList{ID: id.Hex()})
+			require.ErrorIs(t, err, clients.ErrorShoppingListNotFound)
+			require.Nil(t, items)
+		})
+	})
+
+	t.Run("ArchiveShoppingList", func(t *testing.T) {
+		var archived interface{}
+		client := clients.NewNativeClientWrapper(&mocks.MockMongo{
+			OverrideFindOneDocument: func(ctx context.Context, collection string, filter bson.D) (*bson.M, error) {
+				document := current()
+				return &document, nil
+			},
+			OverrideUpdateOneDocument: func(ctx context.Context, collection string, filter bson.D, update interface{}) (int64, int64, error) {
+				archived = update.(bson.M)["$set"].(bson.M)["archived"]
+				return 1, 1, nil
+			},
+		}, "")
+
+		err := client.ArchiveShoppingList(&config.ShoppingList{ID: id.Hex()})
+		require.NoError(t, err)
+		require.Equal(t, true, archived)
+	})
+}
@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/tyler-cromwell/forage/clients"
+	"github.com/tyler-cromwell/forage/config"
+	"github.com/tyler-cromwell/forage/tests/mocks"
+)
+
+func TestTodoistClient(t *testing.T) {
+	logrus.SetOutput(os.Stdout)
+
+	due := time.Date(2022, 1, 8, 0, 0, 0, 0, time.UTC)
+	newTodoist := func() (*mocks.MockTodoist, *httptest.Server, *clients.Todoist) {
+		todoist := &mocks.MockTodoist{Projects: []map[string]interface{}{{"id": "project", "name": "Groceries"}}}
+		server := httptest.NewServer(todoist.Router())
+		client := mocks.NewTodoistClientWrapper(server, "apitoken", "Groceries", "Forage,Food")
+		return todoist, server, client
+	}
+	itemNames := func(todoist *mocks.MockTodoist) []string {
+		names := []string{}
+		for _, task := range todoist.Tasks {
+			if task["parent_id"] != nil {
+				names = append(names, task["content"].(string))
+			}
+		}
+		return names
+	}
+
+	t.Run("NewTodoistClientWrapper", func(t *testing.T) {
+		client := clients.NewTodoistClientWrapper("", "", "")
+		require.NotNil(t, client)
+	})
+
+	t.Run("GetShoppingList", func(t *testing.T) {
+		t.Run("None", func(t *testing.T) {
+			_, server, client := newTodoist()
+			defer server.Close()
+
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("ErrorProjectNotFound", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			todoist.Projects = nil
+
+			list, err := client.GetShoppingList()
+			require.ErrorIs(t, err, clients.ErrorTodoistProjectNotFound)
+			require.Nil(t, list)
+		})
+
+		t.Run("Error", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			todoist.FailWith = http.StatusInternalServerError
+
+			list, err := client.GetShoppingList()
+			require.Error(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("Cached", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{})
+			require.NoError(t, err)
+
+			todoist.Requests = nil
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Equal(t, "Shopping List", list.Name)
+			require.Equal(t, []string{"GET /rest/v2/tasks/task1"}, todoist.Requests)
+
+			// Completed elsewhere, so look for a new one
+			todoist.Tasks[0]["is_completed"] = true
+			list, err = client.GetShoppingList()
+			require.NoError(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("ProjectMoved", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			_, err := client.GetShoppingList()
+			require.NoError(t, err)
+
+			// The cached project ID went stale, so it's looked up again
+			todoist.Projects = []map[string]interface{}{{"id": "project2", "name": "Groceries"}}
+			todoist.Tasks = []map[string]interface{}{{"id": "list", "project_id": "project2", "content": "Shopping List", "created_at": "2022-01-01T00:00:00Z"}}
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.NotNil(t, list)
+			require.Equal(t, "list", list.ID)
+		})
+	})
+
+	t.Run("CreateShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+
+			url, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "milk (expired)"}, {Name: "Eggs (expired)"}})
+			require.NoError(t, err)
+			require.Equal(t, "https://todoist.com/showTask?id=task1", url)
+			require.Equal(t, []interface{}{"Forage", "Food"}, todoist.Tasks[0]["labels"])
+			require.Equal(t, []string{"Milk (expiring)", "Eggs (expired)"}, itemNames(todoist))
+
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Equal(t, "task1", list.ID)
+			require.True(t, due.Equal(*list.Due))
+		})
+
+		t.Run("ErrorProjectNotFound", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			todoist.Projects = nil
+
+			url, err := client.CreateShoppingList(&due, []config.ShoppingItem{})
+			require.ErrorIs(t, err, clients.ErrorTodoistProjectNotFound)
+			require.Empty(t, url)
+		})
+	})
+
+	t.Run("AddToShoppingList", func(t *testing.T) {
+		t.Run("Reconcile", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "Bread (expiring)"}, {Name: "Paper Towels"}, {Name: "Eggs (expired)"}})
+			require.NoError(t, err)
+			_, err = client.CheckOffShoppingList([]string{"Eggs"})
+			require.NoError(t, err)
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expired)"}, {Name: "Eggs (expired)"}, {Name: "Cheese (expiring)"}})
+			require.NoError(t, err)
+			require.Equal(t, "https://todoist.com/showTask?id=task1", url)
+			require.Equal(t, []string{"Milk (expired)", "Paper Towels", "Eggs (expired)", "Cheese (expiring)"}, itemNames(todoist))
+		})
+
+		t.Run("Sections", func(t *testing.T) {
+			_, server, client := newTodoist()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}})
+			require.NoError(t, err)
+
+			_, err = client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Bread (expiring)", Section: "Bakery"}})
+			require.NoError(t, err)
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			items, err := client.GetShoppingListItems(list)
+			require.NoError(t, err)
+			require.Equal(t, []config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Bread (expiring)", Section: "Bakery"}}, items)
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, server, client := newTodoist()
+			defer server.Close()
+
+			_, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk"}})
+			require.ErrorIs(t, err, clients.ErrorShoppingListNotFound)
+		})
+	})
+
+	t.Run("CheckOffShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			_, server, client := newTodoist()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "Eggs (expired)"}})
+			require.NoError(t, err)
+
+			checked, err := client.CheckOffShoppingList([]string{"milk"})
+			require.NoError(t, err)
+			require.Equal(t, []string{"Milk (expiring)"}, checked)
+
+			completed, err := client.GetCompletedShoppingItems()
+			require.NoError(t, err)
+			require.Equal(t, []string{"Milk (expiring)"}, completed)
+		})
+
+		t.Run("OtherTasks", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}})
+			require.NoError(t, err)
+			_, err = client.CheckOffShoppingList([]string{"milk"})
+			require.NoError(t, err)
+
+			// Chores completed in the same project aren't groceries
+			todoist.Tasks = append(todoist.Tasks,
+				map[string]interface{}{"id": "chore", "project_id": "project", "content": "Take out the trash", "is_completed": true},
+				map[string]interface{}{"id": "step", "project_id": "project", "parent_id": "chore", "content": "Recycling", "is_completed": true},
+			)
+			completed, err := client.GetCompletedShoppingItems()
+			require.NoError(t, err)
+			require.Equal(t, []string{"Milk (expiring)"}, completed)
+		})
+
+		t.Run("Nothing", func(t *testing.T) {
+			_, server, client := newTodoist()
+			defer server.Close()
+
+			checked, err := client.CheckOffShoppingList([]string{"milk"})
+			require.NoError(t, err)
+			require.Empty(t, checked)
+		})
+	})
+
+	t.Run("RemoveFromShoppingList", func(t *testing.T) {
+		todoist, server, client := newTodoist()
+		defer server.Close()
+		_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)"}, {Name: "Eggs (expired)"}, {Name: "Bread"}})
+		require.NoError(t, err)
+		_, err = client.CheckOffShoppingList([]string{"Eggs"})
+		require.NoError(t, err)
+
+		err = client.RemoveFromShoppingList([]string{"Eggs (expired)", "Bread"})
+		require.NoError(t, err)
+		require.Equal(t, []string{"Milk (expiring)"}, itemNames(todoist))
+	})
+
+	t.Run("GetShoppingListItems", func(t *testing.T) {
+		_, server, client := newTodoist()
+		defer server.Close()
+		_, err := client.CreateShoppingList(&due, []config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Eggs (expired)"}})
+		require.NoError(t, err)
+		_, err = client.CheckOffShoppingList([]string{"Eggs"})
+		require.NoError(t, err)
+
+		items, err := client.GetShoppingListItems(&config.ShoppingList{ID: "task1"})
+		require.NoError(t, err)
+		require.Equal(t, []config.ShoppingItem{{Name: "Milk (expiring)", Section: "Costco - Dairy"}, {Name: "Eggs (expired)", Checked: true, Section: "Groceries"}}, items)
+	})
+
+	t.Run("ArchiveShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			todoist, server, client := newTodoist()
+			defer server.Close()
+			_, err := client.CreateShoppingList(&due, []config.ShoppingItem{})
+			require.NoError(t, err)
+
+			err = client.ArchiveShoppingList(&config.ShoppingList{ID: "task1"})
+			require.NoError(t, err)
+			require.Equal(t, true, todoist.Tasks[0]["is_completed"])
+
+			list, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.Nil(t, list)
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, server, client := newTodoist()
+			defer server.Close()
+
+			err := client.ArchiveShoppingList(&config.ShoppingList{ID: "task1"})
+			require.Error(t, err)
+		})
+	})
+}
@@ -1,15 +1,19 @@
 package tests
 
 import (
+	"encoding/json"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
 
+	"github.com/adlio/trello"
 	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/require"
 	"github.com/tyler-cromwell/forage/clients"
+	"github.com/tyler-cromwell/forage/config"
 	"github.com/tyler-cromwell/forage/tests/mocks"
 )
 
@@ -95,6 +99,138 @@ func TestTrelloClient(t *testing.T) {
 			require.Error(t, err)
 			require.Nil(t, card)
 		})
+
+		t.Run("ErrorBoardNotFound", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Missing", "List", "Label")
+			require.NotNil(t, client)
+
+			card, err := client.GetShoppingList()
+			require.ErrorIs(t, err, clients.ErrorTrelloBoardNotFound)
+			require.Nil(t, card)
+		})
+
+		t.Run("ErrorListNotFound", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "Missing", "Label")
+			require.NotNil(t, client)
+
+			card, err := client.GetShoppingList()
+			require.ErrorIs(t, err, clients.ErrorTrelloListNotFound)
+			require.Nil(t, card)
+		})
+
+		t.Run("Cached", func(t *testing.T) {
+			paths := []string{}
+			router := mux.NewRouter().StrictSlash(true)
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					paths = append(paths, request.URL.Path)
+					next.ServeHTTP(response, request)
+				})
+			})
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCards(router)
+			router = mocks.MockGetCard(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			for i := 0; i < 2; i++ {
+				card, err := client.GetShoppingList()
+				require.NoError(t, err)
+				require.Equal(t, "shopping_list", card.ID)
+			}
+			require.Equal(t, []string{"/members/mid", "/members/mid/boards", "/boards/board/lists", "/lists/list/cards", "/cards/shopping_list"}, paths)
+		})
+
+		t.Run("CardNotFound", func(t *testing.T) {
+			paths := []string{}
+			router := mux.NewRouter().StrictSlash(true)
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					paths = append(paths, request.URL.Path)
+					next.ServeHTTP(response, request)
+				})
+			})
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCards(router)
+			router = mocks.MockGetCardNotFound(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			for i := 0; i < 2; i++ {
+				card, err := client.GetShoppingList()
+				require.NoError(t, err)
+				require.NotNil(t, card)
+			}
+			require.Equal(t, []string{"/members/mid", "/members/mid/boards", "/boards/board/lists", "/lists/list/cards", "/cards/shopping_list", "/lists/list/cards"}, paths)
+		})
+
+		t.Run("ListNotFound", func(t *testing.T) {
+			// The list is replaced between calls
+			replaced := false
+			paths := []string{}
+			router := mux.NewRouter().StrictSlash(true)
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					paths = append(paths, request.URL.Path)
+					next.ServeHTTP(response, request)
+				})
+			})
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockGetCardNotFound(router)
+			router.HandleFunc("/boards/{bid}/lists", func(response http.ResponseWriter, request *http.Request) {
+				lists := []trello.List{{ID: "list", Name: "List"}}
+				if replaced {
+					lists[0].ID = "list2"
+				}
+				l, _ := json.Marshal(lists)
+				response.WriteHeader(http.StatusOK)
+				response.Write(l)
+			})
+			router.HandleFunc("/lists/{lid}/cards", func(response http.ResponseWriter, request *http.Request) {
+				if replaced && mux.Vars(request)["lid"] == "list" {
+					response.WriteHeader(http.StatusNotFound)
+					return
+				}
+				c, _ := json.Marshal([]trello.Card{{ID: "shopping_list", Name: "Shopping List"}})
+				response.WriteHeader(http.StatusOK)
+				response.Write(c)
+			})
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			card, err := client.GetShoppingList()
+			require.NoError(t, err)
+			require.NotNil(t, card)
+
+			replaced = true
+			paths = []string{}
+			card, err = client.GetShoppingList()
+			require.NoError(t, err)
+			require.NotNil(t, card)
+			require.Equal(t, []string{"/cards/shopping_list", "/lists/list/cards", "/members/mid", "/members/mid/boards", "/boards/board/lists", "/lists/list2/cards"}, paths)
+		})
 	})
 
 	t.Run("CreateShoppingList", func(t *testing.T) {
@@ -108,13 +244,14 @@ func TestTrelloClient(t *testing.T) {
 			router = mocks.MockCardSetPos(router)
 			router = mocks.MockCreateChecklist(router)
 			router = mocks.MockCreateCheckItem(router)
+			router = mocks.MockAddIDLabel(router)
 			server := httptest.NewServer(router)
 			defer server.Close()
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.NoError(t, err)
 			require.NotNil(t, card)
 		})
@@ -128,7 +265,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -143,7 +280,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -159,7 +296,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -176,7 +313,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -194,7 +331,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"Label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -213,7 +350,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"Label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -233,7 +370,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"Label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -254,7 +391,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"Label"}, []string{"ListItem"})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{{Name: "ListItem"}})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -276,7 +413,7 @@ func TestTrelloClient(t *testing.T) {
 			require.NotNil(t, client)
 
 			dueDate := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-			card, err := client.CreateShoppingList(&dueDate, []string{"Label"}, []string{})
+			card, err := client.CreateShoppingList(&dueDate, []config.ShoppingItem{})
 			require.Error(t, err)
 			require.Empty(t, card)
 		})
@@ -296,7 +433,7 @@ func TestTrelloClient(t *testing.T) {
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
-			url, err := client.AddToShoppingList([]string{""})
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: ""}})
 			require.NoError(t, err)
 			require.NotEmpty(t, url)
 		})
@@ -314,7 +451,7 @@ func TestTrelloClient(t *testing.T) {
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
-			url, err := client.AddToShoppingList([]string{"Gyoza"})
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Gyoza"}})
 			require.NoError(t, err)
 			require.NotEmpty(t, url)
 		})
@@ -328,7 +465,7 @@ func TestTrelloClient(t *testing.T) {
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
-			url, err := client.AddToShoppingList([]string{"Gyoza"})
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Gyoza"}})
 			require.Error(t, err)
 			require.Empty(t, url)
 		})
@@ -344,7 +481,7 @@ func TestTrelloClient(t *testing.T) {
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
-			url, err := client.AddToShoppingList([]string{"Gyoza"})
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Gyoza"}})
 			require.Error(t, err)
 			require.Empty(t, url)
 		})
@@ -361,25 +498,27 @@ func TestTrelloClient(t *testing.T) {
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
-			url, err := client.AddToShoppingList([]string{"Gyoza"})
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Gyoza"}})
 			require.Error(t, err)
 			require.Empty(t, url)
 		})
 
-		t.Run("NilChecklist", func(t *testing.T) {
+		t.Run("ErrorCreateChecklist", func(t *testing.T) {
+			// Only a Supplies checklist, so the Groceries one has to be added
 			router := mux.NewRouter().StrictSlash(true)
 			router = mocks.MockGetMember(router)
 			router = mocks.MockMemberGetBoards(router)
 			router = mocks.MockBoardGetLists(router)
 			router = mocks.MockListGetCardsWithCheckLists(router)
 			router = mocks.MockGetChecklistNil(router)
+			router = mocks.MockCreateChecklistError(router)
 			router = mocks.MockCreateCheckItem(router)
 			server := httptest.NewServer(router)
 			defer server.Close()
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
-			url, err := client.AddToShoppingList([]string{""})
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: ""}})
 			require.Error(t, err)
 			require.Empty(t, url)
 		})
@@ -397,9 +536,389 @@ func TestTrelloClient(t *testing.T) {
 			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
 			require.NotNil(t, client)
 
-			url, err := client.AddToShoppingList([]string{"Gyoza"})
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Gyoza"}})
+			require.Error(t, err)
+			require.Empty(t, url)
+		})
+
+		t.Run("Reconcile", func(t *testing.T) {
+			requests := []string{}
+			router := mux.NewRouter().StrictSlash(true)
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					if request.Method != http.MethodGet {
+						requests = append(requests, request.Method+" "+request.URL.Path+" "+request.URL.Query().Get("name"))
+					}
+					next.ServeHTTP(response, request)
+				})
+			})
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistStale(router)
+			router = mocks.MockUpdateCheckItem(router)
+			router = mocks.MockDeleteCheckItem(router)
+			router = mocks.MockCreateCheckItem(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expired)"}, {Name: "Rice (expired)"}, {Name: "Bread (expiring)"}})
+			require.NoError(t, err)
+			require.NotEmpty(t, url)
+			require.Equal(t, []string{
+				"PUT /cards/shopping_list/checkItem/milk Milk (expired)",
+				"DELETE /checklists/groceries/checkItems/milk2 ",
+				"DELETE /checklists/groceries/checkItems/eggs ",
+				"POST /checklists/groceries/checkItems Bread (expiring)",
+			}, requests)
+		})
+
+		t.Run("Sections", func(t *testing.T) {
+			requests := []string{}
+			router := mux.NewRouter().StrictSlash(true)
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					if request.Method != http.MethodGet {
+						query := request.URL.Query()
+						requests = append(requests, request.Method+" "+request.URL.Path+" "+query.Get("name")+" "+query.Get("idChecklist"))
+					}
+					next.ServeHTTP(response, request)
+				})
+			})
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistStale(router)
+			router = mocks.MockCreateChecklist(router)
+			router = mocks.MockUpdateCheckItem(router)
+			router = mocks.MockDeleteCheckItem(router)
+			router = mocks.MockCreateCheckItem(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expired)", Section: "Costco - Dairy"}, {Name: "Bread (expiring)", Section: "Bakery"}})
+			require.NoError(t, err)
+			require.NotEmpty(t, url)
+			require.Equal(t, []string{
+				"POST /cards/shopping_list/checklists Costco - Dairy ",
+				"PUT /cards/shopping_list/checkItem/milk Milk (expired) costco_-_dairy",
+				"DELETE /checklists/groceries/checkItems/milk2  ",
+				"DELETE /checklists/groceries/checkItems/eggs  ",
+				"POST /cards/shopping_list/checklists Bakery ",
+				"POST /checklists/bakery/checkItems Bread (expiring) ",
+			}, requests)
+		})
+
+		t.Run("ErrorUpdateCheckItem", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistStale(router)
+			router = mocks.MockUpdateCheckItemError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{{Name: "Milk (expired)"}})
 			require.Error(t, err)
 			require.Empty(t, url)
 		})
+
+		t.Run("ErrorDeleteCheckItem", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistStale(router)
+			router = mocks.MockDeleteCheckItemError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			url, err := client.AddToShoppingList([]config.ShoppingItem{})
+			require.Error(t, err)
+			require.Empty(t, url)
+		})
+	})
+	t.Run("CheckOffShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistProgress(router)
+			router = mocks.MockUpdateCheckItem(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			checked, err := client.CheckOffShoppingList([]string{"milk", "Rice", "Eggs"})
+			require.NoError(t, err)
+			require.Equal(t, []string{"Milk (Horizon, Whole, expired)"}, checked)
+		})
+
+		t.Run("ErrorGetShoppingList", func(t *testing.T) {
+			// Same as GetShoppingList/ErrorGetMembers
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMemberError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			checked, err := client.CheckOffShoppingList([]string{"Gyoza"})
+			require.Error(t, err)
+			require.Empty(t, checked)
+		})
+
+		t.Run("ErrorIDCheckLists", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCards(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			checked, err := client.CheckOffShoppingList([]string{"Gyoza"})
+			require.Error(t, err)
+			require.Empty(t, checked)
+		})
+
+		t.Run("ErrorUpdateCheckItem", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistProgress(router)
+			router = mocks.MockUpdateCheckItemError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			checked, err := client.CheckOffShoppingList([]string{"Gyoza"})
+			require.Error(t, err)
+			require.Empty(t, checked)
+		})
+	})
+	t.Run("GetCompletedShoppingItems", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistProgress(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			completed, err := client.GetCompletedShoppingItems()
+			require.NoError(t, err)
+			require.Equal(t, []string{"Rice"}, completed)
+		})
+
+		t.Run("ErrorGetShoppingList", func(t *testing.T) {
+			// Same as GetShoppingList/ErrorGetMembers
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMemberError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			completed, err := client.GetCompletedShoppingItems()
+			require.Error(t, err)
+			require.Empty(t, completed)
+		})
+
+		t.Run("ErrorIDCheckLists", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCards(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			completed, err := client.GetCompletedShoppingItems()
+			require.Error(t, err)
+			require.Empty(t, completed)
+		})
+	})
+	t.Run("RemoveFromShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistProgress(router)
+			router = mocks.MockDeleteCheckItem(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			err := client.RemoveFromShoppingList([]string{"Rice", "Eggs"})
+			require.NoError(t, err)
+		})
+
+		t.Run("ErrorGetShoppingList", func(t *testing.T) {
+			// Same as GetShoppingList/ErrorGetMembers
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMemberError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			err := client.RemoveFromShoppingList([]string{"Rice"})
+			require.Error(t, err)
+		})
+
+		t.Run("ErrorIDCheckLists", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCards(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			err := client.RemoveFromShoppingList([]string{"Rice"})
+			require.Error(t, err)
+		})
+
+		t.Run("ErrorDeleteCheckItem", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistProgress(router)
+			router = mocks.MockDeleteCheckItemError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+
+			err := client.RemoveFromShoppingList([]string{"Rice"})
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("GetShoppingListItems", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistProgress(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+			card, err := client.GetShoppingList()
+			require.NoError(t, err)
+
+			items, err := client.GetShoppingListItems(card)
+			require.NoError(t, err)
+			require.Len(t, items, 3)
+			require.True(t, items[2].Checked)
+		})
+
+		t.Run("ErrorGetChecklist", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockGetChecklistError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+			card, err := client.GetShoppingList()
+			require.NoError(t, err)
+
+			items, err := client.GetShoppingListItems(card)
+			require.Error(t, err)
+			require.Nil(t, items)
+		})
+	})
+
+	t.Run("ArchiveShoppingList", func(t *testing.T) {
+		t.Run("Success", func(t *testing.T) {
+			paths := []string{}
+			router := mux.NewRouter().StrictSlash(true)
+			router.Use(func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
+					paths = append(paths, request.Method+" "+request.URL.Path)
+					next.ServeHTTP(response, request)
+				})
+			})
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockCardSetPos(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+			card, err := client.GetShoppingList()
+			require.NoError(t, err)
+
+			paths = []string{}
+			err = client.ArchiveShoppingList(card)
+			require.NoError(t, err)
+
+			// The archived card is no longer cached
+			_, err = client.GetShoppingList()
+			require.NoError(t, err)
+			require.Equal(t, []string{"PUT /cards/shopping_list", "GET /lists/list/cards"}, paths)
+		})
+
+		t.Run("ErrorArchive", func(t *testing.T) {
+			router := mux.NewRouter().StrictSlash(true)
+			router = mocks.MockGetMember(router)
+			router = mocks.MockMemberGetBoards(router)
+			router = mocks.MockBoardGetLists(router)
+			router = mocks.MockListGetCardsWithCheckLists(router)
+			router = mocks.MockCardsParamError(router)
+			server := httptest.NewServer(router)
+			defer server.Close()
+			client := mocks.NewTrelloClientWrapper(server, "apikey", "apitoken", "mid", "Board", "List", "Label")
+			require.NotNil(t, client)
+			card, err := client.GetShoppingList()
+			require.NoError(t, err)
+
+			err = client.ArchiveShoppingList(card)
+			require.Error(t, err)
+		})
 	})
 }
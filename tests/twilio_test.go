@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	"github.com/tyler-cromwell/forage/clients"
+	"github.com/tyler-cromwell/forage/config"
 	"github.com/tyler-cromwell/forage/tests/mocks"
 )
 
@@ -35,13 +36,30 @@ func TestTwilioClient(t *testing.T) {
 		}
 
 		for _, c := range cases {
-			got := client.ComposeMessage(c.quantity, c.quantityExpired, c.url)
+			got := client.ComposeMessage(c.quantity, c.quantityExpired, c.url, nil)
 			if got != c.want {
 				t.Errorf("ComposeMessage(%d, %d, \"%s\"), got (\"%s\"), want (\"%s\")", c.quantity, c.quantityExpired, c.url, got, c.want)
 			}
 		}
 	})
 
+	t.Run("ComposeMessageGrouped", func(t *testing.T) {
+		client := mocks.NewTwilioClientWrapper("", "", "", "")
+		require.NotNil(t, client)
+
+		items := []config.ShoppingItem{
+			{Name: "Milk (expired)", Section: "Costco - Dairy"},
+			{Name: "Bread (expiring)"},
+			{Name: "Yogurt (Chobani, Peach, expiring)", Section: "Costco - Dairy"},
+		}
+		got := client.ComposeMessage(2, 1, "http://nothing.com", items)
+		want := "2 items expiring and 1 already expired! View shopping list: http://nothing.com\n\nCostco - Dairy: Milk, Yogurt\nGroceries: Bread"
+		require.Equal(t, want, got)
+
+		got = client.ComposeMessage(0, 0, "http://nothing.com", items)
+		require.Empty(t, got)
+	})
+
 	t.Run("SendMessage", func(t *testing.T) {
 		client := mocks.NewTwilioClientWrapper("", "", "", "")
 		require.NotNil(t, client)
@@ -1,14 +1,18 @@
 package utils
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/tyler-cromwell/forage/config"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 const ErrorNoMatchedDocuments = "no document matching filter"
 const ErrorInvalidObjectID = "the provided hex string is not a valid ObjectID"
 const ErrorMongoNoDocuments = "mongo: no documents in result"
+const ErrorMemberNotFound = "member not found"
 
 func Contains(s []string, str string) bool {
 	for _, v := range s {
@@ -20,6 +24,66 @@ func Contains(s []string, str string) bool {
 	return false
 }
 
+// Append only the values not already in the list, keeping their order
+func AppendUnique(s []string, values ...string) []string {
+	for _, value := range values {
+		if !Contains(s, value) {
+			s = append(s, value)
+		}
+	}
+	return s
+}
+
+// Shopping list items are written as "Name" or "Name (details, stage)"
+func ShoppingItemName(text string) string {
+	return strings.TrimSpace(strings.SplitN(text, " (", 2)[0])
+}
+
+// The stage ("expiring" or "expired") ending an item written by the expiration job, otherwise empty
+func ShoppingItemStage(text string) string {
+	open := strings.Index(text, " (")
+	if open < 0 || !strings.HasSuffix(text, ")") {
+		return ""
+	}
+
+	details := strings.Split(text[open+2:len(text)-1], ", ")
+	stage := details[len(details)-1]
+	if stage == "expiring" || stage == "expired" {
+		return stage
+	}
+	return ""
+}
+
+// The section for an ingredient's store and aisle: "Store - Aisle", either one alone, or the default
+func ShoppingSection(store, aisle string) string {
+	store, aisle = strings.TrimSpace(store), strings.TrimSpace(aisle)
+	if store != "" && aisle != "" {
+		return store + " - " + aisle
+	} else if store != "" {
+		return store
+	} else if aisle != "" {
+		return aisle
+	}
+	return config.ShoppingSectionDefault
+}
+
+// The items in each section, with the sections in the order they first appear
+func GroupShoppingItems(items []config.ShoppingItem) ([]string, map[string][]config.ShoppingItem) {
+	sections := []string{}
+	grouped := map[string][]config.ShoppingItem{}
+	for _, item := range items {
+		section := item.Section
+		if section == "" {
+			section = config.ShoppingSectionDefault
+		}
+		if _, ok := grouped[section]; !ok {
+			sections = append(sections, section)
+		}
+		grouped[section] = append(grouped[section], item)
+	}
+	return sections, grouped
+}
+
 func ParseDatetimeFromMongoID(id string) (time.Time, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	return oid.Timestamp(), err
@@ -35,3 +99,150 @@ func StringSliceFromBsonM(documents []primitive.M, key string) []string {
 	}
 	return slice
 }
+
+// Conversion factors to grams
+var unitsMass = map[string]float64{
+	"gram":      1,
+	"kilogram":  1000,
+	"milligram": 0.001,
+	"ounce":     28.349523125,
+	"pound":     453.59237,
+}
+
+// Conversion factors to milliliters
+var unitsVolume = map[string]float64{
+	"cup":         236.5882365,
+	"fluid ounce": 29.5735295625,
+	"gallon":      3785.411784,
+	"liter":       1000,
+	"milliliter":  1,
+	"pint":        473.176473,
+	"quart":       946.352946,
+	"tablespoon":  14.78676478125,
+	"teaspoon":    4.92892159375,
+}
+
+// Common abbreviations and alternate spellings
+var unitsAliases = map[string]string{
+	"c":       "cup",
+	"fl oz":   "fluid ounce",
+	"fl. oz.": "fluid ounce",
+	"g":       "gram",
+	"gal":     "gallon",
+	"kg":      "kilogram",
+	"l":       "liter",
+	"lb":      "pound",
+	"lbs":     "pound",
+	"litre":   "liter",
+	"mg":      "milligram",
+	"ml":      "milliliter",
+	"oz":      "ounce",
+	"pt":      "pint",
+	"qt":      "quart",
+	"tbsp":    "tablespoon",
+	"tsp":     "teaspoon",
+}
+
+func NormalizeUnit(unit string) string {
+	u := strings.ToLower(strings.TrimSpace(unit))
+	if alias, ok := unitsAliases[u]; ok {
+		return alias
+	}
+
+	// Singularize known plurals (e.g. "ounces", "fluid ounces")
+	singular := strings.TrimSuffix(u, "s")
+	if _, ok := unitsMass[singular]; ok {
+		return singular
+	} else if _, ok := unitsVolume[singular]; ok {
+		return singular
+	} else if alias, ok := unitsAliases[singular]; ok {
+		return alias
+	}
+
+	return u
+}
+
+func IsUnit(unit string) bool {
+	u := NormalizeUnit(unit)
+	_, isMass := unitsMass[u]
+	_, isVolume := unitsVolume[u]
+	return isMass || isVolume
+}
+
+func ConvertUnits(value float64, from, to string) (float64, error) {
+	f := NormalizeUnit(from)
+	t := NormalizeUnit(to)
+
+	if f == t {
+		return value, nil
+	}
+
+	if mf, ok := unitsMass[f]; ok {
+		if mt, ok := unitsMass[t]; ok {
+			return value * mf / mt, nil
+		}
+	} else if vf, ok := unitsVolume[f]; ok {
+		if vt, ok := unitsVolume[t]; ok {
+			return value * vf / vt, nil
+		}
+	}
+
+	return 0, fmt.Errorf("cannot convert %s to %s", from, to)
+}
+
+func Float64FromNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+// Escape text for an iCalendar property value (RFC 5545 3.3.11)
+func ICalEscape(text string) string {
+	replacer := strings.NewReplacer("\\", "\\\\", ";", "\\;", ",", "\\,", "\r\n", "\\n", "\n", "\\n")
+	return replacer.Replace(text)
+}
+
+func ICalUnescape(text string) string {
+	replacer := strings.NewReplacer("\\\\", "\\", "\\;", ";", "\\,", ",", "\\n", "\n", "\\N", "\n")
+	return replacer.Replace(text)
+}
+
+// Split a content line into lines of at most 75 octets, continuing each with a space (RFC 5545 3.1)
+func ICalFold(line string) string {
+	var folded strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > 75 {
+			folded.WriteString("\r\n ")
+			length = 1
+		}
+		folded.WriteRune(r)
+		length += size
+	}
+	return folded.String()
+}
+
+// The content lines of an iCalendar object, with folded lines joined back together
+func ICalUnfold(data string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+		} else if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
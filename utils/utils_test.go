@@ -2,9 +2,12 @@ package utils
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/tyler-cromwell/forage/config"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -28,6 +31,87 @@ func TestUtils(t *testing.T) {
 		}
 	})
 
+	t.Run("AppendUnique", func(t *testing.T) {
+		got := AppendUnique([]string{"flour"}, "salt", "flour", "salt", "eggs")
+		want := []string{"flour", "salt", "eggs"}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("AppendUnique(%v), got (%v), want (%v)", []string{"flour"}, got, want)
+		}
+	})
+
+	t.Run("ShoppingItemName", func(t *testing.T) {
+		cases := []struct {
+			text string
+			want string
+		}{
+			{"Milk", "Milk"},
+			{"Milk (expiring)", "Milk"},
+			{"Ice Cream (Ben & Jerry's, Half Baked, expired)", "Ice Cream"},
+		}
+
+		for _, c := range cases {
+			got := ShoppingItemName(c.text)
+			if got != c.want {
+				t.Errorf("ShoppingItemName(\"%s\"), got (\"%s\"), want (\"%s\")", c.text, got, c.want)
+			}
+		}
+	})
+
+	t.Run("ShoppingItemStage", func(t *testing.T) {
+		cases := []struct {
+			text string
+			want string
+		}{
+			{"Paper Towels", ""},
+			{"Milk (expiring)", "expiring"},
+			{"Ice Cream (Ben & Jerry's, Half Baked, expired)", "expired"},
+			{"Flour (bread, not cake)", ""},
+		}
+
+		for _, c := range cases {
+			got := ShoppingItemStage(c.text)
+			if got != c.want {
+				t.Errorf("ShoppingItemStage(\"%s\"), got (\"%s\"), want (\"%s\")", c.text, got, c.want)
+			}
+		}
+	})
+
+	t.Run("ShoppingSection", func(t *testing.T) {
+		cases := []struct {
+			store string
+			aisle string
+			want  string
+		}{
+			{"Costco", "Dairy", "Costco - Dairy"},
+			{"Costco", "", "Costco"},
+			{"", " Produce ", "Produce"},
+			{"", "", "Groceries"},
+		}
+
+		for _, c := range cases {
+			got := ShoppingSection(c.store, c.aisle)
+			if got != c.want {
+				t.Errorf("ShoppingSection(\"%s\", \"%s\"), got (\"%s\"), want (\"%s\")", c.store, c.aisle, got, c.want)
+			}
+		}
+	})
+
+	t.Run("GroupShoppingItems", func(t *testing.T) {
+		items := []config.ShoppingItem{
+			{Name: "Milk (expired)", Section: "Costco - Dairy"},
+			{Name: "Paper Towels"},
+			{Name: "Cheese (expiring)", Section: "Costco - Dairy"},
+		}
+
+		sections, grouped := GroupShoppingItems(items)
+		if fmt.Sprint(sections) != "[Costco - Dairy Groceries]" {
+			t.Errorf("GroupShoppingItems(%v), got sections (%v)", items, sections)
+		}
+		if len(grouped["Costco - Dairy"]) != 2 || grouped["Groceries"][0].Name != "Paper Towels" {
+			t.Errorf("GroupShoppingItems(%v), got (%v)", items, grouped)
+		}
+	})
+
 	t.Run("ParseDatetimeFromMongoID", func(t *testing.T) {
 		t1, _ := time.Parse("2006-01-02T15:04:05.000Z", "2021-11-07T14:40:54.000Z")
 
@@ -78,4 +162,130 @@ func TestUtils(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("NormalizeUnit", func(t *testing.T) {
+		cases := []struct {
+			unit string
+			want string
+		}{
+			{"ounces", "ounce"},
+			{"Fluid Ounces", "fluid ounce"},
+			{"tbsp", "tablespoon"},
+			{"lbs", "pound"},
+			{"piece", "piece"},
+		}
+
+		for _, c := range cases {
+			got := NormalizeUnit(c.unit)
+			if got != c.want {
+				t.Errorf("NormalizeUnit(\"%s\"), got (\"%s\"), want (\"%s\")", c.unit, got, c.want)
+			}
+		}
+	})
+
+	t.Run("IsUnit", func(t *testing.T) {
+		cases := []struct {
+			unit string
+			want bool
+		}{
+			{"cups", true},
+			{"g", true},
+			{"Fl Oz", true},
+			{"garlic", false},
+		}
+
+		for _, c := range cases {
+			got := IsUnit(c.unit)
+			if got != c.want {
+				t.Errorf("IsUnit(\"%s\"), got (%t), want (%t)", c.unit, got, c.want)
+			}
+		}
+	})
+
+	t.Run("ConvertUnits", func(t *testing.T) {
+		cases := []struct {
+			value float64
+			from  string
+			to    string
+			want  float64
+			err   bool
+		}{
+			{2, "pound", "ounces", 32, false},
+			{1, "cup", "tablespoon", 16, false},
+			{3, "count", "count", 3, false},
+			{1, "cup", "ounce", 0, true},
+			{1, "piece", "gram", 0, true},
+		}
+
+		for _, c := range cases {
+			got, err := ConvertUnits(c.value, c.from, c.to)
+			if (err != nil) != c.err || math.Abs(got-c.want) > 0.0001 {
+				t.Errorf("ConvertUnits(%f, \"%s\", \"%s\"), got (%f, \"%s\"), want (%f, %t)", c.value, c.from, c.to, got, err, c.want, c.err)
+			}
+		}
+	})
+
+	t.Run("Float64FromNumber", func(t *testing.T) {
+		cases := []struct {
+			value interface{}
+			want  float64
+			ok    bool
+		}{
+			{int32(4), 4, true},
+			{int64(5), 5, true},
+			{2.5, 2.5, true},
+			{"6", 0, false},
+		}
+
+		for _, c := range cases {
+			got, ok := Float64FromNumber(c.value)
+			if got != c.want || ok != c.ok {
+				t.Errorf("Float64FromNumber(%v), got (%f, %t), want (%f, %t)", c.value, got, ok, c.want, c.ok)
+			}
+		}
+	})
+
+	t.Run("ICalEscape", func(t *testing.T) {
+		cases := []struct {
+			text string
+			want string
+		}{
+			{"Milk", "Milk"},
+			{"Ice Cream (Ben & Jerry's, Half Baked; pint)", "Ice Cream (Ben & Jerry's\\, Half Baked\\; pint)"},
+			{"C:\\Recipes", "C:\\\\Recipes"},
+			{"Line one\nLine two", "Line one\\nLine two"},
+		}
+
+		for _, c := range cases {
+			got := ICalEscape(c.text)
+			if got != c.want {
+				t.Errorf("ICalEscape(\"%s\"), got (\"%s\"), want (\"%s\")", c.text, got, c.want)
+			} else if ICalUnescape(got) != c.text {
+				t.Errorf("ICalUnescape(\"%s\"), got (\"%s\"), want (\"%s\")", got, ICalUnescape(got), c.text)
+			}
+		}
+	})
+
+	t.Run("ICalFold", func(t *testing.T) {
+		short := "SUMMARY:Milk"
+		long := "SUMMARY:" + strings.Repeat("é", 40)
+		cases := []struct {
+			line string
+			want []string
+		}{
+			{short, []string{short}},
+			{long, []string{"SUMMARY:" + strings.Repeat("é", 33), " " + strings.Repeat("é", 7)}},
+		}
+
+		for _, c := range cases {
+			got := ICalFold(c.line)
+			if got != strings.Join(c.want, "\r\n") {
+				t.Errorf("ICalFold(\"%s\"), got (%q), want (%q)", c.line, got, strings.Join(c.want, "\r\n"))
+			}
+			unfolded := ICalUnfold(got + "\r\n")
+			if len(unfolded) != 1 || unfolded[0] != c.line {
+				t.Errorf("ICalUnfold(%q), got (%q), want ([%q])", got, unfolded, c.line)
+			}
+		}
+	})
 }